@@ -0,0 +1,97 @@
+// Command evalgen generates a synthetic golden set for the eval command from
+// the indexed phones: natural-language queries built from their specs, each
+// with the IDs of every phone matching the mentioned specs.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/eval"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "evalgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		host        = flag.String("qdrant-host", "localhost", "Qdrant host")
+		port        = flag.Int("qdrant-port", 6334, "Qdrant gRPC port")
+		apiKey      = flag.String("qdrant-api-key", os.Getenv("QDRANT_API_KEY"), "Qdrant API key")
+		useTLS      = flag.Bool("qdrant-tls", false, "connect to Qdrant over TLS")
+		count       = flag.Int("n", 100, "number of queries to generate")
+		maxRelevant = flag.Int("max-relevant", 20, "skip queries matching more phones than this (0 keeps all)")
+		seed        = flag.Uint64("seed", 1, "random seed")
+		out         = flag.String("o", "", "output file (default stdout)")
+	)
+
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := appqdrant.NewClient(appqdrant.ClientOptions{Host: *host, Port: *port, APIKey: *apiKey, TLS: *useTLS})
+	if err != nil {
+		return fmt.Errorf("connecting to qdrant: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	var phones []model.Smartphone
+
+	for phone, err := range appqdrant.NewSearcher(client, nil, appqdrant.Options{}).All(ctx) {
+		if err != nil {
+			return err
+		}
+
+		phones = append(phones, phone)
+	}
+
+	cases := eval.Generate(phones, eval.GenerateOptions{Count: *count, MaxRelevant: *maxRelevant, Seed: *seed})
+	if len(cases) == 0 {
+		return fmt.Errorf("no queries generated from %d phones", len(phones))
+	}
+
+	var w io.Writer = os.Stdout
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("creating output: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	for _, c := range cases {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing cases: %w", err)
+	}
+
+	if len(cases) < *count {
+		fmt.Fprintf(os.Stderr, "generated %d of %d queries; raise -max-relevant for more\n", len(cases), *count)
+	}
+
+	return nil
+}
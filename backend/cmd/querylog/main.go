@@ -0,0 +1,89 @@
+// Command querylog exports the searches in an analytics directory, joined
+// with the clicks on their results, as NDJSON for offline analysis.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "querylog:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		dir      = flag.String("dir", "data/analytics", "analytics directory (ANALYTICS_DIR of the server)")
+		sinceArg = flag.String("since", "", "first day or RFC 3339 time to export (default 7 days ago)")
+		untilArg = flag.String("until", "", "export searches before this day or RFC 3339 time (default now)")
+		out      = flag.String("o", "", "output file (default stdout)")
+	)
+
+	flag.Parse()
+
+	since := time.Now().Add(-7 * 24 * time.Hour)
+
+	if *sinceArg != "" {
+		t, err := analytics.ParseTime(*sinceArg)
+		if err != nil {
+			return fmt.Errorf("-since: %w", err)
+		}
+
+		since = t
+	}
+
+	var until time.Time
+
+	if *untilArg != "" {
+		t, err := analytics.ParseTime(*untilArg)
+		if err != nil {
+			return fmt.Errorf("-until: %w", err)
+		}
+
+		until = t
+	}
+
+	records, err := analytics.ExportQueries(analytics.ReadEvents(*dir, since), until)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("creating output: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing records: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d searches\n", len(records))
+
+	return nil
+}
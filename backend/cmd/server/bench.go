@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/bench"
+)
+
+// runBench replays a query file against a running server and reports the
+// p50, p95 and p99 of the end-to-end, embedding and Qdrant latencies,
+// optionally failing when the end-to-end p95 regressed from an earlier JSON
+// report.
+func runBench(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+
+	var (
+		baseURL       = fs.String("url", "http://localhost:8080", "base URL of the running API server")
+		queriesPath   = fs.String("queries", "", "NDJSON file of queries, such as an eval golden set (required)")
+		limit         = fs.Int("limit", 20, "results per search")
+		rounds        = fs.Int("rounds", 3, "times every query is sent")
+		concurrency   = fs.Int("concurrency", 1, "requests in flight at once")
+		asJSON        = fs.Bool("json", false, "print the full report as JSON")
+		baseline      = fs.String("baseline", "", "JSON report of an earlier run to compare against")
+		maxRegression = fs.Float64("max-regression", 0, "fail when the end-to-end p95 is this many percent slower than -baseline; 0 only reports")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case *queriesPath == "":
+		return errors.New("-queries is required")
+	case *limit < 1 || *limit > 100:
+		return errors.New("limit must be between 1 and 100")
+	case *rounds < 1:
+		return errors.New("rounds must be at least 1")
+	case *concurrency < 1:
+		return errors.New("concurrency must be at least 1")
+	case *maxRegression < 0:
+		return errors.New("max-regression must not be negative")
+	}
+
+	f, err := os.Open(*queriesPath)
+	if err != nil {
+		return fmt.Errorf("opening queries: %w", err)
+	}
+
+	queries, err := bench.LoadQueries(f)
+	_ = f.Close()
+
+	if err != nil {
+		return fmt.Errorf("loading queries: %w", err)
+	}
+
+	runner := &bench.Runner{
+		BaseURL:     *baseURL,
+		Limit:       *limit,
+		Rounds:      *rounds,
+		Concurrency: *concurrency,
+		Client:      &http.Client{Timeout: 30 * time.Second},
+	}
+	report := runner.Run(ctx, queries)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if err := report.WriteText(os.Stdout); err != nil {
+		return err
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d requests failed", report.Failed, report.Requests)
+	}
+
+	if *baseline == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(*baseline)
+	if err != nil {
+		return fmt.Errorf("reading baseline: %w", err)
+	}
+
+	var base bench.Report
+	if err := json.Unmarshal(b, &base); err != nil {
+		return fmt.Errorf("parsing baseline: %w", err)
+	}
+
+	if err := report.WriteComparison(os.Stderr, base); err != nil {
+		return err
+	}
+
+	if r := report.Regression(base) * 100; *maxRegression > 0 && r > *maxRegression {
+		return fmt.Errorf("end-to-end p95 regressed %.1f%%, more than %g%%", r, *maxRegression)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/csvparser"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// runDoctor checks the configuration, the directories, the dataset, Qdrant
+// and the collection schema, and the embedder and its models, printing one
+// line per check with what to do about failures, and fails when any check
+// does.
+func runDoctor(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := configFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	failed := 0
+	report := func(check string, err error, detail string) {
+		if err != nil {
+			failed++
+			// Joined errors continue on indented lines.
+			fmt.Fprintf(os.Stdout, "FAIL  %-14s %s\n", check, strings.ReplaceAll(err.Error(), "\n", "\n"+strings.Repeat(" ", 21)))
+
+			return
+		}
+
+		fmt.Fprintf(os.Stdout, "ok    %-14s %s\n", check, detail)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	report("configuration", err, "valid")
+
+	if err != nil {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+
+	report("directories", errors.Join(cfg.Preflight()...), "writable")
+
+	// A read-only server never seeds, so it does not need the dataset.
+	if !cfg.ReadOnly {
+		detail, err := checkDataset(cfg.Data.CSVPath)
+		report("dataset", err, detail)
+	}
+
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	embedClient := newEmbedder(cfg)
+	searcher := appqdrant.NewSearcher(client, embedClient, qdrantOptions(cfg))
+
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	status, err := searcher.Status(checkCtx)
+
+	switch {
+	case err != nil:
+		report("qdrant", fmt.Errorf("%w\ncheck QDRANT_HOST, QDRANT_PORT and the QDRANT_TLS settings", err), "")
+	case !status.Exists:
+		report("qdrant", fmt.Errorf("collection %s not found; run the seed command", cfg.Qdrant.Collection), "")
+	case status.SchemaVersion < appqdrant.PayloadSchemaVersion:
+		report("qdrant", fmt.Errorf("payload schema version %d is older than %d; run the migrate command", status.SchemaVersion, appqdrant.PayloadSchemaVersion), "")
+	default:
+		report("qdrant", nil, fmt.Sprintf("collection %s holds %d points", cfg.Qdrant.Collection, status.Points))
+	}
+
+	if status.Exists {
+		err := searcher.CheckSchema(checkCtx)
+		if err != nil {
+			hint := "run the migrate-collection command to apply the changes"
+			if errors.Is(err, appqdrant.ErrVectorMismatch) {
+				hint = "run migrate-collection -dry-run to see whether the collection needs a reseed"
+			}
+
+			err = fmt.Errorf("%w\n%s", err, hint)
+		}
+
+		report("schema", err, "vectors and payload indexes match")
+	}
+
+	err = searcher.EmbedderHealth(checkCtx)
+	if err != nil {
+		report("embedder", fmt.Errorf("%w\ncheck EMBEDDER_URL and that the embedding service is running", err), "")
+	} else {
+		report("embedder", nil, cfg.Embedder.URL)
+	}
+
+	if err == nil {
+		imageSize, textSize, err := searcher.EmbeddingSizes(checkCtx)
+		if errors.Is(err, appqdrant.ErrVectorMismatch) {
+			err = fmt.Errorf("%w\nthe embedder serves other models than the collection expects", err)
+		}
+
+		report("models", err, fmt.Sprintf("image vectors have %d dimensions, text vectors %d", imageSize, textSize))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+
+	return nil
+}
+
+// checkDataset parses the CSV dataset the seed command imports, reporting
+// how many rows would be indexed.
+func checkDataset(path string) (string, error) {
+	phones, err := csvparser.ParseFile(path)
+
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("%w\nset CSV_PATH to the smartphones CSV file", err)
+	case len(phones) == 0:
+		return "", fmt.Errorf("%s holds no phones", path)
+	}
+
+	rejected := 0
+
+	for _, p := range phones {
+		if len(p.Validate()) > 0 {
+			rejected++
+		}
+	}
+
+	return fmt.Sprintf("%s holds %d phones, %d failing data validation", path, len(phones), rejected), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/eval"
+)
+
+// runEval runs a golden set of queries against a running server and reports
+// NDCG, recall and MRR at a cutoff, optionally compared to an earlier JSON
+// report.
+func runEval(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+
+	var (
+		baseURL  = fs.String("url", "http://localhost:8080", "base URL of the running API server")
+		golden   = fs.String("golden", "", "NDJSON file of golden cases (required)")
+		k        = fs.Int("k", 10, "ranking cutoff")
+		asJSON   = fs.Bool("json", false, "print the full report as JSON")
+		baseline = fs.String("baseline", "", "JSON report of an earlier run to compare against")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *golden == "" {
+		return errors.New("-golden is required")
+	}
+
+	if *k < 1 || *k > 100 {
+		return errors.New("k must be between 1 and 100")
+	}
+
+	f, err := os.Open(*golden)
+	if err != nil {
+		return fmt.Errorf("opening golden set: %w", err)
+	}
+
+	cases, err := eval.LoadCases(f)
+	_ = f.Close()
+
+	if err != nil {
+		return fmt.Errorf("loading golden set: %w", err)
+	}
+
+	runner := &eval.Runner{BaseURL: *baseURL, K: *k, Client: &http.Client{Timeout: 30 * time.Second}}
+	report := runner.Run(ctx, cases)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if err := report.WriteText(os.Stdout); err != nil {
+		return err
+	}
+
+	if *baseline != "" {
+		b, err := os.ReadFile(*baseline)
+		if err != nil {
+			return fmt.Errorf("reading baseline: %w", err)
+		}
+
+		var base eval.Report
+		if err := json.Unmarshal(b, &base); err != nil {
+			return fmt.Errorf("parsing baseline: %w", err)
+		}
+
+		if err := report.WriteComparison(os.Stderr, base); err != nil {
+			return err
+		}
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d cases failed", report.Failed, report.Cases)
+	}
+
+	return nil
+}
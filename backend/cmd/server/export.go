@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// runExport writes every indexed phone as one JSON object per line.
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	configPath := configFlag(fs)
+	out := fs.String("o", "", "output file (default stdout)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	var w io.Writer = os.Stdout
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("creating output: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	searcher := appqdrant.NewSearcher(client, nil, qdrantOptions(cfg))
+
+	var n int
+
+	for phone, err := range searcher.All(ctx) {
+		if err != nil {
+			return fmt.Errorf("reading phones: %w", err)
+		}
+
+		if err := enc.Encode(phone); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+
+		n++
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d phones\n", n)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/bench"
+)
+
+// runLoadTest sends a weighted mix of text searches, image searches and
+// filtered browses from concurrent workers against a running server and
+// reports the throughput and latency percentiles, for capacity planning.
+func runLoadTest(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+
+	var (
+		baseURL     = fs.String("url", "http://localhost:8080", "base URL of the API server under test")
+		queriesPath = fs.String("queries", "", "NDJSON file of queries; text searches use them, browses their filters")
+		imagesDir   = fs.String("images", "", "directory of photos uploaded by image searches")
+		mixFlag     = fs.String("mix", "text=80,browse=20", "weights of the request kinds: text, image and browse")
+		limit       = fs.Int("limit", 20, "results per request")
+		concurrency = fs.Int("concurrency", 8, "workers, each sending its next request once answered")
+		duration    = fs.Duration("duration", 30*time.Second, "how long to send requests")
+		asJSON      = fs.Bool("json", false, "print the full report as JSON")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mix, err := bench.ParseMix(*mixFlag)
+	if err != nil {
+		return fmt.Errorf("parsing -mix: %w", err)
+	}
+
+	switch {
+	case mix[bench.KindText] > 0 && *queriesPath == "":
+		return errors.New("-queries is required for text searches")
+	case mix[bench.KindImage] > 0 && *imagesDir == "":
+		return errors.New("-images is required for image searches")
+	case *limit < 1 || *limit > 100:
+		return errors.New("limit must be between 1 and 100")
+	case *concurrency < 1:
+		return errors.New("concurrency must be at least 1")
+	case *duration <= 0:
+		return errors.New("duration must be positive")
+	}
+
+	runner := &bench.LoadRunner{
+		BaseURL:     *baseURL,
+		Limit:       *limit,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Mix:         mix,
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+			// One idle connection per worker, as that many browsers would keep.
+			Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+		},
+	}
+
+	if *queriesPath != "" {
+		f, err := os.Open(*queriesPath)
+		if err != nil {
+			return fmt.Errorf("opening queries: %w", err)
+		}
+
+		runner.Queries, err = bench.LoadQueries(f)
+		_ = f.Close()
+
+		if err != nil {
+			return fmt.Errorf("loading queries: %w", err)
+		}
+	}
+
+	if *imagesDir != "" && mix[bench.KindImage] > 0 {
+		if runner.Images, err = bench.LoadImages(*imagesDir); err != nil {
+			return err
+		}
+	}
+
+	report := runner.Run(ctx)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(report)
+	}
+
+	return report.WriteText(os.Stdout)
+}
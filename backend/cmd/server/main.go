@@ -1,75 +1,185 @@
-// Package main is the entry point for the smartphone search engine.
+// Package main is the smartphone search engine: the HTTP server and the
+// tools operating on its collection, run as subcommands.
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
-	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/alessandrolattao/qdrant-experiment/internal/config"
 	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+	"github.com/alessandrolattao/qdrant-experiment/internal/logging"
 	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
-	"github.com/alessandrolattao/qdrant-experiment/internal/server"
+	"github.com/alessandrolattao/qdrant-experiment/internal/translate"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
 )
 
-func main() {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
+// command is a subcommand; run parses its own flags from args.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
 
-	slog.Info("starting qdrant smartphone search engine")
+// logOut and logLevel are the destination and minimum level of the logs;
+// loadConfig applies the configured format and level.
+var (
+	logOut   io.Writer = os.Stderr
+	logLevel           = new(slog.LevelVar)
+)
 
-	qdrantHost := getEnv("QDRANT_HOST", "localhost")
-	qdrantPort := getEnvInt("QDRANT_PORT", 6334)
-	embedderURL := getEnv("EMBEDDER_URL", "http://localhost:8000")
-	listenAddr := getEnv("LISTEN_ADDR", ":8080")
-	imagesDir := getEnv("IMAGES_DIR", "images")
+var commands = []command{
+	{"serve", "run the HTTP API server, seeding a missing collection (default)", runServe},
+	{"seed", "import the dataset into a missing collection and exit", runSeed},
+	{"export", "write the indexed phones as NDJSON", runExport},
+	{"eval", "score a golden query set against a running server", runEval},
+	{"bench", "measure search latency against a running server", runBench},
+	{"loadtest", "send a concurrent mix of searches and browses to a server and report throughput", runLoadTest},
+	{"snapshot", "compare search rankings of a running server with a recorded snapshot", runSnapshot},
+	{"mock-embedder", "serve the embedder API with deterministic vectors, for snapshots", runMockEmbedder},
+	{"migrate", "upgrade the collection payloads to the current schema", runMigrate},
+	{"migrate-collection", "apply vector and payload index changes to the collection", runMigrateCollection},
+	{"doctor", "check the configuration and the services the server depends on", runDoctor},
+}
 
-	client, err := appqdrant.NewClient(qdrantHost, qdrantPort)
-	if err != nil {
-		slog.Error("failed to connect to qdrant", slog.String("error", err.Error()))
-		os.Exit(1)
+func main() {
+	// Without a subcommand, or with only flags, the server starts as it
+	// did before subcommands existed.
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	embedClient := embedder.NewClient(embedderURL)
-	seeder := appqdrant.NewSeeder(client, embedClient, "data/smartphones.csv", imagesDir)
+	if name == "help" {
+		usage(os.Stdout)
+		return
+	}
+
+	i := slices.IndexFunc(commands, func(c command) bool { return c.name == name })
+	if i < 0 {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
 
-	go func() {
-		if err := seeder.SeedIfNeeded(); err != nil {
-			slog.Error("seed failed", slog.String("error", err.Error()))
-		}
-	}()
+	// The tools log to stderr, keeping stdout for their output.
+	logOut = os.Stderr
+	if name == "serve" {
+		logOut = os.Stdout
+	}
 
-	searcher := appqdrant.NewSearcher(client, embedClient)
-	srv := server.New(searcher, imagesDir)
+	slog.SetDefault(logging.New(logOut, logging.FormatText, logLevel))
 
-	slog.Info("server listening", slog.String("addr", listenAddr))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := commands[i].run(ctx, args)
+	stop()
 
-	if err := http.ListenAndServe(listenAddr, srv.Handler()); err != nil {
-		slog.Error("server failed", slog.String("error", err.Error()))
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case err != nil:
+		slog.Error(name+" failed", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 }
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// usage lists the subcommands.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-18s %s\n", c.name, c.summary)
 	}
 
-	return fallback
+	fmt.Fprintln(w, "\nRun a command with -h for its flags.")
+}
+
+// configFlag registers the -config flag, defaulting to $CONFIG_FILE.
+func configFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
 }
 
-func getEnvInt(key string, fallback int) int {
-	v := os.Getenv(key)
-	if v == "" {
-		return fallback
+// loadConfig loads the configuration at path and applies its log settings.
+func loadConfig(path string) (config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("loading configuration: %w", err)
 	}
 
-	n, err := strconv.Atoi(v)
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logLevel.Set(level)
+	slog.SetDefault(logging.New(logOut, cfg.Log.Format, logLevel))
+
+	return cfg, nil
+}
+
+// qdrantOptions returns the collection settings of cfg for the Searchers and
+// Seeders of the commands.
+func qdrantOptions(cfg config.Config) appqdrant.Options {
+	return appqdrant.Options{
+		Collection:             cfg.Qdrant.Collection,
+		BatchSize:              cfg.Qdrant.BatchSize,
+		DownloadConcurrency:    cfg.Qdrant.DownloadConcurrency,
+		DownloadUserAgent:      cfg.Qdrant.DownloadUserAgent,
+		DownloadHostInterval:   time.Duration(cfg.Qdrant.DownloadHostIntervalMS) * time.Millisecond,
+		DownloadBytesPerSecond: int64(cfg.Qdrant.DownloadBandwidthKB) << 10,
+		Multivector:            cfg.Qdrant.Multivector,
+		SpecsVector:            cfg.Qdrant.SpecsVector,
+		SpecsWeight:            cfg.Qdrant.SpecsWeight,
+		ModelMatchBoost:        cfg.Qdrant.ModelMatchBoost,
+		BinaryQuantization:     cfg.Qdrant.BinaryQuantization,
+		Oversampling:           cfg.Qdrant.Oversampling,
+		Distance:               cfg.Qdrant.Distance,
+		ImageDatatype:          cfg.Qdrant.ImageDatatype,
+		TextDatatype:           cfg.Qdrant.TextDatatype,
+	}
+}
+
+// errReadOnly rejects the commands changing the collection under READ_ONLY.
+var errReadOnly = errors.New("READ_ONLY is set; the collection cannot be changed")
+
+// connect opens the Qdrant client of cfg; the caller closes it.
+func connect(cfg config.Config) (*qdrantclient.Client, error) {
+	client, err := appqdrant.NewClient(appqdrant.ClientOptions{
+		Host:             cfg.Qdrant.Host,
+		Port:             cfg.Qdrant.Port,
+		APIKey:           cfg.Qdrant.APIKey,
+		TLS:              cfg.Qdrant.TLS,
+		CAFile:           cfg.Qdrant.CAFile,
+		ServerName:       cfg.Qdrant.ServerName,
+		CertFile:         cfg.Qdrant.CertFile,
+		KeyFile:          cfg.Qdrant.KeyFile,
+		PoolSize:         uint(cfg.Qdrant.PoolSize),
+		KeepAliveSeconds: cfg.Qdrant.KeepAliveSeconds,
+		MaxMessageBytes:  cfg.Qdrant.MaxMessageMB << 20,
+	})
 	if err != nil {
-		return fallback
+		return nil, fmt.Errorf("connecting to qdrant: %w", err)
+	}
+
+	return client, nil
+}
+
+// newEmbedder returns the embedder client of cfg.
+func newEmbedder(cfg config.Config) *embedder.Client {
+	return embedder.NewClient(cfg.Embedder.URL, cfg.Embedder.Token, time.Duration(cfg.Embedder.TimeoutSeconds)*time.Second)
+}
+
+// newTranslator returns the query translator of cfg, nil when translation
+// is not configured.
+func newTranslator(cfg config.Config) translate.Translator {
+	if cfg.Translate.URL == "" {
+		return nil
 	}
 
-	return n
+	return translate.NewClient(cfg.Translate.URL, cfg.Translate.APIKey, time.Duration(cfg.Translate.TimeoutSeconds)*time.Second)
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// runMigrate upgrades the payloads of an existing collection to the current
+// payload schema by re-running the spec parsers on the stored raw strings,
+// without re-embedding the phones.
+func runMigrate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	configPath := configFlag(fs)
+	dryRun := fs.Bool("dry-run", false, "only report the pending migrations")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if cfg.ReadOnly && !*dryRun {
+		return errReadOnly
+	}
+
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	result, err := appqdrant.Migrate(ctx, client, qdrantOptions(cfg), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.From >= result.To:
+		fmt.Fprintf(os.Stderr, "payload schema already at version %d\n", result.From)
+	case *dryRun:
+		fmt.Fprintf(os.Stderr, "would migrate %d points from version %d to %d\n", result.Points, result.From, result.To)
+	default:
+		fmt.Fprintf(os.Stderr, "migrated %d points from version %d to %d\n", result.Points, result.From, result.To)
+	}
+
+	return nil
+}
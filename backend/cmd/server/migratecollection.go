@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// runMigrateCollection compares the vectors and payload indexes of the
+// collection with the ones the code expects and applies the changes that
+// need no reseed: payload indexes in place, vectors by reindexing into a
+// new collection behind an alias.
+func runMigrateCollection(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate-collection", flag.ContinueOnError)
+	configPath := configFlag(fs)
+	dryRun := fs.Bool("dry-run", false, "only report the changes")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if cfg.ReadOnly && !*dryRun {
+		return errReadOnly
+	}
+
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	seeder := appqdrant.NewSeeder(client, newEmbedder(cfg), cfg.Data.CSVPath, cfg.Data.ImagesDir, qdrantOptions(cfg))
+
+	changes, err := seeder.DiffSchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(os.Stderr, "collection schema is current")
+		return nil
+	}
+
+	reseed := 0
+
+	for _, c := range changes {
+		fmt.Fprintf(os.Stderr, "%-8s  %s\n", c.Remedy, c.Description)
+
+		if c.Remedy == appqdrant.RemedyReseed {
+			reseed++
+		}
+	}
+
+	if reseed > 0 {
+		return fmt.Errorf("%d of %d changes need a full reseed: delete the collection and run seed", reseed, len(changes))
+	}
+
+	if *dryRun {
+		return nil
+	}
+
+	if err := seeder.ApplySchema(ctx, changes); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "applied %d changes\n", len(changes))
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+)
+
+// runMockEmbedder serves the embedding service API with deterministic
+// vectors, standing in for the model service in snapshot runs and local
+// development without models.
+func runMockEmbedder(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("mock-embedder", flag.ContinueOnError)
+	addr := fs.String("addr", ":8000", "listen address")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: embedder.NewMock(), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("mock embedder listening", slog.String("addr", *addr))
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
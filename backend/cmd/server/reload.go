@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/config"
+)
+
+// watchConfig reloads the configuration on SIGHUP, and every interval when
+// the file at path changed, until ctx is cancelled. Each valid configuration
+// is passed to apply; an invalid one, or one apply rejects, keeps the
+// previous settings. Changes to settings that need a restart are logged.
+func watchConfig(ctx context.Context, path string, interval time.Duration, running config.Config, apply func(config.Config) error) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+
+	if path != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	modTime := fileModTime(path)
+	applied := running
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.InfoContext(ctx, "reload signal received")
+		case <-tick:
+			if fileModTime(path).Equal(modTime) {
+				continue
+			}
+		}
+
+		modTime = fileModTime(path)
+
+		next, err := config.Load(path)
+		if err != nil {
+			slog.WarnContext(ctx, "reloading configuration failed", slog.String("error", err.Error()))
+			continue
+		}
+
+		// Restart-only settings are compared with the running ones, so a
+		// pending change is reported on every reload until a restart.
+		if _, restart := config.Changed(running, next); len(restart) > 0 {
+			slog.WarnContext(ctx, "configuration changes need a restart", slog.Any("settings", restart))
+		}
+
+		if err := apply(next); err != nil {
+			slog.WarnContext(ctx, "reloading configuration failed", slog.String("error", err.Error()))
+			continue
+		}
+
+		if reloaded, _ := config.Changed(applied, next); len(reloaded) > 0 {
+			slog.InfoContext(ctx, "configuration reloaded", slog.Any("settings", reloaded))
+		}
+
+		applied = next
+	}
+}
+
+// fileModTime returns the modification time of path, or the zero time when
+// it cannot be read.
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// runSeed imports the dataset into the collection when it is missing, as
+// serve does in the background, and exits.
+func runSeed(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	configPath := configFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if cfg.ReadOnly {
+		return errReadOnly
+	}
+
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	embedClient := newEmbedder(cfg)
+	seeder := appqdrant.NewSeeder(client, embedClient, cfg.Data.CSVPath, cfg.Data.ImagesDir, qdrantOptions(cfg))
+
+	return seeder.SeedIfNeeded(ctx)
+}
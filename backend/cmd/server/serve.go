@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	"github.com/alessandrolattao/qdrant-experiment/internal/cache"
+	"github.com/alessandrolattao/qdrant-experiment/internal/config"
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
+	"github.com/alessandrolattao/qdrant-experiment/internal/experiment"
+	"github.com/alessandrolattao/qdrant-experiment/internal/flags"
+	"github.com/alessandrolattao/qdrant-experiment/internal/mail"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+	"github.com/alessandrolattao/qdrant-experiment/internal/quota"
+	"github.com/alessandrolattao/qdrant-experiment/internal/server"
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+	"github.com/alessandrolattao/qdrant-experiment/internal/web"
+	"github.com/alessandrolattao/qdrant-experiment/internal/webhook"
+)
+
+// runServe runs the HTTP API server until ctx is cancelled, seeding the
+// collection in the background unless -seed=false.
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	configPath := configFlag(fs)
+	seed := fs.Bool("seed", true, "import the dataset when the collection is missing")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("starting qdrant smartphone search engine")
+
+	if err := errors.Join(cfg.Preflight()...); err != nil {
+		return fmt.Errorf("checking directories: %w", err)
+	}
+
+	tlsOpts := server.TLSOptions{
+		CertFile:     cfg.TLS.CertFile,
+		KeyFile:      cfg.TLS.KeyFile,
+		ACMEDomains:  cfg.TLS.ACMEDomains,
+		ACMEEmail:    cfg.TLS.ACMEEmail,
+		ACMECacheDir: cfg.TLS.ACMECacheDir,
+	}
+
+	var (
+		tlsConfig        *tls.Config
+		challengeHandler http.Handler
+	)
+
+	if tlsOpts.Enabled() {
+		tlsConfig, challengeHandler, err = tlsOpts.Config()
+		if err != nil {
+			return fmt.Errorf("configuring tls: %w", err)
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.OTLPEndpoint)
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := client.Close(); err != nil {
+			slog.Warn("closing qdrant client", slog.String("error", err.Error()))
+		}
+	}()
+
+	webhooks, err := webhook.New(cfg.Webhooks.URLs, cfg.Webhooks.Secret)
+	if err != nil {
+		return fmt.Errorf("configuring webhooks: %w", err)
+	}
+
+	mailer, err := mail.New(cfg.SMTP.Addr, cfg.SMTP.From, cfg.SMTP.Username, cfg.SMTP.Password)
+	if err != nil {
+		return fmt.Errorf("configuring email: %w", err)
+	}
+
+	// A read-only replica keeps no user data, which would diverge from the
+	// other replicas'.
+	var appStore *store.Store
+
+	if !cfg.ReadOnly {
+		appStore, err = store.Open(cfg.Data.StorePath)
+		if err != nil {
+			return fmt.Errorf("opening store: %w", err)
+		}
+
+		defer func() { _ = appStore.Close() }()
+	}
+
+	analyticsLog, err := analytics.Open(cfg.Analytics.Dir)
+	if err != nil {
+		return fmt.Errorf("opening analytics log: %w", err)
+	}
+
+	featureFlags, err := flags.Load(cfg.Data.FeatureFlagsFile)
+	if err != nil {
+		return fmt.Errorf("loading feature flags: %w", err)
+	}
+
+	rates, err := currency.Load(cfg.Data.CurrencyRatesFile)
+	if err != nil {
+		return fmt.Errorf("loading exchange rates: %w", err)
+	}
+
+	quotas, err := quota.Load(cfg.Data.APIKeysFile, appStore)
+	if err != nil {
+		return fmt.Errorf("loading API keys: %w", err)
+	}
+
+	ranking, err := experiment.Parse(cfg.Analytics.ExperimentName, cfg.Analytics.ExperimentVariants)
+	if err != nil {
+		return fmt.Errorf("configuring experiment: %w", err)
+	}
+
+	checkRanking := func(cfg config.Config) error {
+		if ranking.Has(experiment.RankingCTR) && (cfg.Analytics.CTRBoost <= 0 || analyticsLog == nil) {
+			return errors.New("the ctr experiment variant requires CTR_BOOST and analytics")
+		}
+
+		return nil
+	}
+
+	if err := checkRanking(cfg); err != nil {
+		return err
+	}
+
+	var frontend http.Handler
+
+	if cfg.ServeFrontend {
+		frontend, err = web.Handler()
+		if err != nil {
+			return fmt.Errorf("loading embedded frontend: %w", err)
+		}
+	}
+
+	var searchCache cache.Cache
+
+	if cfg.RedisURL != "" {
+		redisCache, err := cache.NewRedis(ctx, cfg.RedisURL, "phoneseek:")
+		if err != nil {
+			return fmt.Errorf("connecting to redis: %w", err)
+		}
+
+		defer func() { _ = redisCache.Close() }()
+
+		searchCache = redisCache
+	}
+
+	embedClient := newEmbedder(cfg)
+	searcher := appqdrant.NewSearcher(client, embedClient, qdrantOptions(cfg))
+
+	// A collection built for other embedding models would fail every search;
+	// an unreachable Qdrant is left to the seeder and the readiness probe.
+	checkCtx, cancelCheck := context.WithTimeout(ctx, 10*time.Second)
+	err = searcher.CheckVectors(checkCtx)
+	cancelCheck()
+
+	switch {
+	case errors.Is(err, appqdrant.ErrVectorMismatch):
+		return err
+	case err != nil:
+		slog.Warn("checking collection vectors", slog.String("error", err.Error()))
+	}
+
+	seeder := appqdrant.NewSeeder(client, embedClient, cfg.Data.CSVPath, cfg.Data.ImagesDir, qdrantOptions(cfg))
+
+	catalog := seeder
+	if cfg.ReadOnly {
+		catalog = nil
+		*seed = false
+
+		slog.Info("read-only mode: seeding, catalog changes and the other writes are disabled")
+	}
+
+	srv := server.New(searcher, server.Options{
+		ImagesDir:      cfg.Data.ImagesDir,
+		ImageCacheDir:  cfg.Data.ImageCacheDir,
+		Settings:       serverSettings(cfg),
+		Cache:          searchCache,
+		CacheTTL:       time.Duration(cfg.Search.CacheTTLSeconds) * time.Second,
+		FiltersTTL:     time.Duration(cfg.Search.FiltersTTLSeconds) * time.Second,
+		Seeded:         seeder.Seeded,
+		Connected:      seeder.ConnectionError,
+		MaxUploadBytes: int64(cfg.Search.MaxUploadMB) << 20,
+		MaxJSONBytes:   int64(cfg.Search.MaxJSONBodyKB) << 10,
+		Catalog:        catalog,
+		IdempotencyTTL: time.Duration(cfg.Search.IdempotencyTTLSeconds) * time.Second,
+		Webhooks:       webhooks,
+		Store:          appStore,
+		Mailer:         mailer,
+		Accounts:       cfg.Accounts.Enabled,
+		SessionTTL:     time.Duration(cfg.Accounts.SessionTTLHours) * time.Hour,
+		HistoryTTL:     time.Duration(cfg.Accounts.HistoryTTLHours) * time.Hour,
+		HistoryLimit:   cfg.Accounts.HistoryLimit,
+		Analytics:      analyticsLog,
+		Experiment:     ranking,
+		Flags:          featureFlags,
+		Translator:     newTranslator(cfg),
+		TranslateLangs: cfg.Translate.Languages,
+		Quotas:         quotas,
+		LogLevel:       logLevel,
+		Rates:          rates,
+		AdminToken:     cfg.AdminToken,
+		ReadOnly:       cfg.ReadOnly,
+		Frontend:       frontend,
+	})
+
+	seeder.OnSeeded(srv.InvalidateCaches)
+	seeder.OnSeeded(func(ctx context.Context) {
+		data := map[string]any{}
+		if status, err := searcher.Status(ctx); err == nil {
+			data["points"] = status.Points
+		}
+
+		webhooks.Send(ctx, webhook.SeedCompleted, data)
+	})
+	seeder.OnSeeded(srv.CheckSavedSearches)
+	seeder.OnSeeded(srv.CheckPriceWatches)
+
+	var background sync.WaitGroup
+
+	// Both wait for Qdrant in the background, so the server starts serving
+	// and reports the wait through /readyz.
+	background.Go(func() {
+		check, failure := seeder.SeedIfNeeded, "seed failed"
+		if !*seed {
+			check, failure = seeder.CheckSeeded, "checking collection failed"
+		}
+
+		if err := check(ctx); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				slog.Error(failure, slog.String("error", err.Error()))
+			}
+
+			return
+		}
+
+		// Also after a seed at startup, which flushed the caches.
+		if seeder.Seeded() {
+			srv.WarmCache(ctx, cfg.Search.WarmQueries, time.Duration(cfg.Analytics.WindowDays)*24*time.Hour)
+		}
+	})
+
+	background.Go(func() {
+		featureFlags.Watch(ctx, time.Duration(cfg.Reload.FeatureFlagsSeconds)*time.Second)
+	})
+
+	background.Go(func() {
+		watchConfig(ctx, *configPath, time.Duration(cfg.Reload.ConfigSeconds)*time.Second, cfg, func(next config.Config) error {
+			featureFlags.Reload(ctx)
+
+			if err := checkRanking(next); err != nil {
+				return err
+			}
+
+			srv.Reload(serverSettings(next))
+
+			return nil
+		})
+	})
+
+	background.Go(func() {
+		srv.RunPriceWatches(ctx, time.Duration(cfg.Search.PriceWatchIntervalMinutes)*time.Minute)
+	})
+
+	background.Go(func() {
+		rates.Watch(ctx, time.Duration(cfg.Reload.CurrencyRatesSeconds)*time.Second)
+	})
+
+	background.Go(func() {
+		quotas.Watch(ctx, time.Duration(cfg.Reload.APIKeysSeconds)*time.Second)
+	})
+
+	if analyticsLog != nil {
+		background.Go(func() {
+			analyticsLog.RunAggregation(ctx,
+				time.Duration(cfg.Analytics.AggregateMinutes)*time.Minute,
+				time.Duration(cfg.Analytics.WindowDays)*24*time.Hour,
+			)
+		})
+	}
+
+	mainServer := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		Protocols:         server.Protocols(cfg.H2CEnabled),
+	}
+	servers := []*http.Server{mainServer}
+
+	if tlsConfig != nil {
+		mainServer.TLSConfig = tlsConfig
+
+		if challengeHandler != nil && cfg.TLS.ACMEHTTPAddr != "" {
+			servers = append(servers, &http.Server{
+				Addr:              cfg.TLS.ACMEHTTPAddr,
+				Handler:           challengeHandler,
+				ReadHeaderTimeout: 10 * time.Second,
+			})
+		}
+	}
+
+	if cfg.DebugAddr != "" {
+		servers = append(servers, &http.Server{
+			Addr:              cfg.DebugAddr,
+			Handler:           server.DebugHandler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		})
+	}
+
+	serveErr := make(chan error, len(servers))
+
+	for _, hs := range servers {
+		go func() {
+			slog.Info("server listening", slog.String("addr", hs.Addr), slog.Bool("tls", hs.TLSConfig != nil))
+
+			var err error
+			if hs.TLSConfig != nil {
+				err = hs.ListenAndServeTLS("", "")
+			} else {
+				err = hs.ListenAndServe()
+			}
+
+			if !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("serving %s: %w", hs.Addr, err)
+			}
+		}()
+	}
+
+	var runErr error
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutdown signal received, draining requests")
+	case runErr = <-serveErr:
+	}
+
+	// Cancel the root context so the seeder stops between batches.
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	for _, hs := range servers {
+		if err := hs.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("server shutdown incomplete", slog.String("addr", hs.Addr), slog.String("error", err.Error()))
+		}
+	}
+
+	background.Wait()
+	analyticsLog.Close()
+
+	if err := webhooks.Close(shutdownCtx); err != nil {
+		slog.Warn("webhook deliveries abandoned", slog.String("error", err.Error()))
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("flushing traces", slog.String("error", err.Error()))
+	}
+
+	slog.Info("shutdown complete")
+
+	return runErr
+}
+
+// serverSettings returns the server options of cfg that a reload can change.
+func serverSettings(cfg config.Config) server.Settings {
+	return server.Settings{
+		CORS: server.CORSOptions{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           time.Duration(cfg.CORS.MaxAgeSeconds) * time.Second,
+		},
+		MaxConcurrentSearches: cfg.Search.MaxConcurrency,
+		SearchQueueDepth:      cfg.Search.QueueDepth,
+		CTRBoost:              cfg.Analytics.CTRBoost,
+		CTRSmoothing:          cfg.Analytics.CTRSmoothing,
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/bench"
+	"github.com/alessandrolattao/qdrant-experiment/internal/snapshot"
+)
+
+// runSnapshot searches a query file against a running server and compares
+// the rankings with a recorded snapshot, failing on any difference; with
+// -update it records them instead.
+func runSnapshot(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+
+	var (
+		baseURL     = fs.String("url", "http://localhost:8080", "base URL of the running API server")
+		queriesPath = fs.String("queries", "", "NDJSON file of queries, such as an eval golden set (required)")
+		file        = fs.String("file", "", "snapshot file to compare against or write (required)")
+		limit       = fs.Int("limit", 10, "results per search")
+		update      = fs.Bool("update", false, "record the current responses instead of comparing")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case *queriesPath == "":
+		return errors.New("-queries is required")
+	case *file == "":
+		return errors.New("-file is required")
+	case *limit < 1 || *limit > 100:
+		return errors.New("limit must be between 1 and 100")
+	}
+
+	f, err := os.Open(*queriesPath)
+	if err != nil {
+		return fmt.Errorf("opening queries: %w", err)
+	}
+
+	queries, err := bench.LoadQueries(f)
+	_ = f.Close()
+
+	if err != nil {
+		return fmt.Errorf("loading queries: %w", err)
+	}
+
+	runner := &snapshot.Runner{BaseURL: *baseURL, Limit: *limit, Client: &http.Client{Timeout: 30 * time.Second}}
+	current := runner.Run(ctx, queries)
+
+	if *update {
+		var buf bytes.Buffer
+		if err := snapshot.Write(&buf, current); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(*file, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("writing snapshot: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "recorded %d queries in %s\n", len(current), *file)
+
+		return nil
+	}
+
+	b, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+
+	recorded, err := snapshot.Load(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	diffs := snapshot.Compare(recorded, current)
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d differences from %s; rerun with -update if they are intended", len(diffs), *file)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d queries match %s\n", len(current), *file)
+
+	return nil
+}
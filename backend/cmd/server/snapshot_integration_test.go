@@ -0,0 +1,112 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"flag"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+	"github.com/alessandrolattao/qdrant-experiment/internal/flags"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+	"github.com/alessandrolattao/qdrant-experiment/internal/server"
+	"github.com/ory/dockertest/v3"
+)
+
+var update = flag.Bool("update", false, "record testdata/snapshot/search.json instead of comparing")
+
+// qdrantTag is the Qdrant image the snapshot is recorded against; another
+// version may score differently.
+const qdrantTag = "v1.17.1"
+
+// TestSearchSnapshot seeds the snapshot fixture into a Qdrant container with
+// the mock embedder and compares the rankings of its queries with the
+// recorded snapshot, as `server snapshot` does against a running server.
+// It needs Docker:
+//
+//	go test -tags integration -run TestSearchSnapshot ./cmd/server [-update]
+func TestSearchSnapshot(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Fatalf("connecting to docker: %v", err)
+	}
+
+	pool.MaxWait = 2 * time.Minute
+
+	resource, err := pool.Run("qdrant/qdrant", qdrantTag, nil)
+	if err != nil {
+		t.Fatalf("starting qdrant: %v", err)
+	}
+
+	t.Cleanup(func() { _ = pool.Purge(resource) })
+
+	host, port, err := net.SplitHostPort(resource.GetHostPort("6334/tcp"))
+	if err != nil {
+		t.Fatalf("resolving the qdrant port: %v", err)
+	}
+
+	mock := httptest.NewServer(embedder.NewMock())
+	t.Cleanup(mock.Close)
+
+	t.Setenv("QDRANT_HOST", host)
+	t.Setenv("QDRANT_PORT", port)
+	t.Setenv("QDRANT_COLLECTION", "snapshot")
+	t.Setenv("EMBEDDER_URL", mock.URL)
+	t.Setenv("CSV_PATH", "../../testdata/snapshot/phones.csv")
+	t.Setenv("IMAGES_DIR", t.TempDir())
+	t.Setenv("FEATURE_SEARCH_CACHE", "false")
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Minute)
+	defer cancel()
+
+	embedClient := newEmbedder(cfg)
+	seeder := appqdrant.NewSeeder(client, embedClient, cfg.Data.CSVPath, cfg.Data.ImagesDir, qdrantOptions(cfg))
+
+	// The seeder waits for Qdrant to accept connections.
+	if err := seeder.SeedIfNeeded(ctx); err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+
+	featureFlags, err := flags.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.New(appqdrant.NewSearcher(client, embedClient, qdrantOptions(cfg)), server.Options{
+		Settings: serverSettings(cfg),
+		Seeded:   seeder.Seeded,
+		Flags:    featureFlags,
+	})
+
+	api := httptest.NewServer(srv.Handler())
+	t.Cleanup(api.Close)
+
+	args := []string{
+		"-url", api.URL,
+		"-queries", "../../testdata/snapshot/queries.ndjson",
+		"-file", "../../testdata/snapshot/search.json",
+	}
+	if *update {
+		args = append(args, "-update")
+	}
+
+	if err := runSnapshot(ctx, args); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,334 @@
+package analytics
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/lang"
+)
+
+// QueryStats are the aggregated interactions with the results of one query.
+type QueryStats struct {
+	Query       string  `json:"q"`
+	Searches    int     `json:"searches"`
+	Impressions int     `json:"impressions"`
+	Clicks      int     `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+	AvgDwellMs  int64   `json:"avg_dwell_ms,omitempty"`
+}
+
+// PhoneStats are the interactions with one phone in the results of a query.
+type PhoneStats struct {
+	Impressions int     `json:"impressions"`
+	Clicks      int     `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+}
+
+// VariantStats compare the searches served with one experiment variant.
+type VariantStats struct {
+	Variant     string  `json:"variant"`
+	Searches    int     `json:"searches"`
+	ZeroResults int     `json:"zero_results"`
+	Impressions int     `json:"impressions"`
+	Clicks      int     `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+	// ClickedRate is the share of searches with at least one click.
+	ClickedRate float64 `json:"clicked_rate"`
+	// MRR is the mean reciprocal rank of the first clicked result.
+	MRR          float64 `json:"mrr"`
+	AvgLatencyMs int64   `json:"avg_latency_ms"`
+
+	clicked    int
+	rankSum    float64
+	latencySum int64
+}
+
+// CTR is a click-through-rate report over the events of a time window.
+type CTR struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Since       time.Time `json:"since"`
+	// Queries are ordered by number of searches, most frequent first.
+	Queries []QueryStats `json:"queries"`
+	// Variants holds per-variant metrics of text searches served while a
+	// ranking experiment was running, ordered by name.
+	Variants []VariantStats `json:"variants,omitempty"`
+
+	phones map[string]map[uint64]PhoneStats
+}
+
+// Phone returns the stats of phone id in the results of query.
+func (c *CTR) Phone(query string, id uint64) (PhoneStats, bool) {
+	if c == nil {
+		return PhoneStats{}, false
+	}
+
+	ps, ok := c.phones[NormalizeQuery(query)][id]
+
+	return ps, ok
+}
+
+// NormalizeQuery folds case, the accents of Latin letters and whitespace so equivalent queries
+// aggregate together; the server also searches and caches by it.
+func NormalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(lang.FoldAccents(q))), " ")
+}
+
+// search collects the events of one logged search.
+type search struct {
+	query     string
+	variant   string
+	latencyMs int64
+	served    []uint64
+	impressed map[uint64]struct{}
+	clicked   map[uint64]struct{}
+	dwellMs   []int64
+}
+
+// Aggregate computes per-query and per-phone CTR from events. Clicks and
+// impressions count once per search and phone; searches for which the
+// client reported no impressions count all served results as impressions.
+// Interactions with searches outside the window are ignored.
+func Aggregate(events iter.Seq2[Event, error], since time.Time) (*CTR, error) {
+	searches := map[string]*search{}
+
+	for e, err := range events {
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Type == TypeQuery {
+			if e.Mode == "text" {
+				searches[e.QueryID] = &search{
+					query:     NormalizeQuery(e.Query),
+					variant:   e.Variant,
+					latencyMs: e.LatencyMs,
+					served:    e.Results,
+				}
+			}
+
+			continue
+		}
+
+		s, ok := searches[e.QueryID]
+		if !ok {
+			continue
+		}
+
+		switch e.Type {
+		case TypeImpression:
+			s.impressed = addID(s.impressed, e.PhoneID)
+		case TypeClick:
+			s.clicked = addID(s.clicked, e.PhoneID)
+		case TypeDwell:
+			s.dwellMs = append(s.dwellMs, e.DwellMs)
+		}
+	}
+
+	queries := map[string]*QueryStats{}
+	phones := map[string]map[uint64]PhoneStats{}
+	dwell := map[string][2]int64{}
+	variants := map[string]*VariantStats{}
+
+	for _, s := range searches {
+		if s.variant != "" {
+			vs, ok := variants[s.variant]
+			if !ok {
+				vs = &VariantStats{Variant: s.variant}
+				variants[s.variant] = vs
+			}
+
+			vs.add(s)
+		}
+
+		qs, ok := queries[s.query]
+		if !ok {
+			qs = &QueryStats{Query: s.query}
+			queries[s.query] = qs
+			phones[s.query] = map[uint64]PhoneStats{}
+		}
+
+		qs.Searches++
+
+		shown := s.impressed
+		if len(shown) == 0 {
+			for _, id := range s.served {
+				shown = addID(shown, id)
+			}
+		}
+
+		// A click implies the phone was shown even if unreported.
+		for id := range s.clicked {
+			shown = addID(shown, id)
+		}
+
+		for id := range shown {
+			ps := phones[s.query][id]
+			ps.Impressions++
+
+			if _, ok := s.clicked[id]; ok {
+				ps.Clicks++
+			}
+
+			phones[s.query][id] = ps
+		}
+
+		qs.Impressions += len(shown)
+		qs.Clicks += len(s.clicked)
+
+		for _, ms := range s.dwellMs {
+			d := dwell[s.query]
+			dwell[s.query] = [2]int64{d[0] + ms, d[1] + 1}
+		}
+	}
+
+	report := &CTR{GeneratedAt: time.Now().UTC(), Since: since.UTC(), Queries: make([]QueryStats, 0, len(queries)), phones: phones}
+
+	for q, qs := range queries {
+		qs.CTR = rate(qs.Clicks, qs.Impressions)
+		if d := dwell[q]; d[1] > 0 {
+			qs.AvgDwellMs = d[0] / d[1]
+		}
+
+		for id, ps := range phones[q] {
+			ps.CTR = rate(ps.Clicks, ps.Impressions)
+			phones[q][id] = ps
+		}
+
+		report.Queries = append(report.Queries, *qs)
+	}
+
+	slices.SortFunc(report.Queries, func(a, b QueryStats) int {
+		return cmp.Or(cmp.Compare(b.Searches, a.Searches), cmp.Compare(a.Query, b.Query))
+	})
+
+	for _, vs := range variants {
+		report.Variants = append(report.Variants, vs.finish())
+	}
+
+	slices.SortFunc(report.Variants, func(a, b VariantStats) int { return cmp.Compare(a.Variant, b.Variant) })
+
+	return report, nil
+}
+
+// add counts one search towards the variant.
+func (vs *VariantStats) add(s *search) {
+	vs.Searches++
+	vs.latencySum += s.latencyMs
+
+	if len(s.served) == 0 {
+		vs.ZeroResults++
+	}
+
+	shown := len(s.impressed)
+	if shown == 0 {
+		shown = len(s.served)
+	}
+
+	vs.Impressions += shown
+	vs.Clicks += len(s.clicked)
+
+	if len(s.clicked) == 0 {
+		return
+	}
+
+	vs.clicked++
+
+	for i, id := range s.served {
+		if _, ok := s.clicked[id]; ok {
+			vs.rankSum += 1 / float64(i+1)
+			break
+		}
+	}
+}
+
+// finish derives the rates once all searches were added.
+func (vs *VariantStats) finish() VariantStats {
+	vs.CTR = rate(vs.Clicks, vs.Impressions)
+	vs.ClickedRate = rate(vs.clicked, vs.Searches)
+	vs.MRR = vs.rankSum / float64(vs.Searches)
+	vs.AvgLatencyMs = vs.latencySum / int64(vs.Searches)
+
+	return *vs
+}
+
+// Aggregate computes a CTR report over the events logged since since.
+func (l *Log) Aggregate(since time.Time) (*CTR, error) {
+	return Aggregate(l.Events(since), since)
+}
+
+// CTR returns the report of the last aggregation run, or nil before the first.
+func (l *Log) CTR() *CTR {
+	if l == nil {
+		return nil
+	}
+
+	return l.ctr.Load()
+}
+
+// RunAggregation recomputes the CTR report and co-view associations over the
+// trailing window every interval until ctx is cancelled, starting
+// immediately.
+func (l *Log) RunAggregation(ctx context.Context, interval, window time.Duration) {
+	if l == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+
+		since := start.Add(-window)
+
+		report, err := l.Aggregate(since)
+		if err == nil {
+			l.ctr.Store(report)
+
+			var coViews *CoViews
+
+			coViews, err = AggregateCoViews(l.Events(since))
+			if err == nil {
+				l.coViews.Store(coViews)
+			}
+		}
+
+		if err != nil {
+			slog.ErrorContext(ctx, "aggregating analytics failed", slog.String("error", err.Error()))
+		} else {
+			slog.InfoContext(ctx, "analytics aggregated",
+				slog.Int("queries", len(report.Queries)),
+				slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func addID(set map[uint64]struct{}, id uint64) map[uint64]struct{} {
+	if set == nil {
+		set = map[uint64]struct{}{}
+	}
+
+	set[id] = struct{}{}
+
+	return set
+}
+
+func rate(clicks, impressions int) float64 {
+	if impressions == 0 {
+		return 0
+	}
+
+	return float64(clicks) / float64(impressions)
+}
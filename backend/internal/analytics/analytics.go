@@ -0,0 +1,265 @@
+// Package analytics records searches and the interactions with their results
+// in an append-only log and aggregates them into relevance metrics.
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types.
+const (
+	TypeQuery      = "query"
+	TypeImpression = "impression"
+	TypeClick      = "click"
+	TypeDwell      = "dwell"
+)
+
+const (
+	queueSize     = 1024
+	segmentPrefix = "events-"
+	segmentSuffix = ".ndjson"
+	segmentLayout = "2006-01-02"
+	maxLineBytes  = 1 << 20
+)
+
+// Event is one line of the log. Query events are written by the server for
+// every search; the other types are reported by clients and refer to a
+// search by its QueryID.
+type Event struct {
+	Type    string    `json:"type"`
+	QueryID string    `json:"query_id"`
+	At      time.Time `json:"at"`
+	// Variant is the ranking experiment variant the search was served with.
+	Variant string `json:"variant,omitempty"`
+
+	// Mode is "text" or "image" for query events; Lang is the detected
+	// language of a text query, "und" when unknown.
+	Mode      string            `json:"mode,omitempty"`
+	Query     string            `json:"q,omitempty"`
+	Lang      string            `json:"lang,omitempty"`
+	Filters   map[string]string `json:"filters,omitempty"`
+	Results   []uint64          `json:"results,omitempty"`
+	LatencyMs int64             `json:"latency_ms,omitempty"`
+
+	PhoneID  uint64 `json:"phone_id,omitempty"`
+	Position int    `json:"position,omitempty"`
+	DwellMs  int64  `json:"dwell_ms,omitempty"`
+}
+
+// Log appends events to daily NDJSON segments in a directory from a
+// background writer. A nil Log drops events, so callers need not check
+// whether analytics are enabled.
+type Log struct {
+	dir   string
+	queue chan Event
+	done  chan struct{}
+
+	// mu guards closed so late events, e.g. from WebSocket connections
+	// that outlive shutdown, are dropped instead of sent on a closed queue.
+	mu     sync.RWMutex
+	closed bool
+
+	ctr     atomic.Pointer[CTR]
+	coViews atomic.Pointer[CoViews]
+}
+
+// Open starts a Log writing to dir. It returns nil when dir is empty.
+func Open(dir string) (*Log, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating analytics dir: %w", err)
+	}
+
+	l := &Log{
+		dir:   dir,
+		queue: make(chan Event, queueSize),
+		done:  make(chan struct{}),
+	}
+
+	go l.run()
+
+	return l, nil
+}
+
+// Record queues e without blocking; it is dropped when the queue is full.
+func (l *Log) Record(ctx context.Context, e Event) {
+	if l == nil {
+		return
+	}
+
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	e.At = e.At.UTC()
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return
+	}
+
+	select {
+	case l.queue <- e:
+	default:
+		slog.WarnContext(ctx, "analytics queue full, dropping event", slog.String("event", e.Type))
+	}
+}
+
+// Close stops accepting events and waits until queued ones are written.
+func (l *Log) Close() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.queue)
+	}
+	l.mu.Unlock()
+
+	<-l.done
+}
+
+func (l *Log) run() {
+	defer close(l.done)
+
+	var (
+		day  string
+		file *os.File
+		buf  *bufio.Writer
+	)
+
+	closeSegment := func() {
+		if file == nil {
+			return
+		}
+
+		if err := buf.Flush(); err != nil {
+			slog.Error("writing analytics events", slog.String("error", err.Error()))
+		}
+
+		_ = file.Close()
+	}
+	defer closeSegment()
+
+	for e := range l.queue {
+		if d := e.At.Format(segmentLayout); d != day || file == nil {
+			closeSegment()
+
+			f, err := os.OpenFile(l.segmentPath(d), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				slog.Error("opening analytics segment", slog.String("error", err.Error()))
+
+				file = nil
+
+				continue
+			}
+
+			day, file, buf = d, f, bufio.NewWriter(f)
+		}
+
+		line, err := json.Marshal(e)
+		if err != nil {
+			slog.Error("encoding analytics event", slog.String("event", e.Type), slog.String("error", err.Error()))
+			continue
+		}
+
+		_, _ = buf.Write(append(line, '\n'))
+
+		// Flush whenever the queue drains so readers see recent events.
+		if len(l.queue) == 0 {
+			if err := buf.Flush(); err != nil {
+				slog.Error("writing analytics events", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func (l *Log) segmentPath(day string) string {
+	return filepath.Join(l.dir, segmentPrefix+day+segmentSuffix)
+}
+
+// Events yields the logged events at or after since, oldest segment first.
+// Lines that cannot be decoded, such as one being written, are skipped.
+func (l *Log) Events(since time.Time) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		entries, err := os.ReadDir(l.dir)
+		if err != nil {
+			yield(Event{}, fmt.Errorf("listing analytics segments: %w", err))
+			return
+		}
+
+		first := since.UTC().Format(segmentLayout)
+
+		var days []string
+
+		for _, entry := range entries {
+			day, ok := strings.CutPrefix(entry.Name(), segmentPrefix)
+			if day, ok = strings.CutSuffix(day, segmentSuffix); ok && day >= first {
+				days = append(days, day)
+			}
+		}
+
+		slices.Sort(days)
+
+		for _, day := range days {
+			if !l.scanSegment(day, since, yield) {
+				return
+			}
+		}
+	}
+}
+
+// ReadEvents is like Log.Events for a log directory that is not open, so
+// offline tools can read it without a writer.
+func ReadEvents(dir string, since time.Time) iter.Seq2[Event, error] {
+	return (&Log{dir: dir}).Events(since)
+}
+
+// scanSegment yields the events of one segment, returning false when the
+// caller stopped iterating.
+func (l *Log) scanSegment(day string, since time.Time, yield func(Event, error) bool) bool {
+	f, err := os.Open(l.segmentPath(day))
+	if err != nil {
+		return yield(Event{}, fmt.Errorf("opening analytics segment: %w", err))
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil || e.At.Before(since) {
+			continue
+		}
+
+		if !yield(e, nil) {
+			return false
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return yield(Event{}, fmt.Errorf("reading analytics segment %s: %w", day, err))
+	}
+
+	return true
+}
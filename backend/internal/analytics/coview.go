@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"time"
+)
+
+const (
+	// minCoViews is how many searches must have led to both phones before
+	// they are associated, filtering out one-off coincidences.
+	minCoViews = 2
+	// maxCoViewed bounds the associations kept per phone.
+	maxCoViewed = 20
+)
+
+// CoView is a phone viewed together with another one, and in how many
+// searches that happened.
+type CoView struct {
+	ID    uint64 `json:"id"`
+	Count int    `json:"count"`
+}
+
+// CoViews associates phones whose results were clicked from the same search.
+type CoViews struct {
+	GeneratedAt time.Time
+	byPhone     map[uint64][]CoView
+}
+
+// AlsoViewed returns up to n phones most often viewed together with id.
+func (c *CoViews) AlsoViewed(id uint64, n int) []CoView {
+	if c == nil {
+		return nil
+	}
+
+	views := c.byPhone[id]
+
+	return views[:min(n, len(views))]
+}
+
+// AggregateCoViews counts, for every pair of phones, the searches in which
+// both were clicked or dwelled on.
+func AggregateCoViews(events iter.Seq2[Event, error]) (*CoViews, error) {
+	viewed := map[string]map[uint64]struct{}{}
+
+	for e, err := range events {
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Type == TypeClick || e.Type == TypeDwell {
+			viewed[e.QueryID] = addID(viewed[e.QueryID], e.PhoneID)
+		}
+	}
+
+	type pair struct{ a, b uint64 }
+
+	counts := map[pair]int{}
+
+	for _, ids := range viewed {
+		if len(ids) < 2 {
+			continue
+		}
+
+		for a := range ids {
+			for b := range ids {
+				if a < b {
+					counts[pair{a, b}]++
+				}
+			}
+		}
+	}
+
+	byPhone := map[uint64][]CoView{}
+
+	for p, n := range counts {
+		if n < minCoViews {
+			continue
+		}
+
+		byPhone[p.a] = append(byPhone[p.a], CoView{ID: p.b, Count: n})
+		byPhone[p.b] = append(byPhone[p.b], CoView{ID: p.a, Count: n})
+	}
+
+	for id, views := range byPhone {
+		slices.SortFunc(views, func(x, y CoView) int {
+			return cmp.Or(cmp.Compare(y.Count, x.Count), cmp.Compare(x.ID, y.ID))
+		})
+		byPhone[id] = views[:min(maxCoViewed, len(views))]
+	}
+
+	return &CoViews{GeneratedAt: time.Now().UTC(), byPhone: byPhone}, nil
+}
+
+// CoViews returns the associations of the last aggregation run, or nil
+// before the first.
+func (l *Log) CoViews() *CoViews {
+	if l == nil {
+		return nil
+	}
+
+	return l.coViews.Load()
+}
@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+)
+
+// QueryRecord is a logged search joined with the clicks on its results, as
+// exported for offline analysis.
+type QueryRecord struct {
+	QueryID   string            `json:"query_id"`
+	At        time.Time         `json:"at"`
+	Variant   string            `json:"variant,omitempty"`
+	Mode      string            `json:"mode"`
+	Query     string            `json:"q,omitempty"`
+	Lang      string            `json:"lang,omitempty"`
+	Filters   map[string]string `json:"filters,omitempty"`
+	Results   int               `json:"results"`
+	ResultIDs []uint64          `json:"result_ids"`
+	LatencyMs int64             `json:"latency_ms"`
+	// Clicked lists the clicked phones in click order, each once.
+	Clicked []uint64 `json:"clicked"`
+}
+
+// ExportQueries joins the searches in events logged before until with the
+// clicks on their results, in the order the searches were logged. A zero
+// until exports every search. Clicks are matched however late they were
+// reported, as long as they are in events.
+func ExportQueries(events iter.Seq2[Event, error], until time.Time) ([]QueryRecord, error) {
+	var records []QueryRecord
+
+	byID := map[string]int{}
+
+	for e, err := range events {
+		if err != nil {
+			return nil, err
+		}
+
+		switch e.Type {
+		case TypeQuery:
+			if !until.IsZero() && !e.At.Before(until) {
+				continue
+			}
+
+			byID[e.QueryID] = len(records)
+			records = append(records, QueryRecord{
+				QueryID:   e.QueryID,
+				At:        e.At,
+				Variant:   e.Variant,
+				Mode:      e.Mode,
+				Query:     e.Query,
+				Lang:      e.Lang,
+				Filters:   e.Filters,
+				Results:   len(e.Results),
+				ResultIDs: nonNilIDs(e.Results),
+				LatencyMs: e.LatencyMs,
+				Clicked:   []uint64{},
+			})
+		case TypeClick:
+			i, ok := byID[e.QueryID]
+			if !ok {
+				continue
+			}
+
+			if !slices.Contains(records[i].Clicked, e.PhoneID) {
+				records[i].Clicked = append(records[i].Clicked, e.PhoneID)
+			}
+		}
+	}
+
+	return records, nil
+}
+
+// ExportQueries exports the searches logged between since and until.
+func (l *Log) ExportQueries(since, until time.Time) ([]QueryRecord, error) {
+	return ExportQueries(l.Events(since), until)
+}
+
+// ParseTime parses a date (2006-01-02, midnight UTC) or an RFC 3339
+// timestamp, as accepted by the export filters.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(segmentLayout, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want YYYY-MM-DD or RFC 3339", s)
+	}
+
+	return t.UTC(), nil
+}
+
+func nonNilIDs(ids []uint64) []uint64 {
+	if ids == nil {
+		return []uint64{}
+	}
+
+	return ids
+}
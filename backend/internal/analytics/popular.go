@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"cmp"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PopularSearch is a text query searched with the same filters in a window.
+type PopularSearch struct {
+	Query    string            `json:"q"`
+	Filters  map[string]string `json:"filters,omitempty"`
+	Searches int               `json:"searches"`
+}
+
+// PopularSearches returns the n text searches logged since since that were
+// run most often, most frequent first. Searches of the same query with other
+// filters are counted apart, as they are cached apart.
+func PopularSearches(events iter.Seq2[Event, error], since time.Time, n int) ([]PopularSearch, error) {
+	searches := map[string]*PopularSearch{}
+
+	for e, err := range events {
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Type != TypeQuery || e.Mode != "text" || e.Query == "" || e.At.Before(since) {
+			continue
+		}
+
+		key := searchKey(e.Query, e.Filters)
+
+		ps, ok := searches[key]
+		if !ok {
+			ps = &PopularSearch{Query: e.Query, Filters: e.Filters}
+			searches[key] = ps
+		}
+
+		ps.Searches++
+	}
+
+	ranked := make([]PopularSearch, 0, len(searches))
+	for _, ps := range searches {
+		ranked = append(ranked, *ps)
+	}
+
+	slices.SortFunc(ranked, func(a, b PopularSearch) int {
+		return cmp.Or(cmp.Compare(b.Searches, a.Searches), cmp.Compare(a.Query, b.Query),
+			cmp.Compare(searchKey("", a.Filters), searchKey("", b.Filters)))
+	})
+
+	return ranked[:min(n, len(ranked))], nil
+}
+
+// searchKey identifies a query and its filters, in key order.
+func searchKey(query string, filters map[string]string) string {
+	var b strings.Builder
+
+	b.WriteString(query)
+
+	for _, k := range slices.Sorted(maps.Keys(filters)) {
+		b.WriteString("\x00" + k + "=" + filters[k])
+	}
+
+	return b.String()
+}
+
+// PopularSearches returns the n most frequent text searches logged since
+// since.
+func (l *Log) PopularSearches(since time.Time, n int) ([]PopularSearch, error) {
+	return PopularSearches(l.Events(since), since, n)
+}
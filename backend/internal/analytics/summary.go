@@ -0,0 +1,145 @@
+package analytics
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"time"
+)
+
+// QueryCount is how often a query was searched in a window.
+type QueryCount struct {
+	Query       string `json:"q"`
+	Searches    int    `json:"searches"`
+	ZeroResults int    `json:"zero_results"`
+}
+
+// VolumeBucket is the search volume of one interval of a window.
+type VolumeBucket struct {
+	Start       time.Time `json:"start"`
+	Searches    int       `json:"searches"`
+	ZeroResults int       `json:"zero_results"`
+}
+
+// Summary is the traffic and relevance overview of a time window.
+type Summary struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Since       time.Time `json:"since"`
+	Searches    int       `json:"searches"`
+	// Modes counts searches by mode ("text", "image"), Languages text
+	// searches by detected query language.
+	Modes          map[string]int `json:"modes"`
+	Languages      map[string]int `json:"languages"`
+	ZeroResults    int            `json:"zero_results"`
+	ZeroResultRate float64        `json:"zero_result_rate"`
+	AvgLatencyMs   int64          `json:"avg_latency_ms"`
+	// TopQueries and TopZeroResultQueries are ordered by number of
+	// searches, most frequent first.
+	TopQueries           []QueryCount   `json:"top_queries"`
+	TopZeroResultQueries []QueryCount   `json:"top_zero_result_queries"`
+	Volume               []VolumeBucket `json:"volume"`
+}
+
+// Summarize computes a Summary of the searches logged since since, with the
+// search volume split into bucket-sized intervals and at most top entries in
+// each query ranking.
+func Summarize(events iter.Seq2[Event, error], since time.Time, bucket time.Duration, top int) (*Summary, error) {
+	now := time.Now().UTC()
+	since = since.UTC()
+
+	sum := &Summary{GeneratedAt: now, Since: since, Modes: map[string]int{}, Languages: map[string]int{}}
+
+	var volume []VolumeBucket
+
+	first := since.Truncate(bucket)
+	for t := first; !t.After(now); t = t.Add(bucket) {
+		volume = append(volume, VolumeBucket{Start: t})
+	}
+
+	queries := map[string]*QueryCount{}
+
+	var latencySum int64
+
+	for e, err := range events {
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Type != TypeQuery || e.At.Before(since) {
+			continue
+		}
+
+		zero := len(e.Results) == 0
+
+		sum.Searches++
+		sum.Modes[e.Mode]++
+		latencySum += e.LatencyMs
+
+		if zero {
+			sum.ZeroResults++
+		}
+
+		if i := int(e.At.Sub(first) / bucket); i >= 0 && i < len(volume) {
+			volume[i].Searches++
+
+			if zero {
+				volume[i].ZeroResults++
+			}
+		}
+
+		if e.Query == "" {
+			continue
+		}
+
+		if e.Lang != "" {
+			sum.Languages[e.Lang]++
+		}
+
+		q := NormalizeQuery(e.Query)
+
+		qc, ok := queries[q]
+		if !ok {
+			qc = &QueryCount{Query: q}
+			queries[q] = qc
+		}
+
+		qc.Searches++
+
+		if zero {
+			qc.ZeroResults++
+		}
+	}
+
+	if sum.Searches > 0 {
+		sum.ZeroResultRate = rate(sum.ZeroResults, sum.Searches)
+		sum.AvgLatencyMs = latencySum / int64(sum.Searches)
+	}
+
+	sum.TopQueries = topQueries(queries, top, func(qc *QueryCount) int { return qc.Searches })
+	sum.TopZeroResultQueries = topQueries(queries, top, func(qc *QueryCount) int { return qc.ZeroResults })
+	sum.Volume = volume
+
+	return sum, nil
+}
+
+// topQueries returns the n queries with the highest positive count.
+func topQueries(queries map[string]*QueryCount, n int, count func(*QueryCount) int) []QueryCount {
+	ranked := make([]QueryCount, 0, len(queries))
+
+	for _, qc := range queries {
+		if count(qc) > 0 {
+			ranked = append(ranked, *qc)
+		}
+	}
+
+	slices.SortFunc(ranked, func(a, b QueryCount) int {
+		return cmp.Or(cmp.Compare(count(&b), count(&a)), cmp.Compare(a.Query, b.Query))
+	})
+
+	return ranked[:min(n, len(ranked))]
+}
+
+// Summary computes a Summary over the events logged since since.
+func (l *Log) Summary(since time.Time, bucket time.Duration, top int) (*Summary, error) {
+	return Summarize(l.Events(since), since, bucket, top)
+}
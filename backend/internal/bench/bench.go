@@ -0,0 +1,312 @@
+// Package bench measures search latency against a running server: end to
+// end as seen by the client, and the time the server spent embedding the
+// query and querying Qdrant, as reported in its Server-Timing header. It
+// also load tests a server with a concurrent mix of requests.
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query is a search to replay. Golden sets of the eval command can be
+// replayed as they are; their other fields are ignored.
+type Query struct {
+	Query   string            `json:"q"`
+	Filters map[string]string `json:"filters,omitempty"`
+}
+
+// LoadQueries reads queries from NDJSON, one query per line. Blank lines
+// and lines starting with # are skipped.
+func LoadQueries(r io.Reader) ([]Query, error) {
+	var queries []Query
+
+	sc := bufio.NewScanner(r)
+
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 || b[0] == '#' {
+			continue
+		}
+
+		var q Query
+		if err := json.Unmarshal(b, &q); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if q.Query == "" {
+			return nil, fmt.Errorf("line %d: a query needs q", line)
+		}
+
+		queries = append(queries, q)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading queries: %w", err)
+	}
+
+	if len(queries) == 0 {
+		return nil, errors.New("no queries found")
+	}
+
+	return queries, nil
+}
+
+// Percentiles are latency percentiles in milliseconds.
+type Percentiles struct {
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+// Report summarizes a run. Responses served from the server's search cache
+// are counted in Cached but left out of the percentiles, which would
+// otherwise measure the cache.
+type Report struct {
+	Requests int `json:"requests"`
+	Failed   int `json:"failed"`
+	Cached   int `json:"cached"`
+	// Total is the end-to-end latency measured by the client.
+	Total  Percentiles `json:"total"`
+	Embed  Percentiles `json:"embed"`
+	Qdrant Percentiles `json:"qdrant"`
+	// Errors are the distinct errors of the failed requests.
+	Errors []string  `json:"errors,omitempty"`
+	TookMs int64     `json:"took_ms"`
+	At     time.Time `json:"at"`
+}
+
+// Runner replays queries against the search API of a running server. A nil
+// Client uses http.DefaultClient.
+type Runner struct {
+	BaseURL string
+	Limit   int
+	// Rounds is how many times every query is sent.
+	Rounds int
+	// Concurrency is how many requests are in flight at once.
+	Concurrency int
+	Client      *http.Client
+}
+
+// sample is the outcome of one request.
+type sample struct {
+	total, embed, qdrant float64
+	cached               bool
+	err                  error
+}
+
+// Run sends every query Rounds times and reports the latency percentiles.
+func (r *Runner) Run(ctx context.Context, queries []Query) Report {
+	start := time.Now()
+	report := Report{At: start.UTC()}
+
+	jobs := make(chan Query)
+
+	go func() {
+		defer close(jobs)
+
+		for range max(r.Rounds, 1) {
+			for _, q := range queries {
+				select {
+				case jobs <- q:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		wg      sync.WaitGroup
+	)
+
+	for range max(r.Concurrency, 1) {
+		wg.Go(func() {
+			for q := range jobs {
+				s := r.search(ctx, q)
+
+				mu.Lock()
+				samples = append(samples, s)
+				mu.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	var total, embed, qdrant []float64
+
+	for _, s := range samples {
+		report.Requests++
+
+		switch {
+		case s.err != nil:
+			report.Failed++
+
+			if msg := s.err.Error(); !slices.Contains(report.Errors, msg) {
+				report.Errors = append(report.Errors, msg)
+			}
+		case s.cached:
+			report.Cached++
+		default:
+			total = append(total, s.total)
+			embed = append(embed, s.embed)
+			qdrant = append(qdrant, s.qdrant)
+		}
+	}
+
+	report.Total = percentiles(total)
+	report.Embed = percentiles(embed)
+	report.Qdrant = percentiles(qdrant)
+	report.TookMs = time.Since(start).Milliseconds()
+
+	return report
+}
+
+// search sends q once and times it.
+func (r *Runner) search(ctx context.Context, q Query) sample {
+	params := url.Values{}
+	for k, v := range q.Filters {
+		params.Set(k, v)
+	}
+
+	params.Set("q", q.Query)
+	params.Set("limit", strconv.Itoa(r.Limit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.BaseURL+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return sample{err: fmt.Errorf("creating request: %w", err)}
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return sample{err: fmt.Errorf("searching: %w", err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return sample{err: fmt.Errorf("search returned status %d", resp.StatusCode)}
+	}
+
+	var body struct {
+		Cached bool `json:"cached"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return sample{err: fmt.Errorf("decoding response: %w", err)}
+	}
+
+	timings := serverTimings(resp.Header.Get("Server-Timing"))
+
+	return sample{
+		total:  float64(time.Since(start).Microseconds()) / 1000,
+		embed:  timings["embed"],
+		qdrant: timings["qdrant"],
+		cached: body.Cached,
+	}
+}
+
+// serverTimings parses the durations of a Server-Timing header such as
+// "embed;dur=12.5, qdrant;dur=3.1, total;dur=17.0".
+func serverTimings(header string) map[string]float64 {
+	timings := map[string]float64{}
+
+	for metric := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(metric, ";")
+
+		for param := range strings.SplitSeq(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "dur="); ok {
+				if d, err := strconv.ParseFloat(v, 64); err == nil {
+					timings[strings.TrimSpace(name)] = d
+				}
+			}
+		}
+	}
+
+	return timings
+}
+
+// percentiles returns the nearest-rank percentiles of the latencies.
+func percentiles(latencies []float64) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+
+	slices.Sort(latencies)
+
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(latencies)))) - 1
+		return latencies[max(i, 0)]
+	}
+
+	return Percentiles{P50: rank(0.50), P95: rank(0.95), P99: rank(0.99)}
+}
+
+// Regression returns how much slower, as a fraction, the end-to-end p95 of
+// rep is than that of baseline; it is negative when rep is faster.
+func (rep Report) Regression(baseline Report) float64 {
+	if baseline.Total.P95 == 0 {
+		return 0
+	}
+
+	return rep.Total.P95/baseline.Total.P95 - 1
+}
+
+// WriteComparison prints how the p95 latencies changed from baseline.
+func (rep Report) WriteComparison(w io.Writer, baseline Report) error {
+	_, err := fmt.Fprintf(w, "vs baseline p95: total %+.1fms  embed %+.1fms  qdrant %+.1fms (%+.1f%% total)\n",
+		rep.Total.P95-baseline.Total.P95,
+		rep.Embed.P95-baseline.Embed.P95,
+		rep.Qdrant.P95-baseline.Qdrant.P95,
+		rep.Regression(baseline)*100,
+	)
+
+	return err
+}
+
+// WriteText prints the percentiles of each measure followed by the request
+// counts and any errors.
+func (rep Report) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%-8s %9s %9s %9s\n", "", "p50", "p95", "p99")
+
+	for _, m := range []struct {
+		name string
+		p    Percentiles
+	}{{"total", rep.Total}, {"embed", rep.Embed}, {"qdrant", rep.Qdrant}} {
+		fmt.Fprintf(&buf, "%-8s %7.1fms %7.1fms %7.1fms\n", m.name, m.p.P50, m.p.P95, m.p.P99)
+	}
+
+	fmt.Fprintf(&buf, "\n%d requests, %d failed, %d cached (not measured), %dms\n", rep.Requests, rep.Failed, rep.Cached, rep.TookMs)
+
+	for _, e := range rep.Errors {
+		fmt.Fprintf(&buf, "error: %s\n", e)
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
@@ -0,0 +1,391 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of request a load test sends.
+const (
+	// KindText is a text search, GET /api/search.
+	KindText = "text"
+	// KindImage is an image search, POST /api/search/image.
+	KindImage = "image"
+	// KindBrowse is a filtered browse without a query, GET /api/phones/random.
+	KindBrowse = "browse"
+)
+
+var loadKinds = []string{KindText, KindImage, KindBrowse}
+
+// imageExtensions are the files LoadImages picks up.
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".webp", ".heic", ".heif"}
+
+// Mix weighs the kinds of request of a load test; {"text": 8, "browse": 2}
+// sends four text searches for every browse.
+type Mix map[string]int
+
+// ParseMix parses a mix such as "text=70,image=10,browse=20". Kinds left out
+// are not sent.
+func ParseMix(s string) (Mix, error) {
+	mix := Mix{}
+
+	for part := range strings.SplitSeq(s, ",") {
+		kind, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not kind=weight", part)
+		}
+
+		if !slices.Contains(loadKinds, kind) {
+			return nil, fmt.Errorf("unknown request kind %q, want one of %s", kind, strings.Join(loadKinds, ", "))
+		}
+
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("weight of %s must be a non-negative integer", kind)
+		}
+
+		mix[kind] = w
+	}
+
+	if mix.total() == 0 {
+		return nil, errors.New("mix has no weight")
+	}
+
+	return mix, nil
+}
+
+func (m Mix) total() int {
+	var n int
+	for _, w := range m {
+		n += w
+	}
+
+	return n
+}
+
+// pick returns a kind at random, in proportion to the weights.
+func (m Mix) pick() string {
+	n := rand.IntN(m.total())
+
+	for _, kind := range loadKinds {
+		if n < m[kind] {
+			return kind
+		}
+
+		n -= m[kind]
+	}
+
+	return KindText
+}
+
+// Image is a photo uploaded by image searches.
+type Image struct {
+	Name string
+	Data []byte
+}
+
+// LoadImages reads the photos in dir, by extension.
+func LoadImages(dir string) ([]Image, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading images dir: %w", err)
+	}
+
+	var images []Image
+
+	for _, e := range entries {
+		if e.IsDir() || !slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(e.Name()))) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading image: %w", err)
+		}
+
+		images = append(images, Image{Name: e.Name(), Data: data})
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images found in %s", dir)
+	}
+
+	return images, nil
+}
+
+// KindReport summarizes the requests of one kind. Unavailable counts the 503
+// answers of a saturated server or of one whose embedder or Qdrant is down,
+// which are also counted in Failed.
+type KindReport struct {
+	Requests    int         `json:"requests"`
+	Failed      int         `json:"failed"`
+	Unavailable int         `json:"unavailable"`
+	Throughput  float64     `json:"rps"`
+	Latency     Percentiles `json:"latency"`
+}
+
+// LoadReport summarizes a load test. Unlike Report, the latencies include
+// responses served from the search cache, as production traffic does.
+type LoadReport struct {
+	Concurrency int `json:"concurrency"`
+	KindReport
+	Kinds map[string]KindReport `json:"kinds"`
+	// Errors are the distinct errors of the failed requests.
+	Errors []string  `json:"errors,omitempty"`
+	TookMs int64     `json:"took_ms"`
+	At     time.Time `json:"at"`
+}
+
+// LoadRunner sends a mix of requests from Concurrency workers, each sending
+// its next request as soon as the previous one is answered, for Duration.
+// Text searches and browses draw their filters from Queries, image searches
+// their photo from Images. A nil Client uses http.DefaultClient.
+type LoadRunner struct {
+	BaseURL     string
+	Limit       int
+	Concurrency int
+	Duration    time.Duration
+	Mix         Mix
+	Queries     []Query
+	Images      []Image
+	Client      *http.Client
+}
+
+// loadSample is the outcome of one load test request.
+type loadSample struct {
+	kind        string
+	latency     float64
+	unavailable bool
+	err         error
+}
+
+// Run sends requests until Duration elapses or ctx is done, and reports the
+// throughput and latency percentiles, overall and by kind. Requests cut
+// short by the end of the run are not counted.
+func (r *LoadRunner) Run(ctx context.Context) LoadReport {
+	start := time.Now()
+	report := LoadReport{Concurrency: max(r.Concurrency, 1), Kinds: map[string]KindReport{}, At: start.UTC()}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Duration)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		samples []loadSample
+		wg      sync.WaitGroup
+	)
+
+	for range report.Concurrency {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				s := r.send(ctx, r.Mix.pick())
+				if ctx.Err() != nil {
+					return
+				}
+
+				mu.Lock()
+				samples = append(samples, s)
+				mu.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	elapsed := time.Since(start)
+
+	var all []float64
+
+	latencies := map[string][]float64{}
+
+	for _, s := range samples {
+		k := report.Kinds[s.kind]
+		k.Requests++
+
+		switch {
+		case s.err != nil:
+			k.Failed++
+			if s.unavailable {
+				k.Unavailable++
+			}
+
+			if msg := s.err.Error(); !slices.Contains(report.Errors, msg) {
+				report.Errors = append(report.Errors, msg)
+			}
+		default:
+			all = append(all, s.latency)
+			latencies[s.kind] = append(latencies[s.kind], s.latency)
+		}
+
+		report.Kinds[s.kind] = k
+	}
+
+	for kind, k := range report.Kinds {
+		k.Throughput = throughput(k.Requests-k.Failed, elapsed)
+		k.Latency = percentiles(latencies[kind])
+		report.Kinds[kind] = k
+
+		report.Requests += k.Requests
+		report.Failed += k.Failed
+		report.Unavailable += k.Unavailable
+	}
+
+	report.Throughput = throughput(report.Requests-report.Failed, elapsed)
+	report.Latency = percentiles(all)
+	report.TookMs = elapsed.Milliseconds()
+
+	return report
+}
+
+// throughput returns the successful requests per second.
+func throughput(n int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(n) / elapsed.Seconds()
+}
+
+// send sends one request of kind and times it.
+func (r *LoadRunner) send(ctx context.Context, kind string) loadSample {
+	params := url.Values{}
+
+	var q Query
+	if len(r.Queries) > 0 {
+		q = r.Queries[rand.IntN(len(r.Queries))]
+	}
+
+	for k, v := range q.Filters {
+		params.Set(k, v)
+	}
+
+	params.Set("limit", strconv.Itoa(r.Limit))
+
+	var (
+		req *http.Request
+		err error
+	)
+
+	switch kind {
+	case KindImage:
+		req, err = r.imageRequest(ctx, params)
+	case KindBrowse:
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, r.BaseURL+"/api/phones/random?"+params.Encode(), nil)
+	default:
+		params.Set("q", q.Query)
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, r.BaseURL+"/api/search?"+params.Encode(), nil)
+	}
+
+	if err != nil {
+		return loadSample{kind: kind, err: fmt.Errorf("creating request: %w", err)}
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return loadSample{kind: kind, err: fmt.Errorf("%s: %w", kind, err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Read the whole body, as a browser would, so its transfer is timed.
+	_, err = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode != http.StatusOK:
+		return loadSample{
+			kind:        kind,
+			unavailable: resp.StatusCode == http.StatusServiceUnavailable,
+			err:         fmt.Errorf("%s returned status %d", kind, resp.StatusCode),
+		}
+	case err != nil:
+		return loadSample{kind: kind, err: fmt.Errorf("%s: reading response: %w", kind, err)}
+	}
+
+	return loadSample{kind: kind, latency: float64(time.Since(start).Microseconds()) / 1000}
+}
+
+// imageRequest builds an image search uploading one of Images at random.
+func (r *LoadRunner) imageRequest(ctx context.Context, params url.Values) (*http.Request, error) {
+	if len(r.Images) == 0 {
+		return nil, errors.New("no images to upload")
+	}
+
+	img := r.Images[rand.IntN(len(r.Images))]
+
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+
+	part, err := mw.CreateFormFile("image", img.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := part.Write(img.Data); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.BaseURL+"/api/search/image?"+params.Encode(), &body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return req, nil
+}
+
+// WriteText prints the throughput and latency percentiles of each kind and
+// overall, followed by any errors.
+func (rep LoadReport) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%-8s %9s %8s %9s %9s %9s %9s\n", "", "requests", "failed", "req/s", "p50", "p95", "p99")
+
+	line := func(name string, k KindReport) {
+		fmt.Fprintf(&buf, "%-8s %9d %8d %9.1f %7.1fms %7.1fms %7.1fms\n",
+			name, k.Requests, k.Failed, k.Throughput, k.Latency.P50, k.Latency.P95, k.Latency.P99)
+	}
+
+	for _, kind := range loadKinds {
+		if k, ok := rep.Kinds[kind]; ok {
+			line(kind, k)
+		}
+	}
+
+	line("total", rep.KindReport)
+
+	fmt.Fprintf(&buf, "\n%d workers, %dms, %d failed requests answered 503\n", rep.Concurrency, rep.TookMs, rep.Unavailable)
+
+	for _, e := range rep.Errors {
+		fmt.Fprintf(&buf, "error: %s\n", e)
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
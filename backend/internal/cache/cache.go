@@ -0,0 +1,17 @@
+// Package cache provides response caches for the search API.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores serialized values by key with a per-entry TTL.
+type Cache interface {
+	// Get returns the value for key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Flush drops every entry, e.g. after the collection is reseeded.
+	Flush(ctx context.Context) error
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memo holds a single lazily loaded value that expires after a TTL.
+// Concurrent callers share one load; failed loads are not cached.
+type Memo[T any] struct {
+	ttl   time.Duration
+	stats Stats
+
+	mu      sync.Mutex
+	value   T
+	expires time.Time
+}
+
+// NewMemo creates a Memo whose value is reloaded after ttl.
+func NewMemo[T any](ttl time.Duration) *Memo[T] {
+	return &Memo[T]{ttl: ttl}
+}
+
+// Get returns the cached value, calling load when it is missing or expired.
+func (m *Memo[T]) Get(ctx context.Context, load func(context.Context) (T, error)) (T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if time.Now().Before(m.expires) {
+		m.stats.Hit()
+		return m.value, nil
+	}
+
+	m.stats.Miss()
+
+	v, err := load(ctx)
+	if err != nil {
+		return v, err
+	}
+
+	m.value = v
+	m.expires = time.Now().Add(m.ttl)
+
+	return v, nil
+}
+
+// Stats returns hit and miss counts for Get.
+func (m *Memo[T]) Stats() StatsSnapshot {
+	return m.stats.Snapshot()
+}
+
+// Invalidate forces the next Get to reload the value.
+func (m *Memo[T]) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var zero T
+
+	m.value = zero
+	m.expires = time.Time{}
+}
@@ -0,0 +1,32 @@
+package cache
+
+import "sync/atomic"
+
+// Stats counts cache hits and misses; the zero value is ready to use.
+type Stats struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// StatsSnapshot is a point-in-time copy of Stats.
+type StatsSnapshot struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// Hit records a cache hit.
+func (s *Stats) Hit() { s.hits.Add(1) }
+
+// Miss records a cache miss.
+func (s *Stats) Miss() { s.misses.Add(1) }
+
+// Snapshot returns the current counters and hit rate (0 when unused).
+func (s *Stats) Snapshot() StatsSnapshot {
+	snap := StatsSnapshot{Hits: s.hits.Load(), Misses: s.misses.Load()}
+	if total := snap.Hits + snap.Misses; total > 0 {
+		snap.HitRate = float64(snap.Hits) / float64(total)
+	}
+
+	return snap
+}
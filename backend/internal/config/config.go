@@ -0,0 +1,464 @@
+// Package config resolves the server configuration from built-in defaults,
+// an optional YAML file and environment variables, each overriding the
+// previous one.
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every tunable of the server. The env tag of a field names
+// the environment variable overriding it; a reload tag marks the settings a
+// running server applies on reload, and a secret tag those that can be read
+// from a file or Vault (see resolveSecrets). Durations are whole numbers in
+// the unit their name ends with.
+type Config struct {
+	ListenAddr string `yaml:"listen_addr" env:"LISTEN_ADDR"`
+	// DebugAddr serves net/http/pprof; empty disables it.
+	DebugAddr     string `yaml:"debug_addr" env:"DEBUG_ADDR"`
+	H2CEnabled    bool   `yaml:"h2c_enabled" env:"H2C_ENABLED"`
+	ServeFrontend bool   `yaml:"serve_frontend" env:"SERVE_FRONTEND"`
+	// ShutdownTimeoutSeconds bounds the drain of in-flight requests.
+	ShutdownTimeoutSeconds int    `yaml:"shutdown_timeout_seconds" env:"SHUTDOWN_TIMEOUT_SECONDS"`
+	AdminToken             string `yaml:"admin_token" env:"ADMIN_TOKEN" secret:"true"`
+	OTLPEndpoint           string `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	RedisURL               string `yaml:"redis_url" env:"REDIS_URL" secret:"true"`
+	// ReadOnly serves searches from an already seeded collection without
+	// seeding it or changing it and without the user data store.
+	ReadOnly bool `yaml:"read_only" env:"READ_ONLY"`
+
+	Qdrant    QdrantConfig    `yaml:"qdrant"`
+	Embedder  EmbedderConfig  `yaml:"embedder"`
+	Translate TranslateConfig `yaml:"translate"`
+	Data      DataConfig      `yaml:"data"`
+	TLS       TLSConfig       `yaml:"tls"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Search    SearchConfig    `yaml:"search"`
+	Accounts  AccountsConfig  `yaml:"accounts"`
+	Analytics AnalyticsConfig `yaml:"analytics"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks"`
+	SMTP      SMTPConfig      `yaml:"smtp"`
+	Reload    ReloadConfig    `yaml:"reload"`
+	Vault     VaultConfig     `yaml:"vault"`
+	Log       LogConfig       `yaml:"log"`
+}
+
+// QdrantConfig locates the vector database and tunes seeding.
+type QdrantConfig struct {
+	Host       string `yaml:"host" env:"QDRANT_HOST"`
+	Port       int    `yaml:"port" env:"QDRANT_PORT"`
+	Collection string `yaml:"collection" env:"QDRANT_COLLECTION"`
+	APIKey     string `yaml:"api_key" env:"QDRANT_API_KEY" secret:"true"`
+	// TLS encrypts the connection, as Qdrant Cloud requires. CAFile
+	// replaces the system roots and CertFile and KeyFile present a client
+	// certificate.
+	TLS        bool   `yaml:"tls" env:"QDRANT_TLS"`
+	CAFile     string `yaml:"ca_file" env:"QDRANT_CA_FILE"`
+	ServerName string `yaml:"server_name" env:"QDRANT_TLS_SERVER_NAME"`
+	CertFile   string `yaml:"cert_file" env:"QDRANT_CLIENT_CERT_FILE"`
+	KeyFile    string `yaml:"key_file" env:"QDRANT_CLIENT_KEY_FILE"`
+	// PoolSize, KeepAliveSeconds and MaxMessageMB tune the gRPC connection;
+	// zero keeps the client defaults.
+	PoolSize         int `yaml:"pool_size" env:"QDRANT_POOL_SIZE"`
+	KeepAliveSeconds int `yaml:"keepalive_seconds" env:"QDRANT_KEEPALIVE_SECONDS"`
+	MaxMessageMB     int `yaml:"max_message_mb" env:"QDRANT_MAX_MESSAGE_MB"`
+	// BatchSize is the number of phones embedded and upserted at once.
+	BatchSize int `yaml:"batch_size" env:"SEED_BATCH_SIZE"`
+	// DownloadConcurrency bounds the parallel image downloads.
+	DownloadConcurrency int `yaml:"download_concurrency" env:"SEED_DOWNLOAD_CONCURRENCY"`
+	// DownloadUserAgent identifies the image downloads to the image hosts;
+	// empty sends downloader.DefaultUserAgent.
+	DownloadUserAgent string `yaml:"download_user_agent" env:"SEED_DOWNLOAD_USER_AGENT"`
+	// DownloadHostIntervalMS spaces the image requests to one host; zero
+	// disables the per-host limit.
+	DownloadHostIntervalMS int `yaml:"download_host_interval_ms" env:"SEED_DOWNLOAD_HOST_INTERVAL_MS"`
+	// DownloadBandwidthKB caps the combined image download rate in KiB per
+	// second; zero means unlimited.
+	DownloadBandwidthKB int `yaml:"download_bandwidth_kb" env:"SEED_DOWNLOAD_BANDWIDTH_KB"`
+	// Multivector adds token-level description vectors to new collections
+	// and reranks text searches by MaxSim over them.
+	Multivector bool `yaml:"multivector" env:"QDRANT_MULTIVECTOR"`
+	// SpecsVector adds the facts-only specs vector to new collections and
+	// combines it with the text vector in text searches, its score
+	// weighted by SpecsWeight and the text score by the rest.
+	SpecsVector bool    `yaml:"specs_vector" env:"QDRANT_SPECS_VECTOR"`
+	SpecsWeight float64 `yaml:"specs_weight" env:"QDRANT_SPECS_WEIGHT"`
+	// ModelMatchBoost is added to the text search score of the phones
+	// whose model name contains the query; zero disables the boost.
+	ModelMatchBoost float64 `yaml:"model_match_boost" env:"QDRANT_MODEL_MATCH_BOOST"`
+	// BinaryQuantization names the vectors stored binary quantized;
+	// searches over them oversample by Oversampling and rescore.
+	BinaryQuantization []string `yaml:"binary_quantization" env:"QDRANT_BINARY_QUANTIZATION"`
+	Oversampling       float64  `yaml:"oversampling" env:"QDRANT_OVERSAMPLING"`
+	// Distance and the datatypes set the vector parameters of new
+	// collections, for experimenting with other embedding models.
+	Distance      string `yaml:"distance" env:"QDRANT_DISTANCE"`
+	ImageDatatype string `yaml:"image_datatype" env:"QDRANT_IMAGE_DATATYPE"`
+	TextDatatype  string `yaml:"text_datatype" env:"QDRANT_TEXT_DATATYPE"`
+}
+
+// EmbedderConfig locates the embedding service.
+type EmbedderConfig struct {
+	URL string `yaml:"url" env:"EMBEDDER_URL"`
+	// Token is sent as a bearer token; empty sends none.
+	Token          string `yaml:"token" env:"EMBEDDER_TOKEN" secret:"true"`
+	TimeoutSeconds int    `yaml:"timeout_seconds" env:"EMBEDDER_TIMEOUT_SECONDS"`
+}
+
+// TranslateConfig locates the optional query translation service. Text
+// queries detected in one of Languages are translated to English before
+// embedding; an empty URL disables translation.
+type TranslateConfig struct {
+	URL            string   `yaml:"url" env:"TRANSLATE_URL"`
+	APIKey         string   `yaml:"api_key" env:"TRANSLATE_API_KEY" secret:"true"`
+	Languages      []string `yaml:"languages" env:"TRANSLATE_LANGUAGES"`
+	TimeoutSeconds int      `yaml:"timeout_seconds" env:"TRANSLATE_TIMEOUT_SECONDS"`
+}
+
+// DataConfig locates the files and directories the server reads and writes.
+type DataConfig struct {
+	CSVPath   string `yaml:"csv_path" env:"CSV_PATH"`
+	ImagesDir string `yaml:"images_dir" env:"IMAGES_DIR"`
+	// ImageCacheDir defaults to the .variants directory of ImagesDir.
+	ImageCacheDir     string `yaml:"image_cache_dir" env:"IMAGE_CACHE_DIR"`
+	StorePath         string `yaml:"store_path" env:"STORE_PATH"`
+	FeatureFlagsFile  string `yaml:"feature_flags_file" env:"FEATURE_FLAGS_FILE"`
+	CurrencyRatesFile string `yaml:"currency_rates_file" env:"CURRENCY_RATES_FILE"`
+	// APIKeysFile lists the API keys of external partners and their
+	// quotas; empty disables API keys.
+	APIKeysFile string `yaml:"api_keys_file" env:"API_KEYS_FILE"`
+}
+
+// TLSConfig enables HTTPS from certificate files or ACME.
+type TLSConfig struct {
+	CertFile     string   `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile      string   `yaml:"key_file" env:"TLS_KEY_FILE"`
+	ACMEDomains  []string `yaml:"acme_domains" env:"ACME_DOMAINS"`
+	ACMEEmail    string   `yaml:"acme_email" env:"ACME_EMAIL"`
+	ACMECacheDir string   `yaml:"acme_cache_dir" env:"ACME_CACHE_DIR"`
+	ACMEHTTPAddr string   `yaml:"acme_http_addr" env:"ACME_HTTP_ADDR"`
+}
+
+// CORSConfig controls cross-origin access to the API.
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" reload:"true"`
+	AllowedHeaders   []string `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" reload:"true"`
+	AllowCredentials bool     `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" reload:"true"`
+	MaxAgeSeconds    int      `yaml:"max_age_seconds" env:"CORS_MAX_AGE" reload:"true"`
+}
+
+// SearchConfig holds the caching and request limits of the API.
+type SearchConfig struct {
+	CacheTTLSeconds           int `yaml:"cache_ttl_seconds" env:"SEARCH_CACHE_TTL"`
+	FiltersTTLSeconds         int `yaml:"filters_ttl_seconds" env:"FILTERS_CACHE_TTL"`
+	MaxConcurrency            int `yaml:"max_concurrency" env:"SEARCH_MAX_CONCURRENCY" reload:"true"`
+	QueueDepth                int `yaml:"queue_depth" env:"SEARCH_QUEUE_DEPTH" reload:"true"`
+	MaxUploadMB               int `yaml:"max_upload_mb" env:"MAX_UPLOAD_MB"`
+	MaxJSONBodyKB             int `yaml:"max_json_body_kb" env:"MAX_JSON_BODY_KB"`
+	IdempotencyTTLSeconds     int `yaml:"idempotency_ttl_seconds" env:"IDEMPOTENCY_TTL"`
+	PriceWatchIntervalMinutes int `yaml:"price_watch_interval_minutes" env:"PRICE_WATCH_INTERVAL_MINUTES"`
+	// WarmQueries is how many of the most popular logged searches are run
+	// at startup to warm the caches; zero disables warming.
+	WarmQueries int `yaml:"warm_queries" env:"SEARCH_WARM_QUERIES"`
+}
+
+// AccountsConfig controls user accounts and per-caller history.
+type AccountsConfig struct {
+	Enabled         bool `yaml:"enabled" env:"ACCOUNTS_ENABLED"`
+	SessionTTLHours int  `yaml:"session_ttl_hours" env:"SESSION_TTL_HOURS"`
+	HistoryTTLHours int  `yaml:"history_ttl_hours" env:"HISTORY_TTL_HOURS"`
+	HistoryLimit    int  `yaml:"history_limit" env:"HISTORY_LIMIT"`
+}
+
+// AnalyticsConfig controls the query log and the ranking experiments.
+type AnalyticsConfig struct {
+	// Dir holds the query log; empty disables analytics.
+	Dir                string  `yaml:"dir" env:"ANALYTICS_DIR"`
+	AggregateMinutes   int     `yaml:"aggregate_minutes" env:"ANALYTICS_AGGREGATE_MINUTES"`
+	WindowDays         int     `yaml:"window_days" env:"ANALYTICS_WINDOW_DAYS"`
+	CTRBoost           float64 `yaml:"ctr_boost" env:"CTR_BOOST" reload:"true"`
+	CTRSmoothing       float64 `yaml:"ctr_smoothing" env:"CTR_SMOOTHING" reload:"true"`
+	ExperimentName     string  `yaml:"experiment_name" env:"EXPERIMENT_NAME"`
+	ExperimentVariants string  `yaml:"experiment_variants" env:"EXPERIMENT_VARIANTS"`
+}
+
+// WebhooksConfig lists the endpoints notified of catalog events.
+type WebhooksConfig struct {
+	URLs   []string `yaml:"urls" env:"WEBHOOK_URLS"`
+	Secret string   `yaml:"secret" env:"WEBHOOK_SECRET" secret:"true"`
+}
+
+// SMTPConfig configures price alert emails; an empty Addr disables them.
+type SMTPConfig struct {
+	Addr     string `yaml:"addr" env:"SMTP_ADDR"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// ReloadConfig sets how often the watched files are checked for changes.
+type ReloadConfig struct {
+	FeatureFlagsSeconds  int `yaml:"feature_flags_seconds" env:"FEATURE_FLAGS_RELOAD_SECONDS"`
+	CurrencyRatesSeconds int `yaml:"currency_rates_seconds" env:"CURRENCY_RATES_RELOAD_SECONDS"`
+	// APIKeysSeconds is also how often API key usage is persisted.
+	APIKeysSeconds int `yaml:"api_keys_seconds" env:"API_KEYS_RELOAD_SECONDS"`
+	// ConfigSeconds is the check interval of the configuration file; zero
+	// reloads it on SIGHUP only.
+	ConfigSeconds int `yaml:"config_seconds" env:"CONFIG_RELOAD_SECONDS"`
+}
+
+// VaultConfig locates the HashiCorp Vault server secrets may be read from.
+type VaultConfig struct {
+	Addr  string `yaml:"addr" env:"VAULT_ADDR"`
+	Token string `yaml:"token" env:"VAULT_TOKEN" secret:"true"`
+}
+
+// LogConfig sets the format and minimum level of the logs.
+type LogConfig struct {
+	// Format is "text" or "json".
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+	// Level is "debug", "info", "warn" or "error"; it can be changed at
+	// runtime through the admin API.
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	return Config{
+		ListenAddr:             ":8080",
+		ShutdownTimeoutSeconds: 30,
+		Qdrant: QdrantConfig{
+			Host:                   "localhost",
+			Port:                   6334,
+			Collection:             "smartphones",
+			BatchSize:              64,
+			DownloadConcurrency:    10,
+			DownloadHostIntervalMS: 250,
+			SpecsWeight:            0.5,
+			ModelMatchBoost:        1,
+			Oversampling:           3,
+			Distance:               "cosine",
+			ImageDatatype:          "float32",
+			TextDatatype:           "float32",
+		},
+		Embedder: EmbedderConfig{
+			URL:            "http://localhost:8000",
+			TimeoutSeconds: 120,
+		},
+		Translate: TranslateConfig{TimeoutSeconds: 2},
+		Data: DataConfig{
+			CSVPath:   "data/smartphones.csv",
+			ImagesDir: "images",
+			StorePath: "data/phone-seek.db",
+		},
+		TLS: TLSConfig{ACMECacheDir: "certs"},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			MaxAgeSeconds:  600,
+		},
+		Search: SearchConfig{
+			CacheTTLSeconds:           60,
+			FiltersTTLSeconds:         300,
+			MaxConcurrency:            16,
+			QueueDepth:                64,
+			MaxUploadMB:               10,
+			MaxJSONBodyKB:             1024,
+			IdempotencyTTLSeconds:     600,
+			PriceWatchIntervalMinutes: 60,
+			WarmQueries:               50,
+		},
+		Accounts: AccountsConfig{
+			SessionTTLHours: 720,
+			HistoryTTLHours: 720,
+			HistoryLimit:    50,
+		},
+		Analytics: AnalyticsConfig{
+			Dir:              "data/analytics",
+			AggregateMinutes: 15,
+			WindowDays:       30,
+			CTRSmoothing:     10,
+			ExperimentName:   "ranking",
+		},
+		Reload: ReloadConfig{
+			FeatureFlagsSeconds:  5,
+			CurrencyRatesSeconds: 60,
+			APIKeysSeconds:       10,
+			ConfigSeconds:        10,
+		},
+		Log: LogConfig{
+			Format: "text",
+			Level:  "info",
+		},
+	}
+}
+
+// Load returns the defaults overridden by the YAML file at path, then by
+// the environment, with secrets resolved. An empty path skips the file;
+// unknown keys in it are errors. Empty environment variables count as unset.
+// Every invalid value is reported in the returned error, not just the first.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading config file: %w", err)
+		}
+
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+
+		// An empty file decodes to io.EOF and keeps the defaults.
+		if err := dec.Decode(&cfg); err != nil && len(bytes.TrimSpace(data)) > 0 {
+			return Config{}, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	errs := applyEnv(reflect.ValueOf(&cfg).Elem())
+	errs = append(errs, resolveSecrets(&cfg)...)
+
+	if cfg.Data.ImageCacheDir == "" {
+		cfg.Data.ImageCacheDir = filepath.Join(cfg.Data.ImagesDir, ".variants")
+	}
+
+	if len(cfg.CORS.AllowedOrigins) == 0 {
+		cfg.CORS.AllowedOrigins = []string{"*"}
+	}
+
+	errs = append(errs, cfg.Validate()...)
+
+	return cfg, errors.Join(errs...)
+}
+
+// applyEnv overrides the fields of the struct v from the environment
+// variables named by their env tags.
+func applyEnv(v reflect.Value) []error {
+	var errs []error
+
+	for i := range v.NumField() {
+		field, sf := v.Field(i), v.Type().Field(i)
+
+		if sf.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(field)...)
+			continue
+		}
+
+		key := sf.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		val := os.Getenv(key)
+
+		if sf.Tag.Get("secret") == "true" {
+			fileVal, err := readSecretFile(key, val)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			val = cmp.Or(fileVal, val)
+		}
+
+		if val != "" {
+			if err := setField(field, val); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+// setField parses val into field according to its type.
+func setField(field reflect.Value, val string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Int:
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+
+		field.SetBool(b)
+	case reflect.Slice:
+		field.Set(reflect.ValueOf(splitList(val)))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// Changed returns the environment variable names of the settings that
+// differ between prev and next, split into those a running server applies on
+// reload and those that take effect only after a restart.
+func Changed(prev, next Config) (reloadable, restart []string) {
+	changed(reflect.ValueOf(prev), reflect.ValueOf(next), &reloadable, &restart)
+	return reloadable, restart
+}
+
+func changed(a, b reflect.Value, reloadable, restart *[]string) {
+	for i := range a.NumField() {
+		fa, fb, sf := a.Field(i), b.Field(i), a.Type().Field(i)
+
+		if sf.Type.Kind() == reflect.Struct {
+			changed(fa, fb, reloadable, restart)
+			continue
+		}
+
+		// A nil and an empty list are the same setting.
+		if sf.Type.Kind() == reflect.Slice && fa.Len() == 0 && fb.Len() == 0 {
+			continue
+		}
+
+		if reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			continue
+		}
+
+		key := sf.Tag.Get("env")
+		if sf.Tag.Get("reload") == "true" {
+			*reloadable = append(*reloadable, key)
+		} else {
+			*restart = append(*restart, key)
+		}
+	}
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Preflight checks that the directories the server writes to can be
+// written, creating missing ones, and returns one error per directory that
+// cannot. Unlike Validate it touches the filesystem, so it runs once at
+// startup rather than on every Load.
+func (c Config) Preflight() []error {
+	var errs []error
+
+	check := func(dir, key string) {
+		if err := writableDir(dir); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	// A read-only server neither downloads images nor opens the store.
+	if !c.ReadOnly {
+		check(c.Data.ImagesDir, "IMAGES_DIR")
+		check(filepath.Dir(c.Data.StorePath), "STORE_PATH")
+	}
+
+	check(c.Data.ImageCacheDir, "IMAGE_CACHE_DIR")
+
+	if c.Analytics.Dir != "" {
+		check(c.Analytics.Dir, "ANALYTICS_DIR")
+	}
+
+	if len(c.TLS.ACMEDomains) > 0 {
+		check(c.TLS.ACMECacheDir, "ACME_CACHE_DIR")
+	}
+
+	return errs
+}
+
+// writableDir creates dir if needed and checks that a file can be created
+// in it.
+func writableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, ".preflight-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+
+	_ = f.Close()
+
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// vaultPrefix marks a secret value as a reference to a Vault KV version 2
+// secret: "vault:<mount>/<path>#<key>", e.g. "vault:secret/phoneseek#admin_token".
+const vaultPrefix = "vault:"
+
+// vaultTimeout bounds each request to Vault.
+const vaultTimeout = 10 * time.Second
+
+// readSecretFile returns the contents of the file named by the <key>_FILE
+// environment variable, without the trailing newline, or an empty string
+// when it is unset. Setting both key and <key>_FILE is an error.
+func readSecretFile(key, val string) (string, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", nil
+	}
+
+	if val != "" {
+		return "", fmt.Errorf("%s: cannot be combined with %s_FILE", key, key)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE: %w", key, err)
+	}
+
+	secret := strings.TrimRight(string(b), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s_FILE: %s is empty", key, path)
+	}
+
+	return secret, nil
+}
+
+// resolveSecrets replaces the secret settings of cfg holding a Vault
+// reference with the value read from Vault. Each secret path is read once.
+func resolveSecrets(cfg *Config) []error {
+	vault := &vaultClient{
+		addr:    strings.TrimRight(cfg.Vault.Addr, "/"),
+		token:   cfg.Vault.Token,
+		secrets: map[string]map[string]any{},
+		http:    &http.Client{Timeout: vaultTimeout},
+	}
+
+	return resolve(reflect.ValueOf(cfg).Elem(), vault)
+}
+
+func resolve(v reflect.Value, vault *vaultClient) []error {
+	var errs []error
+
+	for i := range v.NumField() {
+		field, sf := v.Field(i), v.Type().Field(i)
+
+		if sf.Type.Kind() == reflect.Struct {
+			errs = append(errs, resolve(field, vault)...)
+			continue
+		}
+
+		ref, ok := strings.CutPrefix(field.String(), vaultPrefix)
+		if sf.Tag.Get("secret") != "true" || !ok {
+			continue
+		}
+
+		secret, err := vault.read(ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sf.Tag.Get("env"), err))
+			continue
+		}
+
+		field.SetString(secret)
+	}
+
+	return errs
+}
+
+// vaultClient reads KV version 2 secrets over the Vault HTTP API.
+type vaultClient struct {
+	addr    string
+	token   string
+	secrets map[string]map[string]any
+	http    *http.Client
+}
+
+// read returns the key of the secret named by ref ("<mount>/<path>#<key>").
+func (c *vaultClient) read(ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	mount, rest, hasPath := strings.Cut(path, "/")
+
+	if !ok || key == "" || !hasPath || mount == "" || rest == "" {
+		return "", fmt.Errorf("vault reference %q must look like vault:<mount>/<path>#<key>", vaultPrefix+ref)
+	}
+
+	if c.addr == "" || c.token == "" {
+		return "", fmt.Errorf("vault reference %q requires VAULT_ADDR and VAULT_TOKEN", vaultPrefix+ref)
+	}
+
+	data, ok := c.secrets[path]
+	if !ok {
+		var err error
+		if data, err = c.fetch(mount, rest); err != nil {
+			return "", err
+		}
+
+		c.secrets[path] = data
+	}
+
+	secret, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string key %q", path, key)
+	}
+
+	return secret, nil
+}
+
+// fetch reads the latest version of the secret at path in the KV mount.
+func (c *vaultClient) fetch(mount, path string) (map[string]any, error) {
+	req, err := http.NewRequest(http.MethodGet, c.addr+"/v1/"+url.PathEscape(mount)+"/data/"+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault secret %s/%s: %w", mount, path, err)
+	}
+
+	req.Header.Set("X-Vault-Token", c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault secret %s/%s: %w", mount, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading vault secret %s/%s: vault returned status %d", mount, path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding vault secret %s/%s: %w", mount, path, err)
+	}
+
+	return body.Data.Data, nil
+}
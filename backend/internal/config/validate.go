@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/logging"
+)
+
+// distances, datatypes and vectors are the accepted vector distances,
+// datatypes and names.
+var (
+	distances = []string{"cosine", "dot", "euclid"}
+	datatypes = []string{"float32", "float16", "uint8"}
+	vectors   = []string{"image", "text", "specs", "tokens"}
+)
+
+// Validate checks that the numeric settings are within their bounds and
+// returns one error per invalid setting, named by its environment variable.
+func (c Config) Validate() []error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+		}
+	}
+
+	checkAddr := func(addr, key string, optional bool) {
+		if addr == "" && optional {
+			return
+		}
+
+		check(validAddr(addr), key, "must be a host:port address with a port between 1 and 65535")
+	}
+
+	checkAddr(c.ListenAddr, "LISTEN_ADDR", false)
+	checkAddr(c.DebugAddr, "DEBUG_ADDR", true)
+	checkAddr(c.TLS.ACMEHTTPAddr, "ACME_HTTP_ADDR", true)
+	check(c.DebugAddr == "" || port(c.DebugAddr) != port(c.ListenAddr), "DEBUG_ADDR", "must not use the port of LISTEN_ADDR")
+	check(c.TLS.ACMEHTTPAddr == "" || port(c.TLS.ACMEHTTPAddr) != port(c.ListenAddr), "ACME_HTTP_ADDR", "must not use the port of LISTEN_ADDR")
+	check(c.Qdrant.Host != "", "QDRANT_HOST", "must not be empty")
+	check(c.Qdrant.Port > 0 && c.Qdrant.Port <= 65535, "QDRANT_PORT", "must be between 1 and 65535")
+	check(c.Qdrant.TLS || (c.Qdrant.CAFile == "" && c.Qdrant.CertFile == "" && c.Qdrant.ServerName == ""), "QDRANT_TLS", "must be true when a Qdrant CA, client certificate or server name is set")
+	check((c.Qdrant.CertFile == "") == (c.Qdrant.KeyFile == ""), "QDRANT_CLIENT_CERT_FILE", "must be set together with QDRANT_CLIENT_KEY_FILE")
+	check(c.Qdrant.PoolSize >= 0, "QDRANT_POOL_SIZE", "must not be negative")
+	check(c.Qdrant.KeepAliveSeconds >= -1, "QDRANT_KEEPALIVE_SECONDS", "must be -1 or more")
+	check(c.Qdrant.MaxMessageMB >= 0, "QDRANT_MAX_MESSAGE_MB", "must not be negative")
+	check(c.Qdrant.Collection != "", "QDRANT_COLLECTION", "must not be empty")
+	check(c.Qdrant.BatchSize > 0, "SEED_BATCH_SIZE", "must be positive")
+	check(c.Qdrant.DownloadConcurrency > 0, "SEED_DOWNLOAD_CONCURRENCY", "must be positive")
+	check(c.Qdrant.DownloadHostIntervalMS >= 0, "SEED_DOWNLOAD_HOST_INTERVAL_MS", "must not be negative")
+	check(c.Qdrant.DownloadBandwidthKB >= 0, "SEED_DOWNLOAD_BANDWIDTH_KB", "must not be negative")
+	check(slices.Contains(distances, c.Qdrant.Distance), "QDRANT_DISTANCE", "must be one of %s", strings.Join(distances, ", "))
+	check(slices.Contains(datatypes, c.Qdrant.ImageDatatype), "QDRANT_IMAGE_DATATYPE", "must be one of %s", strings.Join(datatypes, ", "))
+	check(slices.Contains(datatypes, c.Qdrant.TextDatatype), "QDRANT_TEXT_DATATYPE", "must be one of %s", strings.Join(datatypes, ", "))
+	check(c.Qdrant.SpecsWeight > 0 && c.Qdrant.SpecsWeight <= 1, "QDRANT_SPECS_WEIGHT", "must be greater than 0 and at most 1")
+	check(!c.Qdrant.SpecsVector || c.Qdrant.Distance != "euclid", "QDRANT_SPECS_VECTOR", "cannot be combined with QDRANT_DISTANCE=euclid, whose scores are distances")
+	check(c.Qdrant.ModelMatchBoost >= 0, "QDRANT_MODEL_MATCH_BOOST", "must not be negative")
+	check(!slices.ContainsFunc(c.Qdrant.BinaryQuantization, func(v string) bool { return !slices.Contains(vectors, v) }), "QDRANT_BINARY_QUANTIZATION", "must list vectors among %s", strings.Join(vectors, ", "))
+	check(c.Qdrant.Oversampling >= 1, "QDRANT_OVERSAMPLING", "must be at least 1")
+	check(httpURL(c.Embedder.URL), "EMBEDDER_URL", "must be an absolute http or https URL")
+	check(c.Embedder.TimeoutSeconds > 0, "EMBEDDER_TIMEOUT_SECONDS", "must be positive")
+	check(c.Translate.URL == "" || httpURL(c.Translate.URL), "TRANSLATE_URL", "must be an absolute http or https URL")
+	check(c.Translate.URL == "" || len(c.Translate.Languages) > 0, "TRANSLATE_LANGUAGES", "is required when TRANSLATE_URL is set")
+	check(c.Translate.TimeoutSeconds > 0, "TRANSLATE_TIMEOUT_SECONDS", "must be positive")
+	check(c.Data.CSVPath != "", "CSV_PATH", "must not be empty")
+	check(c.Data.ImagesDir != "", "IMAGES_DIR", "must not be empty")
+	check(c.Data.StorePath != "", "STORE_PATH", "must not be empty")
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "TLS_CERT_FILE", "must be set together with TLS_KEY_FILE")
+	check(c.TLS.CertFile == "" || len(c.TLS.ACMEDomains) == 0, "ACME_DOMAINS", "cannot be combined with TLS_CERT_FILE")
+	check(c.RedisURL == "" || hasScheme(c.RedisURL, "redis", "rediss"), "REDIS_URL", "must be a redis:// or rediss:// URL")
+	check(c.Vault.Addr == "" || httpURL(c.Vault.Addr), "VAULT_ADDR", "must be an absolute http or https URL")
+	check(len(c.Webhooks.URLs) == 0 || c.Webhooks.Secret != "", "WEBHOOK_SECRET", "is required when WEBHOOK_URLS is set")
+	check(!slices.ContainsFunc(c.Webhooks.URLs, func(u string) bool { return !httpURL(u) }), "WEBHOOK_URLS", "must be absolute http or https URLs")
+	check(c.ShutdownTimeoutSeconds > 0, "SHUTDOWN_TIMEOUT_SECONDS", "must be positive")
+	check(c.CORS.MaxAgeSeconds >= 0, "CORS_MAX_AGE", "must not be negative")
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"), "CORS_ALLOW_CREDENTIALS", "cannot be combined with CORS_ALLOWED_ORIGINS=*, list the allowed origins")
+	check(c.Search.CacheTTLSeconds >= 0, "SEARCH_CACHE_TTL", "must not be negative")
+	check(c.Search.FiltersTTLSeconds >= 0, "FILTERS_CACHE_TTL", "must not be negative")
+	check(c.Search.MaxConcurrency >= 0, "SEARCH_MAX_CONCURRENCY", "must not be negative")
+	check(c.Search.QueueDepth >= 0, "SEARCH_QUEUE_DEPTH", "must not be negative")
+	check(c.Search.MaxUploadMB > 0, "MAX_UPLOAD_MB", "must be positive")
+	check(c.Search.MaxJSONBodyKB > 0, "MAX_JSON_BODY_KB", "must be positive")
+	check(c.Search.IdempotencyTTLSeconds >= 0, "IDEMPOTENCY_TTL", "must not be negative")
+	check(c.Search.PriceWatchIntervalMinutes >= 0, "PRICE_WATCH_INTERVAL_MINUTES", "must not be negative")
+	check(c.Search.WarmQueries >= 0, "SEARCH_WARM_QUERIES", "must not be negative")
+	check(!c.ReadOnly || !c.Accounts.Enabled, "ACCOUNTS_ENABLED", "cannot be combined with READ_ONLY")
+	check(c.Accounts.SessionTTLHours > 0, "SESSION_TTL_HOURS", "must be positive")
+	check(c.Accounts.HistoryTTLHours >= 0, "HISTORY_TTL_HOURS", "must not be negative")
+	check(c.Accounts.HistoryLimit >= 0, "HISTORY_LIMIT", "must not be negative")
+	check(c.Analytics.AggregateMinutes > 0, "ANALYTICS_AGGREGATE_MINUTES", "must be positive")
+	check(c.Analytics.WindowDays > 0, "ANALYTICS_WINDOW_DAYS", "must be positive")
+	check(c.Analytics.CTRBoost >= 0, "CTR_BOOST", "must not be negative")
+	check(c.Analytics.CTRSmoothing >= 0, "CTR_SMOOTHING", "must not be negative")
+	check(c.Reload.FeatureFlagsSeconds > 0, "FEATURE_FLAGS_RELOAD_SECONDS", "must be positive")
+	check(c.Reload.CurrencyRatesSeconds > 0, "CURRENCY_RATES_RELOAD_SECONDS", "must be positive")
+	check(c.Reload.APIKeysSeconds > 0, "API_KEYS_RELOAD_SECONDS", "must be positive")
+	check(c.Reload.ConfigSeconds >= 0, "CONFIG_RELOAD_SECONDS", "must not be negative")
+	check(c.Log.Format == logging.FormatText || c.Log.Format == logging.FormatJSON, "LOG_FORMAT", "must be text or json")
+
+	_, err := logging.ParseLevel(c.Log.Level)
+	check(err == nil, "LOG_LEVEL", "must be one of %s", strings.Join(logging.Levels, ", "))
+
+	return errs
+}
+
+// validAddr reports whether addr is a listen address such as ":8080" or
+// "127.0.0.1:6060".
+func validAddr(addr string) bool {
+	p := port(addr)
+	return p > 0 && p <= 65535
+}
+
+// port returns the port of the host:port address addr, or 0 when it has
+// none.
+func port(addr string) int {
+	_, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(p)
+
+	return n
+}
+
+// httpURL reports whether s is an absolute http or https URL.
+func httpURL(s string) bool {
+	return hasScheme(s, "http", "https")
+}
+
+// hasScheme reports whether s is an absolute URL with a host and one of
+// schemes.
+func hasScheme(s string, schemes ...string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Host != "" && slices.Contains(schemes, u.Scheme)
+}
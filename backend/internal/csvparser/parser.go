@@ -17,10 +17,14 @@ var columnMapping = []struct {
 	csvColumn string
 	setter    func(*model.Smartphone, string)
 }{
-	{"Brand", func(s *model.Smartphone, v string) { s.Brand = v }},
+	{"Brand", func(s *model.Smartphone, v string) { s.Brand = model.CanonicalBrand(v) }},
 	{"Model Name", func(s *model.Smartphone, v string) { s.Model = v }},
 	{"Model Image", func(s *model.Smartphone, v string) { s.ImageURL = v }},
 	{"Technology", func(s *model.Smartphone, v string) { s.Technology = v }},
+	{"2G bands", func(s *model.Smartphone, v string) { s.Bands2G = v }},
+	{"3G bands", func(s *model.Smartphone, v string) { s.Bands3G = v }},
+	{"4G bands", func(s *model.Smartphone, v string) { s.Bands4G = v }},
+	{"5G bands", func(s *model.Smartphone, v string) { s.Bands5G = v }},
 	{"Announced", func(s *model.Smartphone, v string) { s.Announced = v }},
 	{"Status", func(s *model.Smartphone, v string) { s.Status = v }},
 	{"Dimensions", func(s *model.Smartphone, v string) { s.Dimensions = v }},
@@ -47,6 +51,7 @@ var columnMapping = []struct {
 	{"NFC", func(s *model.Smartphone, v string) { s.NFC = v }},
 	{"USB", func(s *model.Smartphone, v string) { s.USB = v }},
 	{"Sensors", func(s *model.Smartphone, v string) { s.Sensors = v }},
+	{"Infrared port", func(s *model.Smartphone, v string) { s.Infrared = v }},
 	{"Colors", func(s *model.Smartphone, v string) { s.Colors = v }},
 	{"Price", func(s *model.Smartphone, v string) { s.Price = v }},
 }
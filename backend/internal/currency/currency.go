@@ -0,0 +1,220 @@
+// Package currency converts prices between the currencies found in the phone
+// dataset using an exchange rate table that can be overridden by a JSON file
+// reloaded when it changes.
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Supported currencies, as ISO 4217 codes.
+const (
+	EUR = "EUR"
+	GBP = "GBP"
+	INR = "INR"
+	USD = "USD"
+)
+
+// Supported lists the currencies prices can be parsed from and converted to.
+var Supported = []string{EUR, GBP, INR, USD}
+
+// defaults are approximate rates in units per euro, used until a rates file
+// overrides them.
+var defaults = map[string]float64{
+	EUR: 1,
+	GBP: 0.85,
+	INR: 90,
+	USD: 1.08,
+}
+
+// Rates holds exchange rates as units of each currency per euro. A nil Rates
+// uses the built-in defaults.
+type Rates struct {
+	path string
+
+	mu      sync.RWMutex
+	rates   map[string]float64
+	modTime time.Time
+}
+
+// Load returns the built-in rates overlaid with the JSON object of rates per
+// euro at path, e.g. {"USD": 1.09, "GBP": 0.86}. An empty path skips the file.
+func Load(path string) (*Rates, error) {
+	r := &Rates{path: path, rates: maps.Clone(defaults)}
+
+	if path != "" {
+		if _, err := r.reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// ToEUR converts amount in cur to euros. Unknown currencies return false.
+func (r *Rates) ToEUR(amount float64, cur string) (float64, bool) {
+	rate, ok := r.rate(cur)
+	if !ok {
+		return 0, false
+	}
+
+	return round(amount / rate), true
+}
+
+// FromEUR converts amount in euros to cur. Unknown currencies return false.
+func (r *Rates) FromEUR(amount float64, cur string) (float64, bool) {
+	rate, ok := r.rate(cur)
+	if !ok {
+		return 0, false
+	}
+
+	return round(amount * rate), true
+}
+
+// All returns a copy of the current rates per euro.
+func (r *Rates) All() map[string]float64 {
+	if r == nil {
+		return maps.Clone(defaults)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return maps.Clone(r.rates)
+}
+
+func (r *Rates) rate(cur string) (float64, bool) {
+	if r == nil {
+		rate, ok := defaults[cur]
+		return rate, ok
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rate, ok := r.rates[cur]
+
+	return rate, ok
+}
+
+// Watch reloads the rates file every interval when its modification time
+// changes, until ctx is cancelled. An invalid file keeps the previous rates.
+func (r *Rates) Watch(ctx context.Context, interval time.Duration) {
+	if r == nil || r.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := r.reload()
+		if err != nil {
+			slog.WarnContext(ctx, "reloading exchange rates failed", slog.String("path", r.path), slog.String("error", err.Error()))
+			continue
+		}
+
+		if changed {
+			slog.InfoContext(ctx, "exchange rates reloaded", slog.Any("rates", r.All()))
+		}
+	}
+}
+
+// reload re-reads the rates file if it changed since the last read. A
+// missing file leaves the built-in rates in effect.
+func (r *Rates) reload() (bool, error) {
+	info, err := os.Stat(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r.set(maps.Clone(defaults), time.Time{}), nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("reading exchange rates: %w", err)
+	}
+
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, fmt.Errorf("reading exchange rates: %w", err)
+	}
+
+	rates, err := parse(b)
+	if err != nil {
+		// Remember the broken version so it is reported only once.
+		r.mu.Lock()
+		r.modTime = info.ModTime()
+		r.mu.Unlock()
+
+		return false, fmt.Errorf("parsing exchange rates %s: %w", r.path, err)
+	}
+
+	return r.set(rates, info.ModTime()), nil
+}
+
+// parse overlays the JSON rates in b on the defaults.
+func parse(b []byte) (map[string]float64, error) {
+	var file map[string]float64
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, err
+	}
+
+	rates := maps.Clone(defaults)
+
+	for cur, rate := range file {
+		if !slices.Contains(Supported, cur) {
+			return nil, fmt.Errorf("unsupported currency %q", cur)
+		}
+
+		if rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("rate of %s must be positive", cur)
+		}
+
+		if cur == EUR && rate != 1 {
+			return nil, errors.New("rates are per euro, so EUR must be 1")
+		}
+
+		rates[cur] = rate
+	}
+
+	return rates, nil
+}
+
+// set installs rates, reporting whether any rate changed.
+func (r *Rates) set(rates map[string]float64, modTime time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := !maps.Equal(r.rates, rates)
+	r.rates = rates
+	r.modTime = modTime
+
+	return changed
+}
+
+// round keeps converted prices to cents.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,277 @@
+// Package downloader fetches files over HTTP politely: a bounded number at
+// a time, each host at most once per interval, within an overall bandwidth
+// cap and under an identifying User-Agent.
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults for the zero fields of Options.
+const (
+	DefaultConcurrency = 10
+	DefaultUserAgent   = "phone.seek-image-downloader/1.0"
+	defaultTimeout     = time.Minute
+)
+
+// How long a 429 or 503 response holds back its host: its Retry-After,
+// capped at maxRetryAfter, or defaultRetryAfter without one.
+const (
+	defaultRetryAfter = 30 * time.Second
+	maxRetryAfter     = 5 * time.Minute
+)
+
+// Options tunes a Downloader; zero fields keep the defaults, and zero
+// HostInterval and BytesPerSecond mean no limit.
+type Options struct {
+	// Concurrency bounds the downloads in flight.
+	Concurrency int
+	// UserAgent identifies the downloads to the image hosts.
+	UserAgent string
+	// HostInterval is the minimum time between the starts of two requests
+	// to the same host.
+	HostInterval time.Duration
+	// BytesPerSecond caps the combined download rate.
+	BytesPerSecond int64
+	// Timeout bounds each download, body included.
+	Timeout time.Duration
+}
+
+// Downloader fetches URLs into files; it is safe for concurrent use.
+type Downloader struct {
+	client    *http.Client
+	userAgent string
+	slots     chan struct{}
+	interval  time.Duration
+	bandwidth *limiter
+
+	mu sync.Mutex
+	// next is the earliest start of the next request to each host.
+	next map[string]time.Time
+}
+
+// New returns a Downloader with the options o.
+func New(o Options) *Downloader {
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConcurrency
+	}
+
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+
+	d := &Downloader{
+		client:    &http.Client{Timeout: o.Timeout},
+		userAgent: o.UserAgent,
+		slots:     make(chan struct{}, o.Concurrency),
+		interval:  o.HostInterval,
+		next:      map[string]time.Time{},
+	}
+
+	if o.BytesPerSecond > 0 {
+		d.bandwidth = &limiter{rate: float64(o.BytesPerSecond)}
+	}
+
+	return d
+}
+
+// StatusError is returned for responses other than 200 OK.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// Download fetches rawURL into the file dest, waiting for a free slot and
+// for the host's turn. The body is written to a temporary file renamed to
+// dest once complete, so an interrupted download leaves no partial file.
+// A 429 or 503 response holds back the host's later requests for its
+// Retry-After.
+func (d *Downloader) Download(ctx context.Context, rawURL, dest string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parsing url: %w", err)
+	}
+
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-d.slots }()
+
+	if err := sleep(ctx, d.reserve(u.Host)); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", d.userAgent)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			d.holdBack(u.Host, retryAfter(resp.Header.Get("Retry-After")))
+		}
+
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return d.save(ctx, resp.Body, dest)
+}
+
+// save writes body to dest through a temporary file in the same directory.
+func (d *Downloader) save(ctx context.Context, body io.Reader, dest string) error {
+	f, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.part")
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+
+	tmp := f.Name()
+
+	if d.bandwidth != nil {
+		body = &throttledReader{ctx: ctx, r: body, l: d.bandwidth}
+	}
+
+	_, err = io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, dest)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("writing file: %w", err)
+	}
+
+	return nil
+}
+
+// reserve books the next request slot of host and returns how long to
+// wait for it.
+func (d *Downloader) reserve(host string) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	start := d.next[host]
+
+	if start.Before(now) {
+		start = now
+	}
+
+	d.next[host] = start.Add(d.interval)
+
+	return start.Sub(now)
+}
+
+// holdBack delays the next request to host by at least wait.
+func (d *Downloader) holdBack(host string, wait time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if until := time.Now().Add(wait); until.After(d.next[host]) {
+		d.next[host] = until
+	}
+}
+
+// retryAfter parses a Retry-After header in seconds or as an HTTP date,
+// capped at maxRetryAfter; unparsable or missing values wait
+// defaultRetryAfter.
+func retryAfter(header string) time.Duration {
+	wait := defaultRetryAfter
+
+	if secs, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(header); err == nil {
+		wait = time.Until(t)
+	}
+
+	return min(max(wait, 0), maxRetryAfter)
+}
+
+// limiter spaces reads so that they total at most rate bytes per second.
+type limiter struct {
+	rate float64
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait charges n bytes to the limiter and sleeps until the rate allows
+// them.
+func (l *limiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	until := l.next
+	l.mu.Unlock()
+
+	return sleep(ctx, until.Sub(now))
+}
+
+// throttledReader reads from r within the bandwidth of l.
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *limiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if werr := t.l.wait(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -5,26 +5,42 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/logging"
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/alessandrolattao/qdrant-experiment/internal/embedder")
+
+// ErrUnavailable reports that the embedding service could not be reached or
+// failed to process the request on its side.
+var ErrUnavailable = errors.New("embedder unavailable")
+
 // Client communicates with the embedding service (CLIP + BGE-M3).
 type Client struct {
 	baseURL    string
+	token      string
 	httpClient *http.Client
 }
 
-// NewClient creates a new embedder client.
-func NewClient(baseURL string) *Client {
+// NewClient creates a new embedder client whose requests time out after
+// timeout. A non-empty token is sent as a bearer token.
+func NewClient(baseURL, token string, timeout time.Duration) *Client {
 	return &Client{
 		baseURL: baseURL,
+		token:   token,
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: timeout,
 		},
 	}
 }
@@ -49,6 +65,14 @@ type embeddingsResponse struct {
 	Embeddings [][]float32 `json:"embeddings"`
 }
 
+type tokensResponse struct {
+	Tokens [][]float32 `json:"tokens"`
+}
+
+type tokensBatchResponse struct {
+	Tokens [][][]float32 `json:"tokens"`
+}
+
 // EmbedText returns the BGE-M3 embedding for a text query (1024d).
 func (c *Client) EmbedText(ctx context.Context, text string) ([]float32, error) {
 	body, err := json.Marshal(textRequest{Text: text})
@@ -69,6 +93,41 @@ func (c *Client) EmbedTexts(ctx context.Context, texts []string) ([][]float32, e
 	return c.postEmbeddings(ctx, "/embed/texts", body)
 }
 
+// EmbedTextTokens returns the token-level BGE-M3 embeddings of a text query
+// (1024d each), for late interaction (MaxSim) scoring.
+func (c *Client) EmbedTextTokens(ctx context.Context, text string) ([][]float32, error) {
+	body, err := json.Marshal(textRequest{Text: text})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	var result tokensResponse
+	if err := c.postJSON(ctx, "/embed/text/tokens", body, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Tokens, nil
+}
+
+// EmbedTextsTokens returns the token-level BGE-M3 embeddings of each text.
+func (c *Client) EmbedTextsTokens(ctx context.Context, texts []string) ([][][]float32, error) {
+	body, err := json.Marshal(textsRequest{Texts: texts})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	var result tokensBatchResponse
+	if err := c.postJSON(ctx, "/embed/texts/tokens", body, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Tokens) != len(texts) {
+		return nil, fmt.Errorf("embedder returned %d token embeddings for %d texts", len(result.Tokens), len(texts))
+	}
+
+	return result.Tokens, nil
+}
+
 // EmbedImage returns the CLIP embedding for an uploaded image (512d).
 func (c *Client) EmbedImage(ctx context.Context, imageData io.Reader, filename string) ([]float32, error) {
 	var buf bytes.Buffer
@@ -87,26 +146,16 @@ func (c *Client) EmbedImage(ctx context.Context, imageData io.Reader, filename s
 		return nil, fmt.Errorf("closing multipart writer: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/embed/image", &buf)
+	req, err := c.newRequest(ctx, http.MethodPost, "/embed/image", &buf)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("embedder returned status %d", resp.StatusCode)
-	}
-
 	var result embeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+	if err := c.do(req, &result); err != nil {
+		return nil, err
 	}
 
 	return result.Embedding, nil
@@ -122,6 +171,20 @@ func (c *Client) EmbedImagePaths(ctx context.Context, paths []string) ([][]float
 	return c.postEmbeddings(ctx, "/embed/image-paths", body)
 }
 
+// Health calls the embedder health endpoint once.
+func (c *Client) Health(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/health", nil)
+	if err != nil {
+		return fmt.Errorf("creating health request: %w", err)
+	}
+
+	var status struct {
+		Status string `json:"status"`
+	}
+
+	return c.do(req, &status)
+}
+
 // WaitReady polls the embedder health endpoint until it responds.
 func (c *Client) WaitReady(ctx context.Context) error {
 	for {
@@ -131,19 +194,9 @@ func (c *Client) WaitReady(ctx context.Context) error {
 		default:
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
-		if err != nil {
-			return fmt.Errorf("creating health request: %w", err)
-		}
-
-		resp, err := c.httpClient.Do(req)
-		if err == nil {
-			_ = resp.Body.Close()
-
-			if resp.StatusCode == http.StatusOK {
-				slog.Info("embedder service is ready")
-				return nil
-			}
+		if err := c.Health(ctx); err == nil {
+			slog.Info("embedder service is ready")
+			return nil
 		}
 
 		slog.Info("waiting for embedder service...")
@@ -151,54 +204,88 @@ func (c *Client) WaitReady(ctx context.Context) error {
 	}
 }
 
-func (c *Client) postEmbedding(ctx context.Context, path string, body []byte) ([]float32, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+// newRequest builds a request to the embedder, authenticated with the token
+// and propagating the request ID from ctx.
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("embedder returned status %d", resp.StatusCode)
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
 	}
 
+	return req, nil
+}
+
+func (c *Client) postEmbedding(ctx context.Context, path string, body []byte) ([]float32, error) {
 	var result embeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+	if err := c.postJSON(ctx, path, body, &result); err != nil {
+		return nil, err
 	}
 
 	return result.Embedding, nil
 }
 
 func (c *Client) postEmbeddings(ctx context.Context, path string, body []byte) ([][]float32, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	var result embeddingsResponse
+	if err := c.postJSON(ctx, path, body, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Embeddings, nil
+}
+
+// postJSON posts a JSON body to path and decodes the JSON response into out.
+func (c *Client) postJSON(ctx context.Context, path string, body []byte, out any) error {
+	req, err := c.newRequest(ctx, http.MethodPost, path, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
+	return c.do(req, out)
+}
+
+// do sends req inside a client span, propagating the trace context, and
+// decodes the JSON response into out.
+func (c *Client) do(req *http.Request, out any) error {
+	ctx, span := tracer.Start(req.Context(), "embedder "+req.URL.Path, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	err := c.roundTrip(req, out)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (c *Client) roundTrip(req *http.Request, out any) error {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
+		return fmt.Errorf("%w: sending request: %w", ErrUnavailable, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: embedder returned status %d", ErrUnavailable, resp.StatusCode)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("embedder returned status %d", resp.StatusCode)
+		return fmt.Errorf("embedder returned status %d", resp.StatusCode)
 	}
 
-	var result embeddingsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
 	}
 
-	return result.Embeddings, nil
+	return nil
 }
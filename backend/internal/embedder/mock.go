@@ -0,0 +1,205 @@
+package embedder
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Dimensions of the vectors the mock returns, those of CLIP ViT-B/32 and
+// BGE-M3.
+const (
+	mockImageSize = 512
+	mockTextSize  = 1024
+)
+
+// NewMock returns a handler serving the embedding service API with
+// deterministic vectors instead of models, for end-to-end runs without a
+// GPU or model downloads. A text embeds as the normalized sum of one
+// pseudo-random vector per lowercased word, so texts sharing words score
+// closer, and its tokens as those word vectors; an image embeds as one
+// vector seeded by its bytes, or by its path when the file cannot be read.
+func NewMock() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
+		writeMock(w, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("POST /embed/text", func(w http.ResponseWriter, r *http.Request) {
+		var req textRequest
+		if !readMock(w, r, &req) {
+			return
+		}
+
+		writeMock(w, embeddingResponse{Embedding: mockText(req.Text)})
+	})
+
+	mux.HandleFunc("POST /embed/texts", func(w http.ResponseWriter, r *http.Request) {
+		var req textsRequest
+		if !readMock(w, r, &req) {
+			return
+		}
+
+		resp := embeddingsResponse{Embeddings: make([][]float32, len(req.Texts))}
+		for i, t := range req.Texts {
+			resp.Embeddings[i] = mockText(t)
+		}
+
+		writeMock(w, resp)
+	})
+
+	mux.HandleFunc("POST /embed/text/tokens", func(w http.ResponseWriter, r *http.Request) {
+		var req textRequest
+		if !readMock(w, r, &req) {
+			return
+		}
+
+		writeMock(w, tokensResponse{Tokens: mockTokens(req.Text)})
+	})
+
+	mux.HandleFunc("POST /embed/texts/tokens", func(w http.ResponseWriter, r *http.Request) {
+		var req textsRequest
+		if !readMock(w, r, &req) {
+			return
+		}
+
+		resp := tokensBatchResponse{Tokens: make([][][]float32, len(req.Texts))}
+		for i, t := range req.Texts {
+			resp.Tokens[i] = mockTokens(t)
+		}
+
+		writeMock(w, resp)
+	})
+
+	mux.HandleFunc("POST /embed/image", func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = file.Close() }()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeMock(w, embeddingResponse{Embedding: mockVector(data, mockImageSize)})
+	})
+
+	mux.HandleFunc("POST /embed/image-paths", func(w http.ResponseWriter, r *http.Request) {
+		var req imagePathsRequest
+		if !readMock(w, r, &req) {
+			return
+		}
+
+		resp := embeddingsResponse{Embeddings: make([][]float32, len(req.Paths))}
+
+		for i, p := range req.Paths {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				data = []byte(p)
+			}
+
+			resp.Embeddings[i] = mockVector(data, mockImageSize)
+		}
+
+		writeMock(w, resp)
+	})
+
+	return mux
+}
+
+// mockWords splits text into lowercased words.
+func mockWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// mockText is the normalized sum of the word vectors of text.
+func mockText(text string) []float32 {
+	sum := make([]float32, mockTextSize)
+
+	for _, word := range mockWords(text) {
+		for i, v := range mockVector([]byte(word), mockTextSize) {
+			sum[i] += v
+		}
+	}
+
+	return normalize(sum)
+}
+
+// mockTokens returns one vector per word of text, at least one.
+func mockTokens(text string) [][]float32 {
+	words := mockWords(text)
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	tokens := make([][]float32, len(words))
+	for i, word := range words {
+		tokens[i] = mockVector([]byte(word), mockTextSize)
+	}
+
+	return tokens
+}
+
+// mockVector returns a unit vector of size dimensions seeded by seed.
+func mockVector(seed []byte, size int) []float32 {
+	h := fnv.New64a()
+	_, _ = h.Write(seed)
+	state := h.Sum64()
+
+	v := make([]float32, size)
+	for i := range v {
+		// xorshift64*
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		v[i] = float32(int64(state*2685821657736338717)) / math.MaxInt64
+	}
+
+	return normalize(v)
+}
+
+// normalize scales v to unit length; a zero vector is returned as is.
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+
+	if sum == 0 {
+		return v
+	}
+
+	norm := float32(1 / math.Sqrt(sum))
+	for i := range v {
+		v[i] *= norm
+	}
+
+	return v
+}
+
+// readMock decodes the JSON body of r into req, writing a 400 on failure.
+func readMock(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func writeMock(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,212 @@
+// Package eval measures search relevance against a golden set of queries with
+// known relevant phones.
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Case is a golden query: the search to run and the IDs of the phones a good
+// ranking returns for it.
+type Case struct {
+	Query    string            `json:"q"`
+	Filters  map[string]string `json:"filters,omitempty"`
+	Relevant []uint64          `json:"relevant"`
+}
+
+// Result is the outcome of one case.
+type Result struct {
+	Case
+
+	Returned []uint64 `json:"returned"`
+	Metrics
+	Error string `json:"error,omitempty"`
+}
+
+// Report summarizes a run.
+type Report struct {
+	K       int       `json:"k"`
+	Cases   int       `json:"cases"`
+	Failed  int       `json:"failed"`
+	Mean    Metrics   `json:"mean"`
+	Results []Result  `json:"results"`
+	TookMs  int64     `json:"took_ms"`
+	At      time.Time `json:"at"`
+}
+
+// LoadCases reads golden cases from NDJSON, one case per line. Blank lines
+// and lines starting with # are skipped.
+func LoadCases(r io.Reader) ([]Case, error) {
+	var cases []Case
+
+	sc := bufio.NewScanner(r)
+
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 || b[0] == '#' {
+			continue
+		}
+
+		var c Case
+		if err := json.Unmarshal(b, &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if c.Query == "" || len(c.Relevant) == 0 {
+			return nil, fmt.Errorf("line %d: a case needs q and relevant ids", line)
+		}
+
+		cases = append(cases, c)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading cases: %w", err)
+	}
+
+	if len(cases) == 0 {
+		return nil, errors.New("no cases found")
+	}
+
+	return cases, nil
+}
+
+// Runner runs golden cases against the search API of a running server, so
+// the full ranking pipeline is measured. A nil Client uses
+// http.DefaultClient.
+type Runner struct {
+	BaseURL string
+	K       int
+	Client  *http.Client
+}
+
+// Run searches every case and scores the top K results.
+func (r *Runner) Run(ctx context.Context, cases []Case) Report {
+	start := time.Now()
+	report := Report{K: r.K, Cases: len(cases), At: start.UTC()}
+
+	var scored []Metrics
+
+	for _, c := range cases {
+		res := Result{Case: c}
+
+		ids, err := r.search(ctx, c)
+		if err != nil {
+			res.Error = err.Error()
+			report.Failed++
+		} else {
+			relevant := make(map[uint64]struct{}, len(c.Relevant))
+			for _, id := range c.Relevant {
+				relevant[id] = struct{}{}
+			}
+
+			res.Returned = ids
+			res.Metrics = score(ids, relevant, r.K)
+			scored = append(scored, res.Metrics)
+		}
+
+		report.Results = append(report.Results, res)
+	}
+
+	report.Mean = mean(scored)
+	report.TookMs = time.Since(start).Milliseconds()
+
+	return report
+}
+
+// search returns the IDs of the top K results of c.
+func (r *Runner) search(ctx context.Context, c Case) ([]uint64, error) {
+	params := url.Values{}
+	for k, v := range c.Filters {
+		params.Set(k, v)
+	}
+
+	params.Set("q", c.Query)
+	params.Set("limit", strconv.Itoa(r.K))
+	params.Set("fields", "id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.BaseURL+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("searching: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Results []struct {
+			ID uint64 `json:"id"`
+		} `json:"results"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	ids := make([]uint64, len(body.Results))
+	for i, p := range body.Results {
+		ids[i] = p.ID
+	}
+
+	return ids, nil
+}
+
+// WriteComparison prints how the mean metrics changed from baseline.
+func (rep Report) WriteComparison(w io.Writer, baseline Report) error {
+	_, err := fmt.Fprintf(w, "vs baseline: NDCG %+.4f  Recall %+.4f  MRR %+.4f\n",
+		rep.Mean.NDCG-baseline.Mean.NDCG,
+		rep.Mean.Recall-baseline.Mean.Recall,
+		rep.Mean.MRR-baseline.Mean.MRR,
+	)
+
+	return err
+}
+
+// WriteText prints a per-case table followed by the mean metrics.
+func (rep Report) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%-40s %7s %7s %7s\n", "query", "ndcg", "recall", "mrr")
+
+	for _, res := range rep.Results {
+		q := res.Query
+		if r := []rune(q); len(r) > 40 {
+			q = string(r[:37]) + "..."
+		}
+
+		if res.Error != "" {
+			fmt.Fprintf(&buf, "%-40s error: %s\n", q, res.Error)
+			continue
+		}
+
+		fmt.Fprintf(&buf, "%-40s %7.3f %7.3f %7.3f\n", q, res.NDCG, res.Recall, res.MRR)
+	}
+
+	fmt.Fprintf(&buf, "\n%d cases, %d failed, k=%d, %dms\n", rep.Cases, rep.Failed, rep.K, rep.TookMs)
+	fmt.Fprintf(&buf, "NDCG@%d %.4f  Recall@%d %.4f  MRR %.4f\n", rep.K, rep.Mean.NDCG, rep.K, rep.Mean.Recall, rep.Mean.MRR)
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
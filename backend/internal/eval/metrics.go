@@ -0,0 +1,68 @@
+package eval
+
+import "math"
+
+// Metrics are the ranking quality measures of one or more queries at a
+// cutoff k, with binary relevance.
+type Metrics struct {
+	NDCG   float64 `json:"ndcg"`
+	Recall float64 `json:"recall"`
+	MRR    float64 `json:"mrr"`
+}
+
+// score computes the metrics of the top k results against relevant.
+func score(results []uint64, relevant map[uint64]struct{}, k int) Metrics {
+	var m Metrics
+
+	if len(relevant) == 0 {
+		return m
+	}
+
+	results = results[:min(k, len(results))]
+
+	var dcg float64
+
+	hits := 0
+
+	for i, id := range results {
+		if _, ok := relevant[id]; !ok {
+			continue
+		}
+
+		hits++
+		dcg += 1 / math.Log2(float64(i+2))
+
+		if m.MRR == 0 {
+			m.MRR = 1 / float64(i+1)
+		}
+	}
+
+	var ideal float64
+	for i := range min(k, len(relevant)) {
+		ideal += 1 / math.Log2(float64(i+2))
+	}
+
+	m.NDCG = dcg / ideal
+	m.Recall = float64(hits) / float64(len(relevant))
+
+	return m
+}
+
+// mean averages metrics.
+func mean(all []Metrics) Metrics {
+	var m Metrics
+
+	if len(all) == 0 {
+		return m
+	}
+
+	for _, x := range all {
+		m.NDCG += x.NDCG
+		m.Recall += x.Recall
+		m.MRR += x.MRR
+	}
+
+	n := float64(len(all))
+
+	return Metrics{NDCG: m.NDCG / n, Recall: m.Recall / n, MRR: m.MRR / n}
+}
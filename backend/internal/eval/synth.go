@@ -0,0 +1,198 @@
+package eval
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+// facts are what synthetic queries can mention about a phone, parsed once
+// from its payload.
+type facts struct {
+	id         uint64
+	brand      string
+	batteryMAh float64
+	display    string
+	fiveG      bool
+	nfc        bool
+	ramGB      float64
+	cameraMP   float64
+	priceEUR   float64
+}
+
+func parseFacts(phone model.Smartphone) facts {
+	payload := phone.PayloadMap()
+
+	f := facts{
+		id:    phone.ID,
+		brand: phone.Brand,
+		fiveG: strings.Contains(strings.ToUpper(phone.Technology), "5G"),
+		nfc:   strings.HasPrefix(phone.NFC, "Yes"),
+	}
+
+	f.batteryMAh, _ = payload["battery_mah"].(float64)
+	f.ramGB, _ = payload["ram_gb"].(float64)
+	f.cameraMP, _ = payload["camera_mp"].(float64)
+	f.display, _ = payload["display_type"].(string)
+	f.priceEUR, _ = payload["price_eur"].(float64)
+
+	return f
+}
+
+// attribute is one fact about a phone that a synthetic query can mention,
+// with the test deciding which other phones share it.
+type attribute struct {
+	phrase string
+	match  func(facts) bool
+}
+
+// GenerateOptions tunes Generate.
+type GenerateOptions struct {
+	// Count is the number of cases to generate.
+	Count int
+	// MaxRelevant drops queries matching more phones than this, since they
+	// are too vague to rank meaningfully; zero keeps them all.
+	MaxRelevant int
+	// Seed makes the output reproducible.
+	Seed uint64
+}
+
+// Generate builds golden cases from the phone catalog. Each query describes
+// a random phone by its brand and a few of its specs ("Samsung phone with
+// 5000 mAh battery and AMOLED display"), and its relevant set is every phone
+// sharing all the mentioned attributes, so no manual labeling is needed.
+func Generate(phones []model.Smartphone, opts GenerateOptions) []Case {
+	if len(phones) == 0 || opts.Count <= 0 {
+		return nil
+	}
+
+	catalog := make([]facts, len(phones))
+	for i, p := range phones {
+		catalog[i] = parseFacts(p)
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+	seen := map[string]bool{}
+
+	var cases []Case
+
+	// Give up after a bounded number of draws when the catalog cannot
+	// produce enough distinct, specific queries.
+	for range opts.Count * 20 {
+		if len(cases) == opts.Count {
+			break
+		}
+
+		phone := catalog[rng.IntN(len(catalog))]
+
+		attrs := attributes(phone)
+		if len(attrs) < 2 {
+			continue
+		}
+
+		rng.Shuffle(len(attrs), func(i, j int) { attrs[i], attrs[j] = attrs[j], attrs[i] })
+		attrs = attrs[:2+rng.IntN(min(2, len(attrs)-1))]
+
+		subject := "phone"
+
+		var brand string
+		if phone.brand != "" && rng.IntN(3) > 0 {
+			brand = phone.brand
+			subject = phone.brand + " phone"
+		}
+
+		phrases := make([]string, len(attrs))
+		for i, a := range attrs {
+			phrases[i] = a.phrase
+		}
+
+		query := subject + " with " + strings.Join(phrases[:len(phrases)-1], ", ") + " and " + phrases[len(phrases)-1]
+		if seen[query] {
+			continue
+		}
+
+		seen[query] = true
+
+		var relevant []uint64
+
+		for _, p := range catalog {
+			if brand != "" && !strings.EqualFold(p.brand, brand) {
+				continue
+			}
+
+			if !slices.ContainsFunc(attrs, func(a attribute) bool { return !a.match(p) }) {
+				relevant = append(relevant, p.id)
+			}
+		}
+
+		if opts.MaxRelevant > 0 && len(relevant) > opts.MaxRelevant {
+			continue
+		}
+
+		cases = append(cases, Case{Query: query, Relevant: relevant})
+	}
+
+	return cases
+}
+
+// attributes lists the query-worthy facts about phone.
+func attributes(phone facts) []attribute {
+	var attrs []attribute
+
+	if mah := phone.batteryMAh; mah > 0 {
+		attrs = append(attrs, attribute{
+			phrase: strconv.Itoa(int(mah)) + " mAh battery",
+			match:  func(p facts) bool { return p.batteryMAh == mah },
+		})
+	}
+
+	if display := phone.display; display != "" && display != "Other" {
+		attrs = append(attrs, attribute{
+			phrase: display + " display",
+			match:  func(p facts) bool { return p.display == display },
+		})
+	}
+
+	if phone.fiveG {
+		attrs = append(attrs, attribute{
+			phrase: "5G",
+			match:  func(p facts) bool { return p.fiveG },
+		})
+	}
+
+	if phone.nfc {
+		attrs = append(attrs, attribute{
+			phrase: "NFC",
+			match:  func(p facts) bool { return p.nfc },
+		})
+	}
+
+	if ram := phone.ramGB; ram >= 1 {
+		attrs = append(attrs, attribute{
+			phrase: strconv.FormatFloat(ram, 'f', -1, 64) + "GB RAM",
+			match:  func(p facts) bool { return p.ramGB >= ram },
+		})
+	}
+
+	if mp := phone.cameraMP; mp >= 8 {
+		attrs = append(attrs, attribute{
+			phrase: strconv.FormatFloat(mp, 'f', -1, 64) + " MP camera",
+			match:  func(p facts) bool { return p.cameraMP == mp },
+		})
+	}
+
+	if phone.priceEUR > 0 {
+		ceiling := math.Ceil(phone.priceEUR/100) * 100
+
+		attrs = append(attrs, attribute{
+			phrase: "price under " + strconv.Itoa(int(ceiling)) + " EUR",
+			match:  func(p facts) bool { return p.priceEUR > 0 && p.priceEUR <= ceiling },
+		})
+	}
+
+	return attrs
+}
@@ -0,0 +1,128 @@
+// Package experiment deterministically splits traffic between ranking
+// variants so their relevance metrics can be compared.
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Ranking strategies a variant can select.
+const (
+	// RankingDense orders results by vector similarity alone.
+	RankingDense = "dense"
+	// RankingCTR re-ranks results by historical click-through rate.
+	RankingCTR = "ctr"
+)
+
+// Variant is a ranking strategy and its share of the traffic.
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// Experiment assigns units, such as visitors, to weighted variants.
+type Experiment struct {
+	name     string
+	variants []Variant
+	total    int
+}
+
+// Parse builds an experiment from a spec like "dense:50,ctr:50". It returns
+// nil when spec is empty.
+func Parse(name, spec string) (*Experiment, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	e := &Experiment{name: name}
+
+	for item := range strings.SplitSeq(spec, ",") {
+		variant, weight, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			return nil, fmt.Errorf("variant %q: expected name:weight", item)
+		}
+
+		if variant != RankingDense && variant != RankingCTR {
+			return nil, fmt.Errorf("variant %q: unknown ranking strategy", variant)
+		}
+
+		w, err := strconv.Atoi(weight)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("variant %q: weight must be a positive integer", variant)
+		}
+
+		for _, v := range e.variants {
+			if v.Name == variant {
+				return nil, fmt.Errorf("variant %q: listed twice", variant)
+			}
+		}
+
+		e.variants = append(e.variants, Variant{Name: variant, Weight: w})
+		e.total += w
+	}
+
+	if len(e.variants) < 2 {
+		return nil, errors.New("an experiment needs at least two variants")
+	}
+
+	return e, nil
+}
+
+// Name returns the experiment name.
+func (e *Experiment) Name() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.name
+}
+
+// Variants returns the configured variants.
+func (e *Experiment) Variants() []Variant {
+	if e == nil {
+		return nil
+	}
+
+	return e.variants
+}
+
+// Has reports whether name is one of the variants.
+func (e *Experiment) Has(name string) bool {
+	for _, v := range e.Variants() {
+		if v.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Assign returns the variant of unit. The same unit always gets the same
+// variant for a given experiment name and configuration. A nil Experiment
+// assigns no variant.
+func (e *Experiment) Assign(unit string) string {
+	if e == nil {
+		return ""
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(e.name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(unit))
+
+	bucket := int(h.Sum64() % uint64(e.total))
+
+	for _, v := range e.variants {
+		if bucket < v.Weight {
+			return v.Name
+		}
+
+		bucket -= v.Weight
+	}
+
+	return e.variants[len(e.variants)-1].Name
+}
@@ -0,0 +1,219 @@
+// Package flags provides runtime feature flags read from the environment and
+// an optional JSON file that is reloaded when it changes.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Known flags. All default to enabled; each gates a feature that is also
+// subject to its own configuration.
+const (
+	// SearchCache serves repeated searches from the response cache.
+	SearchCache = "search_cache"
+	// CTRRerank blends click-through rates into the ranking.
+	CTRRerank = "ctr_rerank"
+	// Experiment splits searches between ranking variants.
+	Experiment = "experiment"
+	// BrowseFallback answers searches with filter-only results while the
+	// embedder is down.
+	BrowseFallback = "browse_fallback"
+	// ScriptNormalization folds compatibility characters of queries in
+	// non-Latin scripts before embedding.
+	ScriptNormalization = "script_normalization"
+	// StopwordTrim drops the stopwords of the detected language from
+	// queries before embedding.
+	StopwordTrim = "stopword_trim"
+)
+
+var defaults = map[string]bool{
+	SearchCache:         true,
+	CTRRerank:           true,
+	Experiment:          true,
+	BrowseFallback:      true,
+	ScriptNormalization: true,
+	StopwordTrim:        true,
+}
+
+// Flags holds the current flag values. A nil Flags reports every flag as
+// enabled.
+type Flags struct {
+	path string
+	env  map[string]bool
+
+	mu      sync.RWMutex
+	values  map[string]bool
+	modTime time.Time
+}
+
+// Load reads FEATURE_<NAME> environment variables, e.g.
+// FEATURE_SEARCH_CACHE=false, then the JSON object of flag values at path,
+// which takes precedence. An empty path skips the file.
+func Load(path string) (*Flags, error) {
+	f := &Flags{path: path, env: maps.Clone(defaults)}
+
+	for name := range defaults {
+		key := "FEATURE_" + strings.ToUpper(name)
+		if v := os.Getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", key, err)
+			}
+
+			f.env[name] = b
+		}
+	}
+
+	f.values = f.env
+
+	if path != "" {
+		if _, err := f.reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Enabled reports whether the named flag is on. Unknown flags are off.
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return defaults[name]
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.values[name]
+}
+
+// All returns a copy of the current flag values.
+func (f *Flags) All() map[string]bool {
+	if f == nil {
+		return maps.Clone(defaults)
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return maps.Clone(f.values)
+}
+
+// Watch reloads the flags file every interval when its modification time
+// changes, until ctx is cancelled. An invalid file keeps the previous values.
+func (f *Flags) Watch(ctx context.Context, interval time.Duration) {
+	if f == nil || f.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		f.Reload(ctx)
+	}
+}
+
+// Reload re-reads the flags file now if it changed, logging the outcome. An
+// invalid file keeps the previous values.
+func (f *Flags) Reload(ctx context.Context) {
+	if f == nil || f.path == "" {
+		return
+	}
+
+	changed, err := f.reload()
+	if err != nil {
+		slog.WarnContext(ctx, "reloading feature flags failed", slog.String("path", f.path), slog.String("error", err.Error()))
+		return
+	}
+
+	if changed {
+		slog.InfoContext(ctx, "feature flags reloaded", slog.Any("flags", f.All()))
+	}
+}
+
+// reload re-reads the flags file if it changed since the last read. A
+// missing file leaves only the environment values in effect.
+func (f *Flags) reload() (bool, error) {
+	info, err := os.Stat(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f.set(f.env, time.Time{}), nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("reading feature flags: %w", err)
+	}
+
+	f.mu.RLock()
+	unchanged := info.ModTime().Equal(f.modTime)
+	f.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return false, fmt.Errorf("reading feature flags: %w", err)
+	}
+
+	values, err := f.parse(b)
+	if err != nil {
+		// Remember the broken version so it is reported only once.
+		f.mu.Lock()
+		f.modTime = info.ModTime()
+		f.mu.Unlock()
+
+		return false, fmt.Errorf("parsing feature flags %s: %w", f.path, err)
+	}
+
+	return f.set(values, info.ModTime()), nil
+}
+
+// parse overlays the JSON flag values in b on the environment values.
+func (f *Flags) parse(b []byte) (map[string]bool, error) {
+	var file map[string]bool
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, err
+	}
+
+	values := maps.Clone(f.env)
+
+	for name, on := range file {
+		if _, ok := defaults[name]; !ok {
+			return nil, fmt.Errorf("unknown flag %q", name)
+		}
+
+		values[name] = on
+	}
+
+	return values, nil
+}
+
+// set installs values, reporting whether any flag changed.
+func (f *Flags) set(values map[string]bool, modTime time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	changed := !maps.Equal(f.values, values)
+	f.values = values
+	f.modTime = modTime
+
+	return changed
+}
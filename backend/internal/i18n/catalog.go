@@ -0,0 +1,211 @@
+package i18n
+
+// messages maps English API messages and enum values to translations.
+var messages = map[string]map[string]string{
+	Italian: {
+		// HTTP status titles used in problem responses.
+		"Bad Request":              "Richiesta non valida",
+		"Unauthorized":             "Non autorizzato",
+		"Conflict":                 "Conflitto",
+		"Unprocessable Entity":     "Entità non elaborabile",
+		"Not Found":                "Non trovato",
+		"Request Entity Too Large": "Contenuto troppo grande",
+		"Internal Server Error":    "Errore interno del server",
+		"Service Unavailable":      "Servizio non disponibile",
+		"Too Many Requests":        "Troppe richieste",
+		"Unsupported Media Type":   "Tipo di contenuto non supportato",
+
+		// Problem details.
+		"missing query parameter 'q'":                              "parametro 'q' mancante",
+		"missing image file":                                       "file immagine mancante",
+		"the image could not be decoded":                           "impossibile decodificare l'immagine",
+		"HEIC/HEIF images are not supported by this server":        "questo server non supporta le immagini HEIC/HEIF",
+		"image exceeds the %s upload limit":                        "l'immagine supera il limite di caricamento di %s",
+		"request body exceeds the %s limit":                        "il corpo della richiesta supera il limite di %s",
+		"request body is not valid JSON":                           "il corpo della richiesta non è un JSON valido",
+		"missing or invalid admin token":                           "token di amministrazione mancante o non valido",
+		"invalid API key":                                          "chiave API non valida",
+		"missing or invalid API key":                               "chiave API mancante o non valida",
+		"the daily quota of the API key is used up":                "la quota giornaliera della chiave API è esaurita",
+		"the monthly quota of the API key is used up":              "la quota mensile della chiave API è esaurita",
+		"internal server error":                                    "errore interno del server",
+		"one or more parameters are invalid":                       "uno o più parametri non sono validi",
+		"the embedding service is unavailable, try again later":    "il servizio di embedding non è disponibile, riprova più tardi",
+		"too many concurrent searches, try again later":            "troppe ricerche simultanee, riprova più tardi",
+		"the service is under maintenance, try again later":        "il servizio è in manutenzione, riprova più tardi",
+		"updating the catalog failed":                              "aggiornamento del catalogo non riuscito",
+		"phone id must be a positive integer":                      "l'id del telefono deve essere un intero positivo",
+		"reading the request body failed":                          "lettura del corpo della richiesta non riuscita",
+		"Idempotency-Key must be at most 255 characters":           "Idempotency-Key deve avere al massimo 255 caratteri",
+		"Idempotency-Key was already used for a different request": "Idempotency-Key già usata per una richiesta diversa",
+		"a request with this Idempotency-Key is still in progress": "una richiesta con questa Idempotency-Key è ancora in corso",
+		"loading favorites failed":                                 "caricamento dei preferiti non riuscito",
+		"updating favorites failed":                                "aggiornamento dei preferiti non riuscito",
+		"saving the search failed":                                 "salvataggio della ricerca non riuscito",
+		"loading saved searches failed":                            "caricamento delle ricerche salvate non riuscito",
+		"deleting the saved search failed":                         "eliminazione della ricerca salvata non riuscita",
+		"saved search not found":                                   "ricerca salvata non trovata",
+		"sharing the search failed":                                "condivisione della ricerca non riuscita",
+		"loading the shared search failed":                         "caricamento della ricerca condivisa non riuscito",
+		"shared search not found":                                  "ricerca condivisa non trovata",
+		"phone not found":                                          "telefono non trovato",
+		"brand not found":                                          "marca non trovata",
+		"loading search history failed":                            "caricamento della cronologia di ricerca non riuscito",
+		"updating search history failed":                           "aggiornamento della cronologia di ricerca non riuscito",
+		"email is already registered":                              "email già registrata",
+		"invalid email or password":                                "email o password non validi",
+		"not logged in":                                            "accesso non effettuato",
+		"search failed":                                            "ricerca non riuscita",
+		"summarizing analytics failed":                             "riepilogo delle statistiche non riuscito",
+		"exporting the query log failed":                           "esportazione del registro delle ricerche non riuscita",
+		"saving the price watch failed":                            "salvataggio dell'avviso di prezzo non riuscito",
+		"loading price watches failed":                             "caricamento degli avvisi di prezzo non riuscito",
+		"deleting the price watch failed":                          "eliminazione dell'avviso di prezzo non riuscita",
+		"price watch not found":                                    "avviso di prezzo non trovato",
+		"image not found":                                          "immagine non trovata",
+		"image processing failed":                                  "elaborazione dell'immagine non riuscita",
+
+		// Validation messages (format strings).
+		"must be one of %s":                                    "deve essere uno tra %s",
+		"must be a number":                                     "deve essere un numero",
+		"must not be negative":                                 "non deve essere negativo",
+		"must be an integer":                                   "deve essere un intero",
+		"must be between %d and %d":                            "deve essere compreso tra %d e %d",
+		"must be greater than or equal to price_min":           "deve essere maggiore o uguale a price_min",
+		"must be greater than or equal to screen_min":          "deve essere maggiore o uguale a screen_min",
+		"is required":                                          "è obbligatorio",
+		"must not be empty":                                    "non deve essere vuoto",
+		"must be a valid email address":                        "deve essere un indirizzo email valido",
+		"must be between %d and %d characters":                 "deve essere lungo tra %d e %d caratteri",
+		"must be text":                                         "deve essere testo",
+		"must be at most %d characters":                        "deve essere lungo al massimo %d caratteri",
+		"must not contain URLs":                                "non deve contenere URL",
+		"must contain letters or digits":                       "deve contenere lettere o cifre",
+		"must not contain encoded data":                        "non deve contenere dati codificati",
+		"must contain between %d and %d items":                 "deve contenere tra %d e %d elementi",
+		"must contain two different phone ids":                 "deve contenere due id di telefoni diversi",
+		"must set either id or text":                           "deve impostare id oppure text",
+		"must be a positive integer":                           "deve essere un intero positivo",
+		"must be a comma-separated list of phone ids":          "deve essere un elenco di id di telefoni separati da virgole",
+		"must be a comma-separated list of bands such as n78":  "deve essere un elenco di bande separate da virgole come n78",
+		"must be a date (YYYY-MM-DD) or an RFC 3339 timestamp": "deve essere una data (AAAA-MM-GG) o un timestamp RFC 3339",
+		"must be after since":                                  "deve essere successivo a since",
+		"must be after announced_after":                        "deve essere successivo a announced_after",
+		"must be a positive number":                            "deve essere un numero positivo",
+		"must be set unless q or params are":                   "è obbligatorio se q e params non sono impostati",
+		"cannot be combined with q or params":                  "non può essere combinato con q o params",
+		"cannot be combined with schema=v2":                    "non può essere combinato con schema=v2",
+		"requires logging in":                                  "richiede l'accesso",
+		"must be an absolute http or https URL":                "deve essere un URL http o https assoluto",
+		"%g is outside the plausible range %g to %g":           "%g è fuori dall'intervallo plausibile da %g a %g",
+		"unknown field %q":                                     "campo sconosciuto %q",
+
+		// Enum values.
+		"Yes":       "Sì",
+		"No":        "No",
+		"Other":     "Altro",
+		"flagship":  "top di gamma",
+		"midrange":  "fascia media",
+		"entry":     "entry level",
+		"wide":      "grandangolo",
+		"ultrawide": "ultra grandangolo",
+		"telephoto": "teleobiettivo",
+		"macro":     "macro",
+		"depth":     "profondità",
+		"black":     "nero",
+		"white":     "bianco",
+		"silver":    "argento",
+		"gray":      "grigio",
+		"gold":      "oro",
+		"blue":      "blu",
+		"red":       "rosso",
+		"pink":      "rosa",
+		"green":     "verde",
+		"yellow":    "giallo",
+		"orange":    "arancione",
+		"purple":    "viola",
+		"brown":     "marrone",
+	},
+}
+
+var englishLabels = map[string]string{
+	"brand":        "Brand",
+	"model":        "Model",
+	"technology":   "Network technology",
+	"bands_2g":     "2G bands",
+	"bands_3g":     "3G bands",
+	"bands_4g":     "4G bands",
+	"bands_5g":     "5G bands",
+	"announced":    "Announced",
+	"status":       "Status",
+	"dimensions":   "Dimensions",
+	"weight":       "Weight",
+	"sim":          "SIM",
+	"display":      "Display",
+	"display_type": "Display type",
+	"screen_size":  "Screen size",
+	"resolution":   "Resolution",
+	"protection":   "Protection",
+	"os":           "Operating system",
+	"chipset":      "Chipset",
+	"cpu":          "CPU",
+	"gpu":          "GPU",
+	"card_slot":    "Card slot",
+	"storage":      "Storage",
+	"camera":       "Main camera",
+	"video":        "Video",
+	"selfie":       "Selfie camera",
+	"battery":      "Battery",
+	"charging":     "Charging",
+	"wlan":         "Wi-Fi",
+	"bluetooth":    "Bluetooth",
+	"gps":          "Positioning",
+	"nfc":          "NFC",
+	"network":      "Network",
+	"usb":          "USB",
+	"sensors":      "Sensors",
+	"infrared":     "Infrared port",
+	"colors":       "Colors",
+	"price":        "Price",
+}
+
+var italianLabels = map[string]string{
+	"brand":        "Marca",
+	"model":        "Modello",
+	"technology":   "Tecnologia di rete",
+	"bands_2g":     "Bande 2G",
+	"bands_3g":     "Bande 3G",
+	"bands_4g":     "Bande 4G",
+	"bands_5g":     "Bande 5G",
+	"announced":    "Annunciato",
+	"status":       "Stato",
+	"dimensions":   "Dimensioni",
+	"weight":       "Peso",
+	"sim":          "SIM",
+	"display":      "Display",
+	"display_type": "Tipo di display",
+	"screen_size":  "Dimensione schermo",
+	"resolution":   "Risoluzione",
+	"protection":   "Protezione",
+	"os":           "Sistema operativo",
+	"chipset":      "Chipset",
+	"cpu":          "CPU",
+	"gpu":          "GPU",
+	"card_slot":    "Slot scheda",
+	"storage":      "Memoria",
+	"camera":       "Fotocamera principale",
+	"video":        "Video",
+	"selfie":       "Fotocamera frontale",
+	"battery":      "Batteria",
+	"charging":     "Ricarica",
+	"wlan":         "Wi-Fi",
+	"bluetooth":    "Bluetooth",
+	"gps":          "Posizionamento",
+	"nfc":          "NFC",
+	"network":      "Rete",
+	"usb":          "USB",
+	"sensors":      "Sensori",
+	"infrared":     "Porta infrarossi",
+	"colors":       "Colori",
+	"price":        "Prezzo",
+}
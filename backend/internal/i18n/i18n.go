@@ -0,0 +1,143 @@
+// Package i18n localizes API messages, enum labels and spec labels. Messages
+// are keyed by their English text, so untranslated strings fall back to
+// English unchanged.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Supported languages.
+const (
+	English = "en"
+	Italian = "it"
+)
+
+// Default is used when the client expresses no supported preference.
+const Default = English
+
+// Supported lists the languages with a catalog, in preference order for ties.
+var Supported = []string{English, Italian}
+
+type contextKey struct{}
+
+// WithLang returns a copy of ctx carrying lang.
+func WithLang(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// Lang returns the language stored in ctx, or Default.
+func Lang(ctx context.Context) string {
+	if lang, ok := ctx.Value(contextKey{}).(string); ok {
+		return lang
+	}
+
+	return Default
+}
+
+// Negotiate picks the supported language with the highest q-value in an
+// Accept-Language header, matching on the primary subtag ("it-IT" is "it").
+func Negotiate(header string) string {
+	best, bestQ := Default, 0.0
+
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q > bestQ && slices.Contains(Supported, primary) {
+			best, bestQ = primary, q
+		}
+	}
+
+	return best
+}
+
+// T translates an English message into lang.
+func T(lang, msg string) string {
+	if tr, ok := messages[lang][msg]; ok {
+		return tr
+	}
+
+	return msg
+}
+
+// Sprintf translates an English format string into lang, then formats it.
+func Sprintf(lang, format string, args ...any) string {
+	return fmt.Sprintf(T(lang, format), args...)
+}
+
+var (
+	labelsMu sync.RWMutex
+	labels   = map[string]map[string]string{
+		English: englishLabels,
+		Italian: italianLabels,
+	}
+)
+
+// RegisterLabels adds or overrides spec labels for lang, letting callers
+// plug in further languages or product-specific wording.
+func RegisterLabels(lang string, l map[string]string) {
+	labelsMu.Lock()
+	defer labelsMu.Unlock()
+
+	if labels[lang] == nil {
+		labels[lang] = map[string]string{}
+	}
+
+	for k, v := range l {
+		labels[lang][k] = v
+	}
+}
+
+// Label returns the display label of a spec field in lang, falling back to
+// English and then to the field name itself.
+func Label(lang, field string) string {
+	labelsMu.RLock()
+	defer labelsMu.RUnlock()
+
+	if l, ok := labels[lang][field]; ok {
+		return l
+	}
+
+	if l, ok := labels[English][field]; ok {
+		return l
+	}
+
+	return field
+}
+
+// Labels returns the display labels of the given spec fields in lang.
+func Labels(lang string, fields []string) map[string]string {
+	out := make(map[string]string, len(fields))
+	for _, f := range fields {
+		out[f] = Label(lang, f)
+	}
+
+	return out
+}
+
+// Values returns display labels for enum values in lang, keyed by the
+// canonical value that clients must send back as a filter.
+func Values(lang string, values []string) map[string]string {
+	out := make(map[string]string, len(values))
+	for _, v := range values {
+		out[v] = T(lang, v)
+	}
+
+	return out
+}
@@ -0,0 +1,279 @@
+// Package images serves downloaded phone images, with optional on-the-fly
+// resizing and WebP/AVIF transcoding backed by a disk cache of generated
+// variants.
+package images
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoder for source images
+	"image/jpeg"
+	_ "image/png" // register PNG decoder for source images
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	maxDimension   = 2000
+	defaultQuality = 80
+
+	// Images never change once seeded, so clients and CDNs may keep them
+	// for a year without revalidating.
+	cacheControl = "public, max-age=31536000, immutable"
+)
+
+// Handler serves images from a directory. Requests may add w, h (pixels) and
+// q (quality, 1-100) query parameters to receive a downscaled variant, and
+// clients accepting AVIF or WebP receive a transcoded copy when the matching
+// encoder is installed. Generated files are kept in the cache directory.
+type Handler struct {
+	dir        string
+	cacheDir   string
+	encoders   []encoder
+	writeError ErrorFunc
+}
+
+// ErrorFunc writes an error response with status and a detail for the
+// client, so failed image requests are reported like the rest of the API.
+type ErrorFunc func(w http.ResponseWriter, r *http.Request, status int, detail string)
+
+// NewHandler creates a Handler serving files from dir and caching generated
+// variants in cacheDir. Errors are written with writeError, or as plain text
+// when it is nil.
+func NewHandler(dir, cacheDir string, writeError ErrorFunc) *Handler {
+	if writeError == nil {
+		writeError = func(w http.ResponseWriter, _ *http.Request, status int, detail string) {
+			http.Error(w, detail, status)
+		}
+	}
+
+	return &Handler{dir: dir, cacheDir: cacheDir, encoders: availableEncoders(), writeError: writeError}
+}
+
+// variant describes a requested rendition of a source image.
+type variant struct {
+	width   int
+	height  int
+	quality int
+}
+
+func (v variant) resized() bool {
+	return v.width > 0 || v.height > 0 || v.quality > 0
+}
+
+// ServeHTTP serves the image named by the {file} path value.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("file")
+	if !validName(name) {
+		h.writeError(w, r, http.StatusNotFound, "image not found")
+		return
+	}
+
+	v, err := parseVariant(r)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	path := filepath.Join(h.dir, name)
+	key := strings.TrimSuffix(name, filepath.Ext(name))
+
+	if v.resized() {
+		key = fmt.Sprintf("%s_w%d_h%d_q%d", key, v.width, v.height, v.quality)
+
+		path, err = h.variantPath(path, key, v)
+		if errors.Is(err, os.ErrNotExist) {
+			h.writeError(w, r, http.StatusNotFound, "image not found")
+			return
+		}
+
+		if err != nil {
+			slog.ErrorContext(r.Context(), "resizing image failed", slog.String("file", name), slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "image processing failed")
+
+			return
+		}
+	}
+
+	if len(h.encoders) > 0 {
+		w.Header().Add("Vary", "Accept")
+	}
+
+	if enc := negotiate(r.Header.Get("Accept"), h.encoders); enc != nil {
+		if _, err := os.Stat(path); err != nil {
+			h.writeError(w, r, http.StatusNotFound, "image not found")
+			return
+		}
+
+		quality := v.quality
+		if quality == 0 {
+			quality = defaultQuality
+			key += "_orig"
+		}
+
+		transcoded, err := h.transcode(r.Context(), enc, path, key, quality)
+		if err == nil {
+			w.Header().Set("Content-Type", enc.contentType)
+			h.serveFile(w, r, transcoded)
+
+			return
+		}
+
+		// Fall back to the JPEG/PNG source, e.g. for inputs the encoder rejects.
+		slog.WarnContext(r.Context(), "transcoding image failed", slog.String("file", name), slog.String("error", err.Error()))
+	}
+
+	h.serveFile(w, r, path)
+}
+
+// serveFile serves path with long-lived caching headers. http.ServeFile sets
+// Last-Modified and answers conditional requests, including If-None-Match
+// against the ETag derived here from the file size and modification time.
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		h.writeError(w, r, http.StatusNotFound, "image not found")
+		return
+	}
+
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+	w.Header().Set("Cache-Control", cacheControl)
+
+	http.ServeFile(w, r, path)
+}
+
+// variantPath returns the cached resized variant of src, generating it if needed.
+func (h *Handler) variantPath(src, key string, v variant) (string, error) {
+	path := filepath.Join(h.cacheDir, key+".jpg")
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	img, err := decodeFile(src)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(h.cacheDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating cache dir: %w", err)
+	}
+
+	// Write to a temporary file and rename so concurrent requests never see
+	// a partially written variant.
+	tmp, err := os.CreateTemp(h.cacheDir, ".variant-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := jpeg.Encode(tmp, resize(img, v.width, v.height), &jpeg.Options{Quality: v.quality}); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("encoding jpeg: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("closing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("storing variant: %w", err)
+	}
+
+	return path, nil
+}
+
+func decodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+
+	return img, nil
+}
+
+// resize scales img to fit within width x height, keeping the aspect ratio.
+// A zero bound is unconstrained; images are never upscaled.
+func resize(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	scale := 1.0
+	if width > 0 && width < sw {
+		scale = float64(width) / float64(sw)
+	}
+
+	if height > 0 && height < sh {
+		scale = min(scale, float64(height)/float64(sh))
+	}
+
+	if scale == 1.0 {
+		return img
+	}
+
+	dw := max(1, int(float64(sw)*scale+0.5))
+	dh := max(1, int(float64(sh)*scale+0.5))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+
+	return dst
+}
+
+func parseVariant(r *http.Request) (variant, error) {
+	var v variant
+
+	var err error
+
+	if v.width, err = intParam(r, "w", 1, maxDimension); err != nil {
+		return v, err
+	}
+
+	if v.height, err = intParam(r, "h", 1, maxDimension); err != nil {
+		return v, err
+	}
+
+	if v.quality, err = intParam(r, "q", 1, 100); err != nil {
+		return v, err
+	}
+
+	if v.resized() && v.quality == 0 {
+		v.quality = defaultQuality
+	}
+
+	return v, nil
+}
+
+// intParam parses an optional integer query parameter within [lo, hi];
+// a missing parameter yields 0.
+func intParam(r *http.Request, key string, lo, hi int) (int, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%s must be an integer between %d and %d", key, lo, hi)
+	}
+
+	return n, nil
+}
+
+// validName rejects anything that is not a plain file name in the images dir.
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && filepath.Base(name) == name && !strings.ContainsAny(name, `/\`)
+}
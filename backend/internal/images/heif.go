@@ -0,0 +1,101 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrNoHEIFDecoder is returned by HEIFToJPEG when none of the decoders in
+// heifDecoders is installed.
+var ErrNoHEIFDecoder = errors.New("no HEIC/HEIF decoder installed")
+
+// heifBrands are the ISO base media file brands of HEIC and HEIF images,
+// still pictures and sequences alike.
+var heifBrands = []string{"heic", "heix", "heim", "heis", "hevc", "hevx", "hevm", "hevs", "mif1", "msf1"}
+
+// heifDecoders are the external tools converting a HEIC/HEIF file to JPEG,
+// in order of preference.
+var heifDecoders = []struct {
+	binary  string
+	command func(ctx context.Context, in, out string) *exec.Cmd
+}{
+	{"heif-convert", func(ctx context.Context, in, out string) *exec.Cmd {
+		return exec.CommandContext(ctx, "heif-convert", "-q", "90", in, out)
+	}},
+	{"magick", func(ctx context.Context, in, out string) *exec.Cmd {
+		return exec.CommandContext(ctx, "magick", in, "-quality", "90", out)
+	}},
+	{"vips", func(ctx context.Context, in, out string) *exec.Cmd {
+		return exec.CommandContext(ctx, "vips", "copy", in, out+"[Q=90]")
+	}},
+}
+
+// IsHEIF reports whether head, the first bytes of a file, starts a HEIC or
+// HEIF image: an ftyp box whose major brand is one of heifBrands. AVIF
+// shares the container but not the brands.
+func IsHEIF(head []byte) bool {
+	if len(head) < 12 || string(head[4:8]) != "ftyp" {
+		return false
+	}
+
+	return slices.Contains(heifBrands, string(head[8:12]))
+}
+
+// HEIFToJPEG converts the HEIC/HEIF image read from r to JPEG with the first
+// installed decoder, through temporary files.
+func HEIFToJPEG(ctx context.Context, r io.Reader) ([]byte, error) {
+	command := heifDecoder()
+	if command == nil {
+		return nil, ErrNoHEIFDecoder
+	}
+
+	dir, err := os.MkdirTemp("", "heif-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	in := filepath.Join(dir, "in.heic")
+	out := filepath.Join(dir, "out.jpg")
+
+	f, err := os.Create(in)
+	if err != nil {
+		return nil, fmt.Errorf("creating temp file: %w", err)
+	}
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("writing temp file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, transcodeTimeout)
+	defer cancel()
+
+	if output, err := command(ctx, in, out).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("converting HEIF to JPEG: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return os.ReadFile(out)
+}
+
+// heifDecoder returns the command of the first installed decoder, or nil.
+func heifDecoder() func(ctx context.Context, in, out string) *exec.Cmd {
+	for _, d := range heifDecoders {
+		if _, err := exec.LookPath(d.binary); err == nil {
+			return d.command
+		}
+	}
+
+	return nil
+}
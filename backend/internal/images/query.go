@@ -0,0 +1,114 @@
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+
+	_ "golang.org/x/image/bmp"  // register BMP decoder for query images
+	_ "golang.org/x/image/tiff" // register TIFF decoder for query images
+	_ "golang.org/x/image/webp" // register WebP decoder for query images
+)
+
+// QuerySize is the side of the square query images sent to the embedder,
+// above the 224 pixels CLIP ViT-B/32 sees so its own resize still works
+// from a sharper source.
+const QuerySize = 336
+
+const queryQuality = 90
+
+// PrepareQuery turns an uploaded photo into the JPEG sent to the embedder:
+// center-cropped to a square as CLIP crops it anyway, scaled down to
+// QuerySize and turned upright by its EXIF orientation, so a 10 MB phone
+// photo travels as a few tens of KB and a sideways photo embeds upright.
+func PrepareQuery(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+
+	// The center square of an image is the center square of its rotations
+	// and mirror images, so orienting after cropping and scaling gives the
+	// same pixels while only transforming the small image.
+	img = orient(resize(centerSquare(img), QuerySize, QuerySize), exifOrientation(data))
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: queryQuality}); err != nil {
+		return nil, fmt.Errorf("encoding image: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// centerSquare crops img to the largest centered square.
+func centerSquare(img image.Image) image.Image {
+	b := img.Bounds()
+	if b.Dx() == b.Dy() {
+		return img
+	}
+
+	sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return img
+	}
+
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+
+	return sub.SubImage(image.Rect(x, y, x+side, y+side))
+}
+
+// orient applies an EXIF orientation (1 to 8) to img, returning it as it is
+// meant to be displayed; other values leave img unchanged.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := range dh {
+		for x := range dw {
+			var sx, sy int
+
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90° clockwise to display
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90° counterclockwise to display
+				sx, sy = w-1-y, x
+			}
+
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,122 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const transcodeTimeout = 30 * time.Second
+
+// encoder converts a JPEG/PNG file to a modern format with an external tool.
+type encoder struct {
+	contentType string
+	ext         string
+	command     func(ctx context.Context, quality int, in, out string) *exec.Cmd
+}
+
+// knownEncoders lists supported output formats in order of preference.
+var knownEncoders = []struct {
+	binary string
+	encoder
+}{
+	{"avifenc", encoder{
+		contentType: "image/avif",
+		ext:         ".avif",
+		command: func(ctx context.Context, quality int, in, out string) *exec.Cmd {
+			return exec.CommandContext(ctx, "avifenc", "-q", strconv.Itoa(quality), "-s", "6", in, out)
+		},
+	}},
+	{"cwebp", encoder{
+		contentType: "image/webp",
+		ext:         ".webp",
+		command: func(ctx context.Context, quality int, in, out string) *exec.Cmd {
+			return exec.CommandContext(ctx, "cwebp", "-quiet", "-q", strconv.Itoa(quality), in, "-o", out)
+		},
+	}},
+}
+
+// availableEncoders returns the encoders whose binaries are on PATH.
+func availableEncoders() []encoder {
+	var encoders []encoder
+
+	for _, e := range knownEncoders {
+		if _, err := exec.LookPath(e.binary); err == nil {
+			encoders = append(encoders, e.encoder)
+		}
+	}
+
+	return encoders
+}
+
+// negotiate picks the preferred available encoder accepted by the client, or
+// nil to serve JPEG. Formats listed with q=0 are treated as refused.
+func negotiate(accept string, encoders []encoder) *encoder {
+	accepted := map[string]bool{}
+
+	for part := range strings.SplitSeq(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+
+		accepted[mt] = true
+	}
+
+	for i := range encoders {
+		if accepted[encoders[i].contentType] {
+			return &encoders[i]
+		}
+	}
+
+	return nil
+}
+
+// transcode converts src with enc into the cache directory, reusing a
+// previously generated file when present.
+func (h *Handler) transcode(ctx context.Context, enc *encoder, src, key string, quality int) (string, error) {
+	path := filepath.Join(h.cacheDir, key+enc.ext)
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(h.cacheDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating cache dir: %w", err)
+	}
+
+	f, err := os.CreateTemp(h.cacheDir, ".transcode-*"+enc.ext)
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+
+	tmp := f.Name()
+	_ = f.Close()
+
+	defer func() { _ = os.Remove(tmp) }()
+
+	ctx, cancel := context.WithTimeout(ctx, transcodeTimeout)
+	defer cancel()
+
+	if out, err := enc.command(ctx, quality, src, tmp).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("transcoding to %s: %w: %s", enc.contentType, err, strings.TrimSpace(string(out)))
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("storing transcoded image: %w", err)
+	}
+
+	return path, nil
+}
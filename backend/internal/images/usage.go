@@ -0,0 +1,45 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DiskUsage counts the image files stored in a directory.
+type DiskUsage struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Usage reports the regular, non-hidden files directly inside dir. A missing
+// directory counts as empty.
+func Usage(dir string) (DiskUsage, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return DiskUsage{}, nil
+	}
+
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	var usage DiskUsage
+
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		usage.Files++
+		usage.Bytes += info.Size()
+	}
+
+	return usage, nil
+}
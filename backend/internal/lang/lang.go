@@ -0,0 +1,230 @@
+// Package lang detects the language of search queries and prepares them for
+// the multilingual text embedder: script normalization for non-Latin
+// queries and stopword trimming.
+package lang
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Undetermined is the ISO 639 code of a query whose language is unknown,
+// such as one naming only a brand and model.
+const Undetermined = "und"
+
+// scripts map the non-Latin scripts to the language detected for them when
+// they make up most of a query's letters. Cyrillic, Arabic and Han are
+// refined by detectScript.
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// Detect returns the ISO 639-1 code of the language of q, or Undetermined.
+// Queries mostly in a non-Latin script are told apart by script, Latin ones
+// by their stopwords and accented letters; a query without either, such as
+// "galaxy s24 ultra", is Undetermined.
+func Detect(q string) string {
+	q = strings.ToLower(q)
+
+	var latin int
+
+	counts := map[string]int{}
+
+	for _, r := range q {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.lang]++
+				break
+			}
+		}
+	}
+
+	var best string
+
+	for _, s := range scripts {
+		if counts[s.lang] > counts[best] {
+			best = s.lang
+		}
+	}
+
+	// Kana mark Japanese even among more kanji.
+	if counts["ja"] > 0 {
+		best = "ja"
+	}
+
+	if best != "" && counts[best] >= latin {
+		return detectScript(best, q)
+	}
+
+	return detectLatin(q)
+}
+
+// detectScript tells apart the languages sharing the script of lang by
+// letters only some of them use.
+func detectScript(lang, q string) string {
+	switch {
+	case lang == "ru" && strings.ContainsAny(q, "іїєґ"):
+		return "uk"
+	case lang == "ar" && strings.ContainsAny(q, "پچژگ"):
+		return "fa"
+	}
+
+	return lang
+}
+
+// letterHints are accented letters pointing at a language, counted like a
+// stopword.
+var letterHints = map[rune][]string{
+	'ñ': {"es"},
+	'ß': {"de"},
+	'ä': {"de"},
+	'ö': {"de"},
+	'ü': {"de"},
+	'ã': {"pt"},
+	'õ': {"pt"},
+	'ç': {"fr", "pt"},
+	'ò': {"it"},
+	'ì': {"it"},
+	'è': {"it", "fr"},
+	'à': {"it", "fr"},
+	'ù': {"it", "fr"},
+	'ê': {"fr", "pt"},
+	'â': {"fr", "pt"},
+}
+
+// detectLatin scores the languages with stopwords by their stopwords,
+// markers and letter hints in q, returning the best one or Undetermined on a tie.
+func detectLatin(q string) string {
+	scores := map[string]int{}
+
+	for _, r := range q {
+		for _, l := range letterHints[r] {
+			scores[l]++
+		}
+	}
+
+	for _, w := range words(FoldAccents(q)) {
+		for _, l := range latinLanguages {
+			if _, ok := stopwords[l][w]; ok {
+				scores[l]++
+			}
+
+			if _, ok := markers[l][w]; ok {
+				scores[l]++
+			}
+		}
+	}
+
+	best, tie := Undetermined, false
+
+	for _, l := range latinLanguages {
+		switch {
+		case scores[l] > scores[best]:
+			best, tie = l, false
+		case scores[l] > 0 && scores[l] == scores[best]:
+			tie = true
+		}
+	}
+
+	if tie {
+		return Undetermined
+	}
+
+	return best
+}
+
+// NeedsScriptNormalization reports whether q has letters of a non-Latin
+// script or halfwidth and fullwidth forms, as typed with CJK input methods.
+func NeedsScriptNormalization(q string) bool {
+	for _, r := range q {
+		if r >= 0xFF00 && r <= 0xFFEF {
+			return true
+		}
+
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// arabicMark reports whether r is the Arabic tatweel or a short vowel mark,
+// which typists use inconsistently.
+func arabicMark(r rune) bool {
+	return r == 0x0640 || (r >= 0x064B && r <= 0x0652)
+}
+
+// NormalizeScript folds compatibility characters with NFKC, turning
+// fullwidth "ｉＰｈｏｎｅ　１５" into "iPhone 15" and halfwidth katakana into
+// the usual ones, and drops the Arabic tatweel and short vowel marks.
+func NormalizeScript(q string) string {
+	q = strings.Map(func(r rune) rune {
+		if arabicMark(r) {
+			return -1
+		}
+
+		return r
+	}, q)
+
+	return norm.NFKC.String(q)
+}
+
+// FoldAccents strips the accents of Latin letters, turning "é" into "e" and
+// "ñ" into "n". The combining marks of other scripts, such as Devanagari
+// vowel signs or the kana voicing mark, are part of the letter and kept.
+func FoldAccents(s string) string {
+	decomposed := norm.NFD.String(s)
+
+	var (
+		b     strings.Builder
+		latin bool
+	)
+
+	b.Grow(len(decomposed))
+
+	for _, r := range decomposed {
+		if unicode.Is(unicode.Mn, r) {
+			if latin {
+				continue
+			}
+		} else {
+			latin = unicode.Is(unicode.Latin, r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return norm.NFC.String(b.String())
+}
+
+// words splits q into words at anything but letters and digits, so
+// "dell'iphone" yields "dell" and "iphone".
+func words(q string) []string {
+	return strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
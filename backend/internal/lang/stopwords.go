@@ -0,0 +1,68 @@
+package lang
+
+import "strings"
+
+// latinLanguages are the languages detected by stopword, in the order ties
+// are resolved.
+var latinLanguages = []string{"en", "it", "es", "fr", "de", "pt"}
+
+// stopwords are the function words of each language that carry no meaning
+// for a phone search: articles, prepositions, pronouns and auxiliaries.
+// Negations and comparisons ("without", "under", "più") change what a
+// query asks for and are deliberately left out.
+var stopwords = map[string]map[string]struct{}{
+	"en": set("a an the of with for in on at to and or is are be it its that this which i me my want need looking"),
+	"it": set("il lo la i gli le un uno una di del dello della dei degli delle da dal dalla in nel nella con per su tra fra e o è che mi voglio cerco"),
+	"es": set("el la los las un una unos unas de del al en con por para y o es que me mi quiero busco"),
+	"fr": set("le la les un une des de du au aux en dans avec pour par sur et ou est que je me mon ma mes veux cherche"),
+	"de": set("der die das den dem des ein eine einen einem einer mit für von zu im in am an auf und oder ist ich mein meine möchte suche"),
+	"pt": set("o a os as um uma uns umas de do da dos das no na nos nas em com por para e ou é que eu me meu quero procuro"),
+}
+
+// markers are words common in phone searches of one language, or few, which
+// tell apart languages sharing their stopwords ("con una" is Italian and
+// Spanish). They count for detection only and are never trimmed.
+var markers = map[string]map[string]struct{}{
+	"en": set("phone good cheap best battery screen small big camera"),
+	"it": set("telefono cellulare buona buon economico fotocamera batteria schermo piccolo migliore"),
+	"es": set("teléfono móvil celular buena bueno barato cámara batería pantalla pequeño mejor"),
+	"fr": set("téléphone portable bon bonne appareil écran batterie petit meilleur"),
+	"de": set("handy telefon gute guter gutes günstig akku bildschirm kleines besten"),
+	"pt": set("telefone celular boa bom barato câmera bateria tela pequeno melhor"),
+}
+
+// set returns the words of list, accents folded to match normalized queries.
+func set(list string) map[string]struct{} {
+	m := map[string]struct{}{}
+	for _, w := range strings.Fields(FoldAccents(list)) {
+		m[w] = struct{}{}
+	}
+
+	return m
+}
+
+// TrimStopwords removes the stopwords of language lang from q, a normalized
+// query, so the embedding weighs the words describing the phone; "a phone
+// with a good camera" becomes "phone good camera". Languages without a list,
+// and queries made only of stopwords, are returned unchanged.
+func TrimStopwords(lang, q string) string {
+	list, ok := stopwords[lang]
+	if !ok {
+		return q
+	}
+
+	fields := strings.Fields(q)
+	kept := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		if _, stop := list[strings.Trim(f, ",.;:!?")]; !stop {
+			kept = append(kept, f)
+		}
+	}
+
+	if len(kept) == 0 {
+		return q
+	}
+
+	return strings.Join(kept, " ")
+}
@@ -0,0 +1,103 @@
+// Package logging provides request-scoped structured logging helpers.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the HTTP header used to carry the request ID between services.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Log formats accepted by New.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Levels lists the level names accepted by ParseLevel, most verbose first.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// New returns a logger writing the records at or above level to w as
+// logfmt-style text, or as JSON objects when format is FormatJSON, with the
+// request and trace IDs added by NewHandler.
+func New(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(NewHandler(h))
+}
+
+// ParseLevel parses one of Levels, case-insensitively.
+func ParseLevel(s string) (slog.Level, error) {
+	if !slices.Contains(Levels, strings.ToLower(s)) {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+
+	return level, err
+}
+
+// LevelName returns the name of level as listed in Levels.
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// contextHandler decorates every record with the request and trace IDs found in its context.
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewHandler wraps h so that records logged with a request-scoped context
+// carry request_id and, when tracing is active, trace_id attributes.
+func NewHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h}
+}
+
+// Handle adds the request and trace ID attributes, if any, and delegates to the wrapped handler.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a wrapped handler with the given attributes.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a wrapped handler with the given group.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,82 @@
+// Package mail sends plain-text notification emails through an SMTP relay.
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Sender delivers emails through one SMTP server. A nil Sender reports
+// ErrDisabled, so callers need not check whether email is configured.
+type Sender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// ErrDisabled is returned by a nil Sender.
+var ErrDisabled = errors.New("email is not configured")
+
+// New returns a Sender relaying through addr ("host:port") with from as the
+// sender address, authenticating with PLAIN auth when username is set. It
+// returns nil when addr is empty.
+func New(addr, from, username, password string) (*Sender, error) {
+	if addr == "" {
+		return nil, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP address %q: %w", addr, err)
+	}
+
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+
+	s := &Sender{addr: addr, from: from}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return s, nil
+}
+
+// Send emails a plain-text message to one recipient.
+func (s *Sender) Send(to, subject, body string) error {
+	if s == nil {
+		return ErrDisabled
+	}
+
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", to)
+	}
+
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(s.addr, s.auth, from.Address, []string{to}, msg.Bytes()); err != nil {
+		return fmt.Errorf("sending email: %w", err)
+	}
+
+	return nil
+}
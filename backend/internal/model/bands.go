@@ -0,0 +1,94 @@
+package model
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Bands are the network bands parsed from the band strings.
+type Bands struct {
+	// MHz2G and MHz3G are the 2G and 3G frequencies in MHz.
+	MHz2G []int `json:"2g_mhz,omitempty"`
+	MHz3G []int `json:"3g_mhz,omitempty"`
+	// LTE and NR are the 4G and 5G band numbers, e.g. 20 for b20 and 78
+	// for n78.
+	LTE []int `json:"lte,omitempty"`
+	NR  []int `json:"nr,omitempty"`
+}
+
+var (
+	// frequencyRe matches a frequency in MHz such as the 1700 of
+	// "1700(AWS)".
+	frequencyRe = regexp.MustCompile(`\b\d{3,4}\b`)
+	// bandNumberRe matches the band number a band list item starts with,
+	// but not a model number such as "6070K".
+	bandNumberRe = regexp.MustCompile(`^\s*(\d{1,3})\b`)
+)
+
+// ParseBands parses the 2G, 3G, 4G and 5G band strings of the dataset. The
+// 2G and 3G strings list frequencies ("GSM 850 / 900 / 1800 / 1900 - SIM 1 &
+// SIM 2"), the 4G and 5G strings band numbers ("1, 3, 41, 77, 78 SA/NSA").
+// LTE listed by frequency ("LTE 800 / 1800") maps to no band number and is
+// skipped. It returns nil when no band is recognized.
+func ParseBands(bands2G, bands3G, bands4G, bands5G string) *Bands {
+	b := Bands{
+		MHz2G: frequencies(bands2G),
+		MHz3G: frequencies(bands3G),
+		LTE:   bandNumbers(bands4G),
+		NR:    bandNumbers(bands5G),
+	}
+
+	if b.MHz2G == nil && b.MHz3G == nil && b.LTE == nil && b.NR == nil {
+		return nil
+	}
+
+	return &b
+}
+
+// frequencies returns the distinct frequencies of a 2G or 3G band string,
+// ignoring the region or model notes after " - ".
+func frequencies(s string) []int {
+	s, _, _ = strings.Cut(s, " - ")
+
+	var mhz []int
+
+	for _, f := range frequencyRe.FindAllString(s, -1) {
+		if n, _ := strconv.Atoi(f); !slices.Contains(mhz, n) {
+			mhz = append(mhz, n)
+		}
+	}
+
+	return mhz
+}
+
+// bandNumbers returns the distinct band numbers of a 4G or 5G band string:
+// the comma-separated items starting with a number. Notes such as the
+// "SA/NSA - Global" of the last item follow the number and are ignored.
+func bandNumbers(s string) []int {
+	var bands []int
+
+	for item := range strings.FieldsFuncSeq(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		m := bandNumberRe.FindStringSubmatch(item)
+		if m == nil {
+			continue
+		}
+
+		if n, _ := strconv.Atoi(m[1]); n > 0 && !slices.Contains(bands, n) {
+			bands = append(bands, n)
+		}
+	}
+
+	return bands
+}
+
+// intSlice converts integers to a list Qdrant payloads accept.
+func intSlice(s []int) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+
+	return out
+}
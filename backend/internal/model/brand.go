@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// brandAliases maps spellings of brands found in admin uploads and filters
+// to the name the dataset indexes them under. Keys are in the form
+// CanonicalBrand compares them, lowercase with separators folded to "_";
+// brands differing from the dataset only in case or separators, such as
+// "BLU" or "T-Mobile", need no entry.
+var brandAliases = map[string]string{
+	"tmobile":         "t_mobile",
+	"at_&_t":          "at&t",
+	"at_and_t":        "at&t",
+	"att":             "at&t",
+	"one_plus":        "oneplus",
+	"black_berry":     "blackberry",
+	"sonyericsson":    "sony_ericsson",
+	"hewlett_packard": "hp",
+	"lg_electronics":  "lg",
+	"blu_products":    "blu",
+	"imate":           "i_mate",
+	"imobile":         "i_mobile",
+	"vkmobile":        "vk_mobile",
+	"benqsiemens":     "benq_siemens",
+	"tel_me":          "tel_me_",
+	"telme":           "tel_me_",
+}
+
+// CanonicalBrand returns the name the dataset indexes brand under:
+// lowercase, with each run of spaces, hyphens, dots and underscores folded
+// to "_" and known aliases resolved, so "T Mobile", "T-Mobile" and
+// "t_mobile" are one brand in filters and facets.
+func CanonicalBrand(brand string) string {
+	var b strings.Builder
+
+	separator := false
+
+	for _, r := range strings.TrimSpace(brand) {
+		if unicode.IsSpace(r) || r == '-' || r == '.' || r == '_' {
+			separator = true
+			continue
+		}
+
+		if separator {
+			b.WriteByte('_')
+			separator = false
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	// A trailing separator is kept, as in "tel_me_" for "Tel.Me.".
+	if separator {
+		b.WriteByte('_')
+	}
+
+	canonical := b.String()
+	if alias, ok := brandAliases[canonical]; ok {
+		return alias
+	}
+
+	return canonical
+}
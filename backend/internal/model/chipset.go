@@ -0,0 +1,234 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SoC tiers, relative to the other chips of the same family.
+const (
+	TierFlagship = "flagship"
+	TierMidrange = "midrange"
+	TierEntry    = "entry"
+)
+
+// SoC is a chipset normalized from the free-text Chipset string.
+type SoC struct {
+	// Name is the marketing name, e.g. "Snapdragon 8 Gen 2" or "Apple A16".
+	Name string `json:"name"`
+	// Family is the product line, one of SoCFamilies.
+	Family string `json:"family"`
+	// Tier is TierFlagship, TierMidrange, TierEntry or "" when the family
+	// has no meaningful tiers.
+	Tier string `json:"tier,omitempty"`
+}
+
+// chipsetRule recognizes one chipset family. The first capture group of re
+// is the model within the family.
+type chipsetRule struct {
+	family string
+	re     *regexp.Regexp
+	// name builds the marketing name from the model.
+	name func(model string) string
+	// tier classifies the model; nil leaves the tier unknown.
+	tier func(model string) string
+}
+
+// chipsetRules recognize the chipset families. Product lines also match the
+// vendor and part number that often precede them ("Qualcomm SM8250
+// Snapdragon 865"), so they win over the vendor's catch-all rule listed
+// after them.
+var chipsetRules = []chipsetRule{
+	{
+		family: "Snapdragon",
+		re:     regexp.MustCompile(`(?:Qualcomm\s+(?:[\w-]+\s+)?)?Snapdragon\s+(\d\+?\s+Gen\s+\d|\d{3}[A-Z+]*|S\d(?:\s+(?:Plus|Pro|Play))?)`),
+		name:   prefixed("Snapdragon"),
+		tier: func(m string) string {
+			if strings.HasPrefix(m, "S") {
+				return TierEntry
+			}
+
+			switch m[0] {
+			case '8':
+				return TierFlagship
+			case '6', '7':
+				return TierMidrange
+			default:
+				return TierEntry
+			}
+		},
+	},
+	{
+		family: "Dimensity",
+		re:     regexp.MustCompile(`(?:(?i:MediaTek)\s+(?:MT\w+\s+)?)?Dimensity\s+(\d{3,4}[A-Z+]*)`),
+		name:   prefixed("Dimensity"),
+		tier: func(m string) string {
+			if n := leadingNumber(m); n >= 9000 || (n >= 1000 && n < 2000) {
+				return TierFlagship
+			}
+
+			return TierMidrange
+		},
+	},
+	{
+		family: "Helio",
+		re:     regexp.MustCompile(`(?:(?i:MediaTek)\s+(?:MT\w+\s+)?)?Helio\s+([AGPX]\d{2,3}[A-Z]*)`),
+		name:   prefixed("Helio"),
+		tier: func(m string) string {
+			if m[0] == 'A' || (m[0] == 'G' && leadingNumber(m[1:]) < 70) {
+				return TierEntry
+			}
+
+			return TierMidrange
+		},
+	},
+	{
+		family: "Exynos",
+		re:     regexp.MustCompile(`Exynos\s+(?:\d\s+\w+\s+)?(\d{3,4})`),
+		name:   prefixed("Exynos"),
+		tier: func(m string) string {
+			switch m[0] {
+			case '2', '8', '9':
+				return TierFlagship
+			case '7':
+				return TierMidrange
+			default:
+				return TierEntry
+			}
+		},
+	},
+	{
+		family: "Kirin",
+		re:     regexp.MustCompile(`Kirin\s+(\d{3,4}[A-Z]?)`),
+		name:   prefixed("Kirin"),
+		tier: func(m string) string {
+			switch m[0] {
+			case '9':
+				return TierFlagship
+			case '6', '7', '8':
+				return TierMidrange
+			default:
+				return TierEntry
+			}
+		},
+	},
+	{
+		family: "Apple",
+		re:     regexp.MustCompile(`Apple\s+(A\d+[XZ]?|M\d+|S\d+P?)`),
+		name:   prefixed("Apple"),
+		tier: func(m string) string {
+			// The S series powers watches, not phones.
+			if m[0] == 'S' {
+				return ""
+			}
+
+			return TierFlagship
+		},
+	},
+	{
+		family: "Tensor",
+		re:     regexp.MustCompile(`Tensor(\s+G\d)?`),
+		name:   func(m string) string { return "Google Tensor" + m },
+		tier:   func(string) string { return TierFlagship },
+	},
+	{
+		family: "Unisoc",
+		re:     regexp.MustCompile(`(?i)(?:Unisoc|Spreadtrum)\s+(\w+)`),
+		name:   prefixed("Unisoc"),
+		tier:   func(string) string { return TierEntry },
+	},
+	{
+		family: "Tegra",
+		re:     regexp.MustCompile(`Tegra\s+(\w+)`),
+		name:   prefixed("Tegra"),
+	},
+	{
+		family: "OMAP",
+		re:     regexp.MustCompile(`OMAP\s*(\w+)`),
+		name:   prefixed("OMAP"),
+	},
+	{
+		family: "Atom",
+		re:     regexp.MustCompile(`Atom\s+(\w+)`),
+		name:   prefixed("Atom"),
+	},
+	{
+		family: "MediaTek",
+		re:     regexp.MustCompile(`(?i)MediaTek\s+(MT\w+)`),
+		name:   prefixed("MediaTek"),
+	},
+	{
+		family: "Qualcomm",
+		re:     regexp.MustCompile(`Qualcomm\s+(\w+)`),
+		name:   prefixed("Qualcomm"),
+	},
+}
+
+// SoCFamilies lists the families NormalizeChipset recognizes.
+var SoCFamilies = func() []string {
+	families := make([]string, len(chipsetRules))
+	for i, r := range chipsetRules {
+		families[i] = r.family
+	}
+
+	return families
+}()
+
+// SoCTiers lists the tiers NormalizeChipset assigns.
+var SoCTiers = []string{TierFlagship, TierMidrange, TierEntry}
+
+// NormalizeChipset recognizes the chipset in s, a string such as "Qualcomm
+// SM8250 Snapdragon 865 (7 nm+)". When s lists regional variants, the first
+// one wins. It returns false for unrecognized chipsets.
+func NormalizeChipset(s string) (SoC, bool) {
+	var (
+		best  *chipsetRule
+		model string
+		start = len(s)
+	)
+
+	for i := range chipsetRules {
+		r := &chipsetRules[i]
+
+		m := r.re.FindStringSubmatchIndex(s)
+		if m == nil || m[0] >= start {
+			continue
+		}
+
+		best, start = r, m[0]
+		model = ""
+
+		if m[2] >= 0 {
+			model = s[m[2]:m[3]]
+		}
+	}
+
+	if best == nil {
+		return SoC{}, false
+	}
+
+	soc := SoC{Name: best.name(model), Family: best.family}
+	if best.tier != nil {
+		soc.Tier = best.tier(model)
+	}
+
+	return soc, true
+}
+
+// prefixed returns a name function prepending family to the model.
+func prefixed(family string) func(string) string {
+	return func(m string) string { return family + " " + m }
+}
+
+// leadingNumber returns the integer s starts with, or 0.
+func leadingNumber(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+
+	n, _ := strconv.Atoi(s[:end])
+
+	return n
+}
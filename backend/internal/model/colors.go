@@ -0,0 +1,118 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ColorFamilies are the base colors marketing color names are grouped into.
+var ColorFamilies = []string{
+	"black", "white", "silver", "gray", "gold", "blue", "red", "pink",
+	"green", "yellow", "orange", "purple", "brown",
+}
+
+// colorSynonyms maps words of marketing color names that are not themselves
+// a family to the family they belong to.
+var colorSynonyms = map[string]string{
+	"grey":       "gray",
+	"graphite":   "gray",
+	"titanium":   "gray",
+	"titan":      "gray",
+	"anthracite": "gray",
+	"slate":      "gray",
+	"charcoal":   "gray",
+	"platinum":   "silver",
+	"chrome":     "silver",
+	"steel":      "silver",
+	"pearl":      "white",
+	"ivory":      "white",
+	"champagne":  "gold",
+	"golden":     "gold",
+	"burgundy":   "red",
+	"rose":       "pink",
+	"magenta":    "pink",
+	"fuchsia":    "pink",
+	"coral":      "pink",
+	"violet":     "purple",
+	"lavender":   "purple",
+	"lilac":      "purple",
+	"plum":       "purple",
+	"aubergine":  "purple",
+	"orchid":     "purple",
+	"cyan":       "blue",
+	"aqua":       "blue",
+	"navy":       "blue",
+	"turquoise":  "blue",
+	"azure":      "blue",
+	"indigo":     "blue",
+	"lime":       "green",
+	"mint":       "green",
+	"emerald":    "green",
+	"olive":      "green",
+	"copper":     "brown",
+	"bronze":     "brown",
+	"chocolate":  "brown",
+	"tan":        "brown",
+	"beige":      "brown",
+}
+
+// ColorNames splits a colors string such as "Midnight Black, Pearl White"
+// into its distinct lowercase color names.
+func ColorNames(s string) []string {
+	var names []string
+
+	for name := range strings.FieldsFuncSeq(s, func(r rune) bool { return r == ',' || r == '/' || r == ';' || r == '&' }) {
+		name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+
+		// Skip bare counts such as "3", listed instead of the colors.
+		if !strings.ContainsFunc(name, unicode.IsLetter) || slices.Contains(names, name) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// ColorFamily returns the family of a color name, decided by its last word
+// naming one ("rose gold" is gold), or "" for names such as "aurora".
+func ColorFamily(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+
+	for _, w := range slices.Backward(words) {
+		if slices.Contains(ColorFamilies, w) {
+			return w
+		}
+
+		if family, ok := colorSynonyms[w]; ok {
+			return family
+		}
+	}
+
+	return ""
+}
+
+// colorFamilies returns the distinct families of names.
+func colorFamilies(names []string) []string {
+	var families []string
+
+	for _, name := range names {
+		if f := ColorFamily(name); f != "" && !slices.Contains(families, f) {
+			families = append(families, f)
+		}
+	}
+
+	return families
+}
+
+// anySlice converts strings to a list Qdrant payloads accept.
+func anySlice(s []string) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+
+	return out
+}
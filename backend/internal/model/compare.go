@@ -0,0 +1,84 @@
+package model
+
+import "github.com/alessandrolattao/qdrant-experiment/internal/specs"
+
+// Sides of a head-to-head comparison.
+const (
+	SideA = "a"
+	SideB = "b"
+	Tie   = "tie"
+)
+
+// SpecResult compares one numeric spec of two phones. Winner is empty when
+// the spec is unknown for either phone.
+type SpecResult struct {
+	Spec   string  `json:"spec"`
+	Unit   string  `json:"unit"`
+	A      float64 `json:"a,omitempty"`
+	B      float64 `json:"b,omitempty"`
+	Winner string  `json:"winner,omitempty"`
+}
+
+// Comparison is the head-to-head result of two phones over their comparable
+// numeric specs. Winner is the side that wins more specs, or Tie.
+type Comparison struct {
+	Specs  []SpecResult `json:"specs"`
+	WinsA  int          `json:"wins_a"`
+	WinsB  int          `json:"wins_b"`
+	Ties   int          `json:"ties"`
+	Winner string       `json:"winner"`
+}
+
+// comparableSpec is a numeric spec that Compare scores.
+type comparableSpec struct {
+	name   string
+	unit   string
+	value  func(specs.Specs) float64
+	higher bool
+}
+
+var comparableSpecs = []comparableSpec{
+	{"battery", "mAh", func(sp specs.Specs) float64 { return sp.BatteryMAh }, true},
+	{"ram", "GB", func(sp specs.Specs) float64 { return sp.RAMGB }, true},
+	{"camera", "MP", func(sp specs.Specs) float64 { return sp.CameraMP }, true},
+	{"weight", "g", func(sp specs.Specs) float64 { return sp.WeightG }, false},
+}
+
+// Compare scores a against b on battery capacity, RAM, main camera
+// resolution and weight. Larger values win except for weight, where the
+// lighter phone wins.
+func Compare(a, b Smartphone) Comparison {
+	c := Comparison{Specs: make([]SpecResult, 0, len(comparableSpecs))}
+	specsA, specsB := a.Specs(), b.Specs()
+
+	for _, spec := range comparableSpecs {
+		r := SpecResult{Spec: spec.name, Unit: spec.unit, A: spec.value(specsA), B: spec.value(specsB)}
+
+		if r.A > 0 && r.B > 0 {
+			switch {
+			case r.A == r.B:
+				r.Winner = Tie
+				c.Ties++
+			case (r.A > r.B) == spec.higher:
+				r.Winner = SideA
+				c.WinsA++
+			default:
+				r.Winner = SideB
+				c.WinsB++
+			}
+		}
+
+		c.Specs = append(c.Specs, r)
+	}
+
+	switch {
+	case c.WinsA > c.WinsB:
+		c.Winner = SideA
+	case c.WinsB > c.WinsA:
+		c.Winner = SideB
+	default:
+		c.Winner = Tie
+	}
+
+	return c
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SpecsText builds a facts-only text from the parsed and classified specs,
+// embedded as the "specs" vector: values a spec query names ("12 GB RAM",
+// "5000 mAh battery") without the free-form notes, sensors lists and color
+// names that dilute the Description.
+func (s Smartphone) SpecsText() string {
+	sp := s.Specs()
+
+	facts := []string{s.Brand + " " + s.Model}
+	add := func(format string, args ...any) {
+		facts = append(facts, fmt.Sprintf(format, args...))
+	}
+
+	if !sp.Announced.IsZero() {
+		add("announced %d", sp.Announced.Year())
+	}
+
+	if os := classifyOS(s.OS); os != "Other" {
+		add("%s", os)
+	}
+
+	if soc, ok := NormalizeChipset(s.Chipset); ok {
+		add("%s chipset", soc.Name)
+
+		if soc.Tier != "" {
+			add("%s chipset", soc.Tier)
+		}
+	}
+
+	if sp.RAMGB > 0 {
+		add("%g GB RAM", sp.RAMGB)
+	}
+
+	if sp.StorageGB > 0 {
+		add("%g GB storage", sp.StorageGB)
+	}
+
+	if sp.ScreenInches > 0 {
+		add("%g inch display", sp.ScreenInches)
+	}
+
+	if display := classifyDisplay(s.Display); display != "Other" {
+		add("%s display", display)
+	}
+
+	if sp.ResolutionWidth > 0 && sp.ResolutionHeight > 0 {
+		add("%dx%d resolution", sp.ResolutionWidth, sp.ResolutionHeight)
+	}
+
+	if sp.BatteryMAh > 0 {
+		add("%g mAh battery", sp.BatteryMAh)
+	}
+
+	if sp.CameraMP > 0 {
+		add("%g MP main camera", sp.CameraMP)
+	}
+
+	// The first lens is the main camera.
+	for i, lens := range sp.Lenses {
+		if i > 0 && lens.Type != "" {
+			add("%g MP %s camera", lens.MP, lens.Type)
+		}
+	}
+
+	if sp.WeightG > 0 {
+		add("%g g", sp.WeightG)
+	}
+
+	if strings.Contains(s.Technology, "5G") {
+		add("5G")
+	}
+
+	if strings.HasPrefix(s.NFC, "Yes") {
+		add("NFC")
+	}
+
+	if sim, ok := ParseSIM(s.SIM); ok {
+		if sim.DualSIM {
+			add("dual SIM")
+		}
+
+		if sim.ESIM {
+			add("eSIM")
+		}
+	}
+
+	if price, ok := ParsePrice(s.Price); ok {
+		if eur := price.EUR(); eur > 0 {
+			add("%.0f EUR", eur)
+		}
+	}
+
+	return strings.Join(facts, ", ")
+}
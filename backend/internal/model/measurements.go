@@ -0,0 +1,98 @@
+package model
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/specs"
+)
+
+// Unit systems for Measurements.
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+)
+
+const (
+	gramsPerOunce = 28.349523125
+	mmPerInch     = 25.4
+)
+
+// dimensionsRe matches "146.7 x 71.5 x 7.8 mm", taking the lower bound of
+// ranges such as "7.8-8.9".
+var dimensionsRe = regexp.MustCompile(`(\d+(?:\.\d+)?)(?:-[\d.]+)?\s*x\s*(\d+(?:\.\d+)?)(?:-[\d.]+)?\s*x\s*(\d+(?:\.\d+)?)(?:-[\d.]+)?\s*mm`)
+
+// Measurements are the numeric weight and dimensions of a phone in one unit
+// system: grams and millimeters for metric, ounces and inches for imperial.
+// Zero means unknown.
+type Measurements struct {
+	System     string  `json:"system"`
+	Weight     float64 `json:"weight,omitempty"`
+	WeightUnit string  `json:"weight_unit"`
+	Height     float64 `json:"height,omitempty"`
+	Width      float64 `json:"width,omitempty"`
+	Depth      float64 `json:"depth,omitempty"`
+	LengthUnit string  `json:"length_unit"`
+}
+
+// NewMeasurements returns metric measurements from grams and millimeters, or
+// nil when all are unknown.
+func NewMeasurements(grams, height, width, depth float64) *Measurements {
+	if grams <= 0 && height <= 0 {
+		return nil
+	}
+
+	return &Measurements{
+		System:     Metric,
+		Weight:     grams,
+		WeightUnit: "g",
+		Height:     height,
+		Width:      width,
+		Depth:      depth,
+		LengthUnit: "mm",
+	}
+}
+
+// ParseMeasurements parses weight strings like "168 g (5.93 oz)" and
+// dimension strings like "146.7 x 71.5 x 7.8 mm (5.78 x 2.81 x 0.31 in)".
+// It returns nil when neither can be parsed.
+func ParseMeasurements(weight, dimensions string) *Measurements {
+	var height, width, depth float64
+
+	grams := specs.Weight(weight)
+
+	if m := dimensionsRe.FindStringSubmatch(dimensions); m != nil {
+		height, _ = strconv.ParseFloat(m[1], 64)
+		width, _ = strconv.ParseFloat(m[2], 64)
+		depth, _ = strconv.ParseFloat(m[3], 64)
+	}
+
+	return NewMeasurements(grams, height, width, depth)
+}
+
+// In returns m converted to system, which must be Metric or Imperial.
+func (m Measurements) In(system string) Measurements {
+	if m.System == system {
+		return m
+	}
+
+	weightFactor, lengthFactor := 1/gramsPerOunce, 1/mmPerInch
+	out := Measurements{System: Imperial, WeightUnit: "oz", LengthUnit: "in"}
+
+	if system == Metric {
+		weightFactor, lengthFactor = gramsPerOunce, mmPerInch
+		out = Measurements{System: Metric, WeightUnit: "g", LengthUnit: "mm"}
+	}
+
+	out.Weight = round2(m.Weight * weightFactor)
+	out.Height = round2(m.Height * lengthFactor)
+	out.Width = round2(m.Width * lengthFactor)
+	out.Depth = round2(m.Depth * lengthFactor)
+
+	return out
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
+)
+
+// Money is an amount in a currency.
+type Money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+var (
+	// priceCodeRe matches "About 130 EUR" style prices.
+	priceCodeRe = regexp.MustCompile(`(\d[\d,]*(?:\.\d+)?)\s*(EUR|USD|GBP|INR)\b`)
+	// priceSymbolRe matches "$ 199.99 / € 189.00 / £ 169.99 / ₹ 15,999" style prices.
+	priceSymbolRe = regexp.MustCompile(`([$€£₹])\s*(\d[\d,]*(?:\.\d+)?)`)
+)
+
+var priceSymbols = map[string]string{
+	"$": currency.USD,
+	"€": currency.EUR,
+	"£": currency.GBP,
+	"₹": currency.INR,
+}
+
+// builtinRates normalizes prices at seed time; nil uses the built-in rates.
+var builtinRates *currency.Rates
+
+// ParsePrice extracts the price listed in s. When several currencies are
+// listed, euros are preferred, then the first one given.
+func ParsePrice(s string) (Money, bool) {
+	var prices []Money
+
+	for _, m := range priceCodeRe.FindAllStringSubmatch(s, -1) {
+		if amount, ok := parseAmount(m[1]); ok {
+			prices = append(prices, Money{Amount: amount, Currency: m[2]})
+		}
+	}
+
+	for _, m := range priceSymbolRe.FindAllStringSubmatch(s, -1) {
+		if amount, ok := parseAmount(m[2]); ok {
+			prices = append(prices, Money{Amount: amount, Currency: priceSymbols[m[1]]})
+		}
+	}
+
+	if len(prices) == 0 {
+		return Money{}, false
+	}
+
+	for _, p := range prices {
+		if p.Currency == currency.EUR {
+			return p, true
+		}
+	}
+
+	return prices[0], true
+}
+
+// EUR returns the amount converted to euros with the built-in rates.
+func (m Money) EUR() float64 {
+	eur, _ := builtinRates.ToEUR(m.Amount, m.Currency)
+	return eur
+}
+
+func parseAmount(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+
+	return v, true
+}
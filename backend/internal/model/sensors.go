@@ -0,0 +1,89 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Sensor keywords, as indexed in the sensors payload field.
+const (
+	SensorFingerprint             = "fingerprint"
+	SensorFingerprintRear         = "fingerprint_rear"
+	SensorFingerprintFront        = "fingerprint_front"
+	SensorFingerprintSide         = "fingerprint_side"
+	SensorFingerprintUnderDisplay = "fingerprint_under_display"
+	SensorFaceRecognition         = "face_recognition"
+	SensorIris                    = "iris_scanner"
+	SensorAccelerometer           = "accelerometer"
+	SensorGyro                    = "gyro"
+	SensorProximity               = "proximity"
+	SensorCompass                 = "compass"
+	SensorBarometer               = "barometer"
+	SensorAltimeter               = "altimeter"
+	SensorThermometer             = "thermometer"
+	SensorHumidity                = "humidity"
+	SensorHeartRate               = "heart_rate"
+	SensorSpO2                    = "spo2"
+	SensorColorSpectrum           = "color_spectrum"
+	SensorUV                      = "uv"
+	SensorGesture                 = "gesture"
+	SensorIRBlaster               = "ir_blaster"
+)
+
+// sensorRules map the wording of the Sensors string to sensor keywords, in
+// the order ParseSensors lists them.
+var sensorRules = []struct {
+	sensor string
+	re     *regexp.Regexp
+}{
+	{SensorFingerprint, regexp.MustCompile(`(?i)fingerprint`)},
+	{SensorFingerprintRear, regexp.MustCompile(`(?i)rear-mounted`)},
+	{SensorFingerprintFront, regexp.MustCompile(`(?i)front-mounted`)},
+	{SensorFingerprintSide, regexp.MustCompile(`(?i)side-mounted`)},
+	{SensorFingerprintUnderDisplay, regexp.MustCompile(`(?i)under display`)},
+	{SensorFaceRecognition, regexp.MustCompile(`(?i)face id|face recognition`)},
+	{SensorIris, regexp.MustCompile(`(?i)\biris\b`)},
+	{SensorAccelerometer, regexp.MustCompile(`(?i)accelerometer`)},
+	{SensorGyro, regexp.MustCompile(`(?i)\bgyro`)},
+	{SensorProximity, regexp.MustCompile(`(?i)proximity`)},
+	{SensorCompass, regexp.MustCompile(`(?i)compass`)},
+	{SensorBarometer, regexp.MustCompile(`(?i)barometer|baroceptor`)},
+	{SensorAltimeter, regexp.MustCompile(`(?i)altimeter`)},
+	{SensorThermometer, regexp.MustCompile(`(?i)thermometer|temperature`)},
+	{SensorHumidity, regexp.MustCompile(`(?i)humidity`)},
+	{SensorHeartRate, regexp.MustCompile(`(?i)heart rate`)},
+	{SensorSpO2, regexp.MustCompile(`(?i)\bspo2\b`)},
+	{SensorColorSpectrum, regexp.MustCompile(`(?i)color spectrum`)},
+	{SensorUV, regexp.MustCompile(`(?i)\buv\b`)},
+	{SensorGesture, regexp.MustCompile(`(?i)gesture`)},
+}
+
+// Sensors lists the keywords ParseSensors returns.
+var Sensors = func() []string {
+	sensors := make([]string, 0, len(sensorRules)+1)
+	for _, r := range sensorRules {
+		sensors = append(sensors, r.sensor)
+	}
+
+	return append(sensors, SensorIRBlaster)
+}()
+
+// ParseSensors returns the keywords of the sensors named in a Sensors string
+// such as "Fingerprint (under display, optical), accelerometer, gyro". A
+// fingerprint reader also gets a keyword for its placement. An infrared port
+// ("Yes") adds SensorIRBlaster.
+func ParseSensors(sensors, infrared string) []string {
+	var found []string
+
+	for _, r := range sensorRules {
+		if r.re.MatchString(sensors) {
+			found = append(found, r.sensor)
+		}
+	}
+
+	if strings.HasPrefix(infrared, "Yes") {
+		found = append(found, SensorIRBlaster)
+	}
+
+	return found
+}
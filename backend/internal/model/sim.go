@@ -0,0 +1,51 @@
+package model
+
+import "regexp"
+
+// SIM card sizes, as indexed in the sim_sizes payload field.
+const (
+	SIMMini  = "mini"
+	SIMMicro = "micro"
+	SIMNano  = "nano"
+)
+
+// SIMSizes lists the sizes ParseSIM recognizes.
+var SIMSizes = []string{SIMMini, SIMMicro, SIMNano}
+
+// SIMConfig is the SIM configuration classified from the SIM string.
+type SIMConfig struct {
+	// DualSIM reports two or more SIM slots, in some variant or shared
+	// with the memory card ("Hybrid Dual SIM").
+	DualSIM bool `json:"dual_sim"`
+	ESIM    bool `json:"esim"`
+	// Sizes are the physical SIM sizes accepted, in SIMSizes order.
+	Sizes []string `json:"sizes,omitempty"`
+}
+
+var (
+	dualSIMRe = regexp.MustCompile(`(?i)\b(?:dual|triple|quad)\s+sim\b`)
+	esimRe    = regexp.MustCompile(`(?i)\besim\b`)
+	simSizeRe = map[string]*regexp.Regexp{
+		SIMMini:  regexp.MustCompile(`(?i)\bmini[- ]?sim\b`),
+		SIMMicro: regexp.MustCompile(`(?i)\bmicro[- ]?sim\b`),
+		SIMNano:  regexp.MustCompile(`(?i)\bnano[- ]?sim\b`),
+	}
+)
+
+// ParseSIM classifies a SIM string such as "Single SIM (Nano-SIM and/or
+// eSIM) or Dual SIM (Nano-SIM, dual stand-by)". It returns false when the
+// string names no SIM configuration, e.g. "Yes" or "No".
+func ParseSIM(s string) (SIMConfig, bool) {
+	c := SIMConfig{
+		DualSIM: dualSIMRe.MatchString(s),
+		ESIM:    esimRe.MatchString(s),
+	}
+
+	for _, size := range SIMSizes {
+		if simSizeRe[size].MatchString(s) {
+			c.Sizes = append(c.Sizes, size)
+		}
+	}
+
+	return c, c.DualSIM || c.ESIM || len(c.Sizes) > 0
+}
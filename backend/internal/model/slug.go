@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Slugify builds a URL slug from parts, e.g. "samsung-galaxy-s23-ultra" from
+// "Samsung" and "Galaxy S23 Ultra". Letters are lowercased, "+" is spelled
+// out so "S23+" and "S23" differ, and other runs of punctuation or spaces
+// become single hyphens.
+func Slugify(parts ...string) string {
+	var b strings.Builder
+
+	hyphen := false
+
+	for _, part := range parts {
+		for _, r := range strings.ReplaceAll(part, "+", " plus ") {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				if hyphen && b.Len() > 0 {
+					b.WriteByte('-')
+				}
+
+				b.WriteRune(unicode.ToLower(r))
+				hyphen = false
+
+				continue
+			}
+
+			hyphen = true
+		}
+
+		hyphen = true
+	}
+
+	return b.String()
+}
+
+// AssignSlugs sets the slug of every phone from its brand and model, adding
+// "-2", "-3" and so on to phones whose slug is already taken.
+func AssignSlugs(phones []Smartphone) {
+	taken := make(map[string]bool, len(phones))
+
+	for i := range phones {
+		base := Slugify(phones[i].Brand, phones[i].Model)
+		slug := base
+
+		for n := 2; taken[slug]; n++ {
+			slug = base + "-" + strconv.Itoa(n)
+		}
+
+		taken[slug] = true
+		phones[i].Slug = slug
+	}
+}
@@ -2,20 +2,27 @@
 package model
 
 import (
+	"maps"
 	"net/url"
 	"path"
-	"regexp"
-	"strconv"
 	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/specs"
 )
 
 // Smartphone represents a phone from the GSMArena dataset.
 type Smartphone struct {
+	ID         uint64 `json:"id,omitempty"`
 	Brand      string `json:"brand"`
 	Model      string `json:"model"`
+	Slug       string `json:"slug,omitempty"`
 	ImageURL   string `json:"image_url"`
 	ImageFile  string `json:"image_file"`
 	Technology string `json:"technology"`
+	Bands2G    string `json:"bands_2g"`
+	Bands3G    string `json:"bands_3g"`
+	Bands4G    string `json:"bands_4g"`
+	Bands5G    string `json:"bands_5g"`
 	Announced  string `json:"announced"`
 	Status     string `json:"status"`
 	Dimensions string `json:"dimensions"`
@@ -42,26 +49,24 @@ type Smartphone struct {
 	NFC        string `json:"nfc"`
 	USB        string `json:"usb"`
 	Sensors    string `json:"sensors"`
+	Infrared   string `json:"infrared"`
 	Colors     string  `json:"colors"`
 	Price      string  `json:"price"`
-	Score      float32 `json:"score,omitempty"`
-}
-
-var eurPriceRe = regexp.MustCompile(`(\d+(?:\.\d{1,2})?)\s*EUR`)
-
-// parseEURPrice extracts the first EUR price from a string like "About 130 EUR".
-func parseEURPrice(s string) float64 {
-	m := eurPriceRe.FindStringSubmatch(s)
-	if len(m) < 2 {
-		return 0
-	}
-
-	v, err := strconv.ParseFloat(m[1], 64)
-	if err != nil {
-		return 0
-	}
-
-	return v
+	// PriceNormalized is the parsed price in euros, or in the currency
+	// requested from the API.
+	PriceNormalized *Money `json:"price_normalized,omitempty"`
+	// Measurements are the parsed weight and dimensions, metric unless
+	// imperial units were requested from the API.
+	Measurements *Measurements `json:"measurements,omitempty"`
+	// SoC is the normalized chipset, when recognized.
+	SoC *SoC `json:"soc,omitempty"`
+	// SIMConfig is the classified SIM string, when recognized.
+	SIMConfig *SIMConfig `json:"sim_config,omitempty"`
+	// Bands are the parsed network bands, when listed.
+	Bands *Bands `json:"bands,omitempty"`
+	// SpecsParsed are the numeric values parsed from the spec strings.
+	SpecsParsed *specs.Specs `json:"specs_parsed,omitempty"`
+	Score       float32      `json:"score,omitempty"`
 }
 
 // classifyOS normalizes the raw OS string into a family bucket.
@@ -163,14 +168,49 @@ func (s Smartphone) Description() string {
 	return b.String()
 }
 
+// ParseSpecs parses the numeric specs from the phone's spec strings.
+func (s Smartphone) ParseSpecs() specs.Specs {
+	return specs.Parse(specs.Raw{
+		Announced:  s.Announced,
+		Battery:    s.Battery,
+		Weight:     s.Weight,
+		ScreenSize: s.ScreenSize,
+		Storage:    s.Storage,
+		Resolution: s.Resolution,
+		Camera:     s.Camera,
+	})
+}
+
+// Specs returns the specs read from the index, parsing the spec strings of
+// phones that have none.
+func (s Smartphone) Specs() specs.Specs {
+	if s.SpecsParsed != nil {
+		return *s.SpecsParsed
+	}
+
+	return s.ParseSpecs()
+}
+
 // PayloadMap returns the smartphone data as a map for Qdrant payload.
 func (s Smartphone) PayloadMap() map[string]any {
-	return map[string]any{
-		"brand":       s.Brand,
+	price, _ := ParsePrice(s.Price)
+
+	var size Measurements
+	if m := ParseMeasurements(s.Weight, s.Dimensions); m != nil {
+		size = *m
+	}
+
+	payload := map[string]any{
+		"brand":       CanonicalBrand(s.Brand),
 		"model":       s.Model,
+		"slug":        s.Slug,
 		"image_url":   s.ImageURL,
 		"image_file":  s.ImageFile,
 		"technology":  s.Technology,
+		"bands_2g":    s.Bands2G,
+		"bands_3g":    s.Bands3G,
+		"bands_4g":    s.Bands4G,
+		"bands_5g":    s.Bands5G,
 		"announced":   s.Announced,
 		"status":      s.Status,
 		"dimensions":  s.Dimensions,
@@ -197,11 +237,49 @@ func (s Smartphone) PayloadMap() map[string]any {
 		"nfc":         s.NFC,
 		"usb":         s.USB,
 		"sensors":     s.Sensors,
+		"infrared":    s.Infrared,
 		"colors":      s.Colors,
 		"price":       s.Price,
-		"description":  s.Description(),
-		"os_family":    classifyOS(s.OS),
-		"display_type": classifyDisplay(s.Display),
-		"price_eur":    parseEURPrice(s.Price),
+		"description":    s.Description(),
+		"os_family":      classifyOS(s.OS),
+		"display_type":   classifyDisplay(s.Display),
+		"price_eur":      price.EUR(),
+		"price_amount":   price.Amount,
+		"price_currency": price.Currency,
+		"height_mm":      size.Height,
+		"width_mm":       size.Width,
+		"depth_mm":       size.Depth,
+	}
+
+	if sim, ok := ParseSIM(s.SIM); ok {
+		payload["dual_sim"] = sim.DualSIM
+		payload["esim"] = sim.ESIM
+		payload["sim_sizes"] = anySlice(sim.Sizes)
+	}
+
+	if bands := ParseBands(s.Bands2G, s.Bands3G, s.Bands4G, s.Bands5G); bands != nil {
+		payload["bands_2g_mhz"] = intSlice(bands.MHz2G)
+		payload["bands_3g_mhz"] = intSlice(bands.MHz3G)
+		payload["lte_bands"] = intSlice(bands.LTE)
+		payload["nr_bands"] = intSlice(bands.NR)
+	}
+
+	if sensors := ParseSensors(s.Sensors, s.Infrared); len(sensors) > 0 {
+		payload["sensor_list"] = anySlice(sensors)
+	}
+
+	if names := ColorNames(s.Colors); len(names) > 0 {
+		payload["color_names"] = anySlice(names)
+		payload["color_families"] = anySlice(colorFamilies(names))
 	}
+
+	if soc, ok := NormalizeChipset(s.Chipset); ok {
+		payload["soc_name"] = soc.Name
+		payload["soc_family"] = soc.Family
+		payload["soc_tier"] = soc.Tier
+	}
+
+	maps.Copy(payload, s.ParseSpecs().Payload())
+
+	return payload
 }
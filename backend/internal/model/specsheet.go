@@ -0,0 +1,161 @@
+package model
+
+import "github.com/alessandrolattao/qdrant-experiment/internal/specs"
+
+// SpecSheet is a Smartphone with its spec strings grouped into sections, the
+// response shape of API schema v2.
+type SpecSheet struct {
+	ID        uint64 `json:"id,omitempty"`
+	Brand     string `json:"brand"`
+	Model     string `json:"model"`
+	Slug      string `json:"slug,omitempty"`
+	Announced string `json:"announced"`
+	Status    string `json:"status"`
+
+	Image        ImageSection        `json:"image"`
+	Network      NetworkSection      `json:"network"`
+	Body         BodySection         `json:"body"`
+	Display      DisplaySection      `json:"display"`
+	Platform     PlatformSection     `json:"platform"`
+	Camera       CameraSection       `json:"camera"`
+	Battery      BatterySection      `json:"battery"`
+	Connectivity ConnectivitySection `json:"connectivity"`
+	Price        PriceSection        `json:"price"`
+
+	// SpecsParsed are the numeric values parsed from the spec strings.
+	SpecsParsed *specs.Specs `json:"specs_parsed,omitempty"`
+	Score       float32      `json:"score,omitempty"`
+}
+
+// ImageSection locates the phone's picture.
+type ImageSection struct {
+	URL  string `json:"url"`
+	File string `json:"file"`
+}
+
+// NetworkSection lists the supported networks and SIM cards.
+type NetworkSection struct {
+	Technology string     `json:"technology"`
+	Bands2G    string     `json:"bands_2g"`
+	Bands3G    string     `json:"bands_3g"`
+	Bands4G    string     `json:"bands_4g"`
+	Bands5G    string     `json:"bands_5g"`
+	Bands      *Bands     `json:"bands,omitempty"`
+	SIM        string     `json:"sim"`
+	SIMConfig  *SIMConfig `json:"sim_config,omitempty"`
+}
+
+// BodySection describes the phone's build.
+type BodySection struct {
+	Dimensions   string        `json:"dimensions"`
+	Weight       string        `json:"weight"`
+	Colors       string        `json:"colors"`
+	Measurements *Measurements `json:"measurements,omitempty"`
+}
+
+// DisplaySection describes the screen.
+type DisplaySection struct {
+	Type       string `json:"type"`
+	Size       string `json:"size"`
+	Resolution string `json:"resolution"`
+	Protection string `json:"protection"`
+}
+
+// PlatformSection describes the software, chipset and memory.
+type PlatformSection struct {
+	OS       string `json:"os"`
+	Chipset  string `json:"chipset"`
+	CPU      string `json:"cpu"`
+	GPU      string `json:"gpu"`
+	SoC      *SoC   `json:"soc,omitempty"`
+	Storage  string `json:"storage"`
+	CardSlot string `json:"card_slot"`
+	Sensors  string `json:"sensors"`
+}
+
+// CameraSection describes the main and selfie cameras.
+type CameraSection struct {
+	Main   string `json:"main"`
+	Video  string `json:"video"`
+	Selfie string `json:"selfie"`
+}
+
+// BatterySection describes the battery and charging.
+type BatterySection struct {
+	Type     string `json:"type"`
+	Charging string `json:"charging"`
+}
+
+// ConnectivitySection lists the radios and ports.
+type ConnectivitySection struct {
+	WLAN      string `json:"wlan"`
+	Bluetooth string `json:"bluetooth"`
+	GPS       string `json:"gps"`
+	NFC       string `json:"nfc"`
+	USB       string `json:"usb"`
+	Infrared  string `json:"infrared"`
+}
+
+// PriceSection holds the listed and normalized price.
+type PriceSection struct {
+	Listed     string `json:"listed"`
+	Normalized *Money `json:"normalized,omitempty"`
+}
+
+// SpecSheet groups the phone's specs into sections.
+func (s Smartphone) SpecSheet() SpecSheet {
+	return SpecSheet{
+		ID:        s.ID,
+		Brand:     s.Brand,
+		Model:     s.Model,
+		Slug:      s.Slug,
+		Announced: s.Announced,
+		Status:    s.Status,
+		Image:     ImageSection{URL: s.ImageURL, File: s.ImageFile},
+		Network: NetworkSection{
+			Technology: s.Technology,
+			Bands2G:    s.Bands2G,
+			Bands3G:    s.Bands3G,
+			Bands4G:    s.Bands4G,
+			Bands5G:    s.Bands5G,
+			Bands:      s.Bands,
+			SIM:        s.SIM,
+			SIMConfig:  s.SIMConfig,
+		},
+		Body: BodySection{
+			Dimensions:   s.Dimensions,
+			Weight:       s.Weight,
+			Colors:       s.Colors,
+			Measurements: s.Measurements,
+		},
+		Display: DisplaySection{
+			Type:       s.Display,
+			Size:       s.ScreenSize,
+			Resolution: s.Resolution,
+			Protection: s.Protection,
+		},
+		Platform: PlatformSection{
+			OS:       s.OS,
+			Chipset:  s.Chipset,
+			CPU:      s.CPU,
+			GPU:      s.GPU,
+			SoC:      s.SoC,
+			Storage:  s.Storage,
+			CardSlot: s.CardSlot,
+			Sensors:  s.Sensors,
+		},
+		Camera:  CameraSection{Main: s.Camera, Video: s.Video, Selfie: s.Selfie},
+		Battery: BatterySection{Type: s.Battery, Charging: s.Charging},
+		Connectivity: ConnectivitySection{
+			WLAN:      s.WLAN,
+			Bluetooth: s.Bluetooth,
+			GPS:       s.GPS,
+			NFC:       s.NFC,
+			USB:       s.USB,
+			Infrared:  s.Infrared,
+		},
+		Price:       PriceSection{Listed: s.Price, Normalized: s.PriceNormalized},
+		SpecsParsed: s.SpecsParsed,
+		Score:       s.Score,
+	}
+}
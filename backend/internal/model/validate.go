@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/specs"
+)
+
+// Data validation rules, as reported in Rejection.Rule.
+const (
+	RuleRequired = "required"
+	RuleURL      = "url"
+	RuleRange    = "range"
+)
+
+// Rejection is one reason a phone failed data validation.
+type Rejection struct {
+	// Field is the JSON name of the offending field, or of the parsed spec
+	// for range violations.
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+
+	// format and args rebuild Message in another language.
+	format string
+	args   []any
+}
+
+func reject(field, rule, format string, args ...any) Rejection {
+	return Rejection{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...), format: format, args: args}
+}
+
+// Format returns the untranslated format and arguments of Message.
+func (r Rejection) Format() (string, []any) {
+	return r.format, r.args
+}
+
+// plausibleRange bounds a parsed spec; values outside it are parse errors or
+// bad data rather than real phones.
+type plausibleRange struct {
+	field string
+	value func(specs.Specs) float64
+	min   float64
+	max   float64
+}
+
+var plausibleRanges = []plausibleRange{
+	{"battery_mah", func(sp specs.Specs) float64 { return sp.BatteryMAh }, 100, 30000},
+	{"weight_g", func(sp specs.Specs) float64 { return sp.WeightG }, 3, 3000},
+	{"screen_inches", func(sp specs.Specs) float64 { return sp.ScreenInches }, 0.5, 25},
+	{"ram_gb", func(sp specs.Specs) float64 { return sp.RAMGB }, 0, 32},
+	{"storage_gb", func(sp specs.Specs) float64 { return sp.StorageGB }, 0, 4096},
+	{"camera_mp", func(sp specs.Specs) float64 { return sp.CameraMP }, 0.1, 250},
+	{"resolution_width", func(sp specs.Specs) float64 { return float64(sp.ResolutionWidth) }, 16, 8192},
+	{"resolution_height", func(sp specs.Specs) float64 { return float64(sp.ResolutionHeight) }, 16, 8192},
+}
+
+// Validate checks the phone against the data validation rules applied before
+// indexing: brand and model are required, the image URL must be an absolute
+// http(s) URL and the parsed specs must be plausible. Unknown specs pass. It
+// returns nil for a valid phone.
+func (s Smartphone) Validate() []Rejection {
+	var rejections []Rejection
+
+	if strings.TrimSpace(s.Brand) == "" {
+		rejections = append(rejections, reject("brand", RuleRequired, "is required"))
+	}
+
+	if strings.TrimSpace(s.Model) == "" {
+		rejections = append(rejections, reject("model", RuleRequired, "is required"))
+	}
+
+	if s.ImageURL != "" {
+		u, err := url.Parse(s.ImageURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			rejections = append(rejections, reject("image_url", RuleURL, "must be an absolute http or https URL"))
+		}
+	}
+
+	parsed := s.Specs()
+
+	for _, r := range plausibleRanges {
+		if v := r.value(parsed); v > 0 && (v < r.min || v > r.max) {
+			rejections = append(rejections, reject(r.field, RuleRange, "%g is outside the plausible range %g to %g", v, r.min, r.max))
+		}
+	}
+
+	return rejections
+}
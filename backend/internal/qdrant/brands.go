@@ -0,0 +1,110 @@
+package qdrant
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BrandOverview aggregates the indexed phones of one brand.
+type BrandOverview struct {
+	Brand  string
+	Phones int
+	// PriceMin and PriceMax span the euro prices of the Priced phones that
+	// have one.
+	PriceMin float64
+	PriceMax float64
+	Priced   int
+	// OS counts phones by OS family.
+	OS map[string]uint64
+	// Newest are the most recently announced phones, newest first.
+	Newest []model.Smartphone
+}
+
+// BrandOverview computes the overview of brand, with up to newest of its
+// latest phones. Phones is zero when the brand has no indexed phones.
+func (s *Searcher) BrandOverview(ctx context.Context, brand string, newest int) (BrandOverview, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "qdrant.BrandOverview", trace.WithAttributes(attribute.String("qdrant.brand", brand)))
+	defer span.End()
+
+	filter := &qdrantclient.Filter{Must: []*qdrantclient.Condition{qdrantclient.NewMatch("brand", brand)}}
+	overview := BrandOverview{Brand: brand, OS: map[string]uint64{}}
+
+	counts, err := s.facet(ctx, "os_family", filter)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return BrandOverview{}, err
+	}
+
+	for _, c := range counts {
+		overview.OS[c.value] = c.count
+	}
+
+	var phones []model.Smartphone
+
+	var offset *qdrantclient.PointId
+
+	scrollLimit := uint32(1000)
+
+	for {
+		points, next, err := s.client.ScrollAndOffset(ctx, &qdrantclient.ScrollPoints{
+			CollectionName: s.collectionName,
+			Filter:         filter,
+			Limit:          &scrollLimit,
+			Offset:         offset,
+			WithPayload:    qdrantclient.NewWithPayload(true),
+			WithVectors:    qdrantclient.NewWithVectors(false),
+		})
+		if err != nil {
+			tracing.RecordError(span, err)
+			return BrandOverview{}, fmt.Errorf("scrolling brand phones: %w", err)
+		}
+
+		for _, p := range points {
+			phone := payloadToSmartphone(p.Payload)
+			phone.ID = p.GetId().GetNum()
+			phones = append(phones, phone)
+		}
+
+		if next == nil {
+			break
+		}
+
+		offset = next
+	}
+
+	overview.Phones = len(phones)
+
+	for _, p := range phones {
+		if p.PriceNormalized == nil {
+			continue
+		}
+
+		price := p.PriceNormalized.Amount
+		if overview.Priced == 0 || price < overview.PriceMin {
+			overview.PriceMin = price
+		}
+
+		overview.PriceMax = max(overview.PriceMax, price)
+		overview.Priced++
+	}
+
+	slices.SortStableFunc(phones, func(a, b model.Smartphone) int {
+		return cmp.Or(b.Specs().Announced.Compare(a.Specs().Announced), cmp.Compare(b.ID, a.ID))
+	})
+
+	overview.Newest = phones[:min(newest, len(phones))]
+
+	return overview, nil
+}
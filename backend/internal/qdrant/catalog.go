@@ -0,0 +1,203 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// UpsertResult reports one phone written by Upsert.
+type UpsertResult struct {
+	Phone model.Smartphone
+	// Created is false when the phone replaced an indexed one.
+	Created bool
+}
+
+// Upsert indexes phones outside the initial seed, with their brands
+// canonicalized by model.CanonicalBrand. Phones without an ID reuse
+// the ID of an indexed phone with the same brand and model, so re-importing
+// a phone replaces it; otherwise they get an ID derived from brand and model.
+// Each phone gets the slug of its brand and model. The results carry the
+// phones with IDs, slugs and image files filled in.
+func (s *Seeder) Upsert(ctx context.Context, phones []model.Smartphone) ([]UpsertResult, error) {
+	ctx, span := tracer.Start(ctx, "qdrant.Upsert", trace.WithAttributes(attribute.Int("catalog.size", len(phones))))
+	defer span.End()
+
+	phones = append([]model.Smartphone(nil), phones...)
+	created := make([]bool, len(phones))
+
+	for i := range phones {
+		var err error
+
+		phones[i].Brand = model.CanonicalBrand(phones[i].Brand)
+
+		if phones[i].ID != 0 {
+			created[i], err = s.missing(ctx, phones[i].ID)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		id, err := s.findID(ctx, phones[i].Brand, phones[i].Model)
+		if err != nil {
+			return nil, err
+		}
+
+		// A phone added before whose slug took an ID suffix is found by
+		// its derived ID.
+		if id == 0 {
+			id = catalogID(phones[i].Brand, phones[i].Model)
+
+			created[i], err = s.missing(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		phones[i].ID = id
+	}
+
+	for i := range phones {
+		if err := s.assignSlug(ctx, &phones[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < len(phones); i += s.batchSize {
+		if err := s.index(ctx, phones[i:min(i+s.batchSize, len(phones))]); err != nil {
+			return nil, fmt.Errorf("indexing phones: %w", err)
+		}
+	}
+
+	results := make([]UpsertResult, len(phones))
+	for i, p := range phones {
+		results[i] = UpsertResult{Phone: p, Created: created[i]}
+	}
+
+	return results, nil
+}
+
+// Delete removes the phones with the given IDs; missing IDs are ignored.
+func (s *Seeder) Delete(ctx context.Context, ids ...uint64) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	pointIDs := make([]*qdrantclient.PointId, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = qdrantclient.NewIDNum(id)
+	}
+
+	wait := true
+
+	if _, err := s.client.Delete(ctx, &qdrantclient.DeletePoints{
+		CollectionName: s.collectionName,
+		Points:         qdrantclient.NewPointsSelector(pointIDs...),
+		Wait:           &wait,
+	}); err != nil {
+		return fmt.Errorf("deleting points: %w", err)
+	}
+
+	return nil
+}
+
+// missing reports whether no point with id is indexed.
+func (s *Seeder) missing(ctx context.Context, id uint64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	points, err := s.client.Get(ctx, &qdrantclient.GetPoints{
+		CollectionName: s.collectionName,
+		Ids:            []*qdrantclient.PointId{qdrantclient.NewIDNum(id)},
+		WithPayload:    qdrantclient.NewWithPayload(false),
+		WithVectors:    qdrantclient.NewWithVectors(false),
+	})
+	if err != nil {
+		return false, fmt.Errorf("getting point %d: %w", id, err)
+	}
+
+	return len(points) == 0, nil
+}
+
+// findID returns the ID of the indexed phone with brand and model, or 0.
+// The model field has a full-text index only, so phones are looked up by
+// the keyword-indexed brand and base slug, and the model compared here.
+func (s *Seeder) findID(ctx context.Context, brand, phoneModel string) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	limit := uint32(10)
+
+	points, err := s.client.Scroll(ctx, &qdrantclient.ScrollPoints{
+		CollectionName: s.collectionName,
+		Filter: &qdrantclient.Filter{Must: []*qdrantclient.Condition{
+			qdrantclient.NewMatch("brand", brand),
+			qdrantclient.NewMatch("slug", model.Slugify(brand, phoneModel)),
+		}},
+		Limit:       &limit,
+		WithPayload: qdrantclient.NewWithPayloadInclude("model"),
+		WithVectors: qdrantclient.NewWithVectors(false),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("looking up %s %s: %w", brand, phoneModel, err)
+	}
+
+	for _, p := range points {
+		if payloadString(p.GetPayload(), "model") == phoneModel {
+			return p.GetId().GetNum(), nil
+		}
+	}
+
+	return 0, nil
+}
+
+// assignSlug gives phone the slug of its brand and model, suffixed with its
+// ID when another indexed phone already uses that slug.
+func (s *Seeder) assignSlug(ctx context.Context, phone *model.Smartphone) error {
+	slug := model.Slugify(phone.Brand, phone.Model)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	limit := uint32(1)
+
+	points, err := s.client.Scroll(ctx, &qdrantclient.ScrollPoints{
+		CollectionName: s.collectionName,
+		Filter: &qdrantclient.Filter{
+			Must:    []*qdrantclient.Condition{qdrantclient.NewMatch("slug", slug)},
+			MustNot: []*qdrantclient.Condition{qdrantclient.NewHasID(qdrantclient.NewIDNum(phone.ID))},
+		},
+		Limit:       &limit,
+		WithPayload: qdrantclient.NewWithPayload(false),
+		WithVectors: qdrantclient.NewWithVectors(false),
+	})
+	if err != nil {
+		return fmt.Errorf("looking up slug %s: %w", slug, err)
+	}
+
+	if len(points) > 0 {
+		slug += "-" + strconv.FormatUint(phone.ID, 10)
+	}
+
+	phone.Slug = slug
+
+	return nil
+}
+
+// catalogID derives a stable point ID for a phone added after seeding. IDs lie
+// in [2^52, 2^53): above the sequential IDs of seeded phones, yet still exact
+// as JavaScript numbers.
+func catalogID(brand, phoneModel string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(brand + "\x00" + phoneModel))
+
+	return h.Sum64()&(1<<52-1) | 1<<52
+}
@@ -0,0 +1,104 @@
+//go:build integration
+
+package qdrant
+
+import (
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	"github.com/ory/dockertest/v3"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+)
+
+// newTestSeeder starts a Qdrant container and returns a Seeder using it
+// with the mock embedder, its collection created. It needs Docker.
+func newTestSeeder(t *testing.T) *Seeder {
+	t.Helper()
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Fatalf("connecting to docker: %v", err)
+	}
+
+	pool.MaxWait = 2 * time.Minute
+
+	resource, err := pool.Run("qdrant/qdrant", "v1.17.1", nil)
+	if err != nil {
+		t.Fatalf("starting qdrant: %v", err)
+	}
+
+	t.Cleanup(func() { _ = pool.Purge(resource) })
+
+	host, port, err := net.SplitHostPort(resource.GetHostPort("6334/tcp"))
+	if err != nil {
+		t.Fatalf("resolving the qdrant port: %v", err)
+	}
+
+	portNum, _ := strconv.Atoi(port)
+
+	client, err := NewClient(ClientOptions{Host: host, Port: portNum})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = client.Close() })
+
+	if err := pool.Retry(func() error {
+		_, err := client.HealthCheck(t.Context())
+		return err
+	}); err != nil {
+		t.Fatalf("waiting for qdrant: %v", err)
+	}
+
+	mock := httptest.NewServer(embedder.NewMock())
+	t.Cleanup(mock.Close)
+
+	s := NewSeeder(client, embedder.NewClient(mock.URL, "", 10*time.Second), "", t.TempDir(), Options{})
+	if err := s.createCollection(t.Context()); err != nil {
+		t.Fatalf("creating collection: %v", err)
+	}
+
+	return s
+}
+
+func TestUpsertSameModelTwiceUpdatesOnePoint(t *testing.T) {
+	s := newTestSeeder(t)
+
+	phone := model.Smartphone{Brand: "Samsung", Model: "Galaxy S23+", Weight: "168 g"}
+
+	first, err := s.Upsert(t.Context(), []model.Smartphone{phone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phone.Weight = "170 g"
+
+	second, err := s.Upsert(t.Context(), []model.Smartphone{phone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !first[0].Created || second[0].Created {
+		t.Errorf("created = %t then %t, want true then false", first[0].Created, second[0].Created)
+	}
+
+	if first[0].Phone.ID != second[0].Phone.ID {
+		t.Errorf("IDs %d and %d, want one", first[0].Phone.ID, second[0].Phone.ID)
+	}
+
+	exact := true
+
+	count, err := s.client.Count(t.Context(), &qdrantclient.CountPoints{CollectionName: s.collectionName, Exact: &exact})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 1 {
+		t.Errorf("%d points, want 1", count)
+	}
+}
@@ -2,20 +2,106 @@
 package qdrant
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
 
 	qdrantclient "github.com/qdrant/go-client/qdrant"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
 )
 
+var tracer = otel.Tracer("github.com/alessandrolattao/qdrant-experiment/internal/qdrant")
+
+// ClientOptions configures the gRPC connection to Qdrant.
+type ClientOptions struct {
+	Host string
+	Port int
+	// APIKey authenticates every request; empty sends none.
+	APIKey string
+	// TLS encrypts the connection, verifying the server against the system
+	// roots, or against CAFile when set. ServerName overrides the name
+	// verified. CertFile and KeyFile present a client certificate.
+	TLS        bool
+	CAFile     string
+	ServerName string
+	CertFile   string
+	KeyFile    string
+	// PoolSize is the number of connections requests are spread over; 0
+	// selects the client default.
+	PoolSize uint
+	// KeepAliveSeconds is the idle time after which the connection is
+	// pinged; 0 selects the client default and -1 disables pings.
+	KeepAliveSeconds int
+	// MaxMessageBytes bounds the responses accepted; 0 keeps the gRPC
+	// default of 4MB.
+	MaxMessageBytes int
+	// DialOptions are appended to the options built from the fields above.
+	DialOptions []grpc.DialOption
+}
+
 // NewClient creates a new Qdrant gRPC client.
-func NewClient(host string, port int) (*qdrantclient.Client, error) {
-	client, err := qdrantclient.NewClient(&qdrantclient.Config{
-		Host: host,
-		Port: port,
-	})
+func NewClient(o ClientOptions) (*qdrantclient.Client, error) {
+	cfg := &qdrantclient.Config{
+		Host:          o.Host,
+		Port:          o.Port,
+		APIKey:        o.APIKey,
+		UseTLS:        o.TLS,
+		PoolSize:      o.PoolSize,
+		KeepAliveTime: o.KeepAliveSeconds,
+		GrpcOptions:   o.DialOptions,
+	}
+
+	if o.TLS {
+		tlsConfig, err := o.tlsConfig()
+		if err != nil {
+			return nil, fmt.Errorf("configuring qdrant tls: %w", err)
+		}
+
+		cfg.TLSConfig = tlsConfig
+	}
+
+	if o.MaxMessageBytes > 0 {
+		cfg.GrpcOptions = append(cfg.GrpcOptions, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(o.MaxMessageBytes)))
+	}
+
+	client, err := qdrantclient.NewClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("creating qdrant client: %w", err)
 	}
 
 	return client, nil
 }
+
+// tlsConfig builds the TLS configuration of the connection.
+func (o ClientOptions) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		ServerName: o.ServerName,
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading ca file: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("ca file holds no PEM certificates")
+		}
+	}
+
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,100 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+)
+
+// Example is a discovery example: the stored text vector of an indexed
+// phone when ID is set, otherwise the embedding of Text.
+type Example struct {
+	ID   uint64
+	Text string
+}
+
+// ContextPair steers discovery toward Positive and away from Negative.
+type ContextPair struct {
+	Positive Example
+	Negative Example
+}
+
+// Discover returns phones close to target within the region of the text
+// vector space the context pairs point to: the results are on the positive
+// side of as many pairs as possible, ranked by similarity to target. Without
+// a target the results are ranked by the context alone. Phones given as
+// examples are left out.
+func (s *Searcher) Discover(ctx context.Context, target *Example, pairs []ContextPair, limit uint64, filters SearchFilters) ([]model.Smartphone, error) {
+	var examples []uint64
+
+	input := func(e Example) (*qdrantclient.VectorInput, error) {
+		if e.ID != 0 {
+			examples = append(examples, e.ID)
+			return qdrantclient.NewVectorInputID(qdrantclient.NewIDNum(e.ID)), nil
+		}
+
+		start := time.Now()
+		embedding, err := s.embedder.EmbedText(ctx, e.Text)
+		recordEmbed(ctx, start)
+
+		if err != nil {
+			return nil, fmt.Errorf("embedding text: %w", err)
+		}
+
+		return qdrantclient.NewVectorInputDense(embedding), nil
+	}
+
+	contextInput := &qdrantclient.ContextInput{Pairs: make([]*qdrantclient.ContextInputPair, len(pairs))}
+
+	for i, p := range pairs {
+		positive, err := input(p.Positive)
+		if err != nil {
+			return nil, err
+		}
+
+		negative, err := input(p.Negative)
+		if err != nil {
+			return nil, err
+		}
+
+		contextInput.Pairs[i] = &qdrantclient.ContextInputPair{Positive: positive, Negative: negative}
+	}
+
+	query := qdrantclient.NewQueryContext(contextInput)
+
+	if target != nil {
+		t, err := input(*target)
+		if err != nil {
+			return nil, err
+		}
+
+		query = qdrantclient.NewQueryDiscover(&qdrantclient.DiscoverInput{Target: t, Context: contextInput})
+	}
+
+	filter := buildFilter(filters)
+
+	if len(examples) > 0 {
+		ids := make([]*qdrantclient.PointId, len(examples))
+		for i, id := range examples {
+			ids[i] = qdrantclient.NewIDNum(id)
+		}
+
+		if filter == nil {
+			filter = &qdrantclient.Filter{}
+		}
+
+		filter.MustNot = append(filter.MustNot, qdrantclient.NewHasID(ids...))
+	}
+
+	using := "text"
+
+	phones, err := s.query(ctx, query, &using, limit, filter)
+	if err != nil {
+		return nil, fmt.Errorf("discovering: %w", err)
+	}
+
+	return collect(phones, limit), nil
+}
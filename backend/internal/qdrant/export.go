@@ -0,0 +1,126 @@
+package qdrant
+
+import (
+	"context"
+	"iter"
+	"slices"
+
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+)
+
+// scrollPageSize is the number of points fetched per Scroll call without
+// vectors.
+const scrollPageSize = 1000
+
+// vectorPageSize is the number of points fetched per Scroll call with
+// vectors, keeping pages under the 4MB default gRPC message size: a point
+// holds about 6KB of dense vectors, and a few hundred KB of token vectors
+// with multivector set.
+func (st settings) vectorPageSize() uint32 {
+	if st.multivector {
+		return 8
+	}
+
+	return 256
+}
+
+// Point is a stored point as exported: its ID, its raw payload and,
+// optionally, its named vectors, dense ones as a list of numbers and
+// multivectors as a list of lists.
+type Point struct {
+	ID      uint64         `json:"id"`
+	Payload map[string]any `json:"payload"`
+	Vectors map[string]any `json:"vectors,omitempty"`
+}
+
+// Export yields every point in ID order with its payload as stored, and its
+// vectors when withVectors is set. Unlike All it keeps payload fields the
+// model does not read, so the output can back up the collection.
+func (s *Searcher) Export(ctx context.Context, withVectors bool) iter.Seq2[Point, error] {
+	return func(yield func(Point, error) bool) {
+		for p, err := range s.scroll(ctx, withVectors) {
+			if err != nil {
+				yield(Point{}, err)
+				return
+			}
+
+			point := Point{
+				ID:      p.GetId().GetNum(),
+				Payload: make(map[string]any, len(p.GetPayload())),
+			}
+
+			for k, v := range p.GetPayload() {
+				point.Payload[k] = payloadValue(v)
+			}
+
+			if vectors := p.GetVectors().GetVectors().GetVectors(); len(vectors) > 0 {
+				point.Vectors = make(map[string]any, len(vectors))
+				for name, v := range vectors {
+					point.Vectors[name] = vectorData(v)
+				}
+			}
+
+			if !yield(point, nil) {
+				return
+			}
+		}
+	}
+}
+
+// payloadValue converts a payload value to its plain Go form.
+func payloadValue(v *qdrantclient.Value) any {
+	switch k := v.GetKind().(type) {
+	case *qdrantclient.Value_BoolValue:
+		return k.BoolValue
+	case *qdrantclient.Value_IntegerValue:
+		return k.IntegerValue
+	case *qdrantclient.Value_DoubleValue:
+		return k.DoubleValue
+	case *qdrantclient.Value_StringValue:
+		return k.StringValue
+	case *qdrantclient.Value_ListValue:
+		list := make([]any, len(k.ListValue.GetValues()))
+		for i, item := range k.ListValue.GetValues() {
+			list[i] = payloadValue(item)
+		}
+
+		return list
+	case *qdrantclient.Value_StructValue:
+		fields := make(map[string]any, len(k.StructValue.GetFields()))
+		for name, field := range k.StructValue.GetFields() {
+			fields[name] = payloadValue(field)
+		}
+
+		return fields
+	default:
+		return nil
+	}
+}
+
+// vectorData returns a stored vector as []float32, or as [][]float32 for a
+// multivector, handling the legacy flat data of older servers.
+func vectorData(v *qdrantclient.VectorOutput) any {
+	if multi := v.GetMultiDense(); multi != nil {
+		vectors := make([][]float32, len(multi.GetVectors()))
+		for i, d := range multi.GetVectors() {
+			vectors[i] = d.GetData()
+		}
+
+		return vectors
+	}
+
+	if dense := v.GetDense(); dense != nil {
+		return dense.GetData()
+	}
+
+	if data, n := v.GetData(), int(v.GetVectorsCount()); n > 1 && len(data) >= n {
+		vectors := make([][]float32, 0, n)
+		for chunk := range slices.Chunk(data, len(data)/n) {
+			vectors = append(vectors, chunk)
+		}
+
+		return vectors
+	}
+
+	return v.GetData()
+}
@@ -0,0 +1,51 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxFacetValues bounds the values a facet returns; Qdrant returns only 10
+// unless told otherwise.
+const maxFacetValues = 10000
+
+// facetCount is the number of phones with one value of a payload field.
+type facetCount struct {
+	value string
+	count uint64
+}
+
+// facet counts the phones matching filter per value of the keyword-indexed
+// payload field key, most frequent first. Unlike scrolling the points, it is
+// answered from the payload index.
+func (s *Searcher) facet(ctx context.Context, key string, filter *qdrantclient.Filter) ([]facetCount, error) {
+	ctx, span := tracer.Start(ctx, "qdrant.Facet", trace.WithAttributes(attribute.String("qdrant.facet", key)))
+	defer span.End()
+
+	limit := uint64(maxFacetValues)
+	exact := true
+
+	hits, err := s.client.Facet(ctx, &qdrantclient.FacetCounts{
+		CollectionName: s.collectionName,
+		Key:            key,
+		Filter:         filter,
+		Limit:          &limit,
+		Exact:          &exact,
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("counting %s values: %w", key, err)
+	}
+
+	counts := make([]facetCount, len(hits))
+	for i, hit := range hits {
+		counts[i] = facetCount{value: hit.GetValue().GetStringValue(), count: hit.GetCount()}
+	}
+
+	return counts, nil
+}
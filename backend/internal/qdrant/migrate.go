@@ -0,0 +1,205 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+)
+
+// schemaVersionKey is the collection metadata key holding the payload
+// schema version.
+const schemaVersionKey = "payload_schema_version"
+
+// payloadMigration lists the payload fields a schema version derived from
+// the raw spec strings, so Migrate can recompute them on older points.
+type payloadMigration struct {
+	version     int
+	description string
+	fields      []string
+}
+
+// payloadMigrations are the payload schema versions after the first, oldest
+// first. A parser change that alters the payload adds a version here.
+var payloadMigrations = []payloadMigration{
+	{2, "parsed numeric specs", []string{
+		"battery_mah", "weight_g", "screen_inches", "ram_gb", "storage_gb",
+		"resolution_width", "resolution_height", "resolution_pixels", "camera_mp",
+	}},
+	{3, "announcement dates", []string{"announced_date", "announced_at"}},
+	{4, "camera lenses", []string{"camera_lenses"}},
+	{5, "normalized chipsets", []string{"soc_name", "soc_family", "soc_tier"}},
+	{6, "memory variants", []string{"memory_variants", "ram_min_gb", "storage_min_gb"}},
+	{7, "color lists", []string{"color_names", "color_families"}},
+	{8, "sensor lists", []string{"infrared", "sensor_list"}},
+	{9, "network bands", []string{
+		"bands_2g", "bands_3g", "bands_4g", "bands_5g",
+		"bands_2g_mhz", "bands_3g_mhz", "lte_bands", "nr_bands",
+	}},
+	{10, "SIM configurations", []string{"dual_sim", "esim", "sim_sizes"}},
+	{11, "announcement years", []string{"announced_year"}},
+	{12, "canonical brands", []string{"brand"}},
+}
+
+// PayloadSchemaVersion is the payload schema version written by the seeder.
+var PayloadSchemaVersion = payloadMigrations[len(payloadMigrations)-1].version
+
+// MigrationResult reports what Migrate did.
+type MigrationResult struct {
+	From   int
+	To     int
+	Points int
+}
+
+// Migrate upgrades the payload of every point from the collection's schema
+// version to PayloadSchemaVersion by re-running the parsers of the versions
+// in between on the raw spec strings, then records the new version. Vectors
+// are left untouched, so no re-embedding is needed. Missing payload indexes
+// are created first. With dryRun set it only counts the points to upgrade.
+// o names the collection.
+func Migrate(ctx context.Context, client *qdrantclient.Client, o Options, dryRun bool) (MigrationResult, error) {
+	st := newSettings(o)
+
+	info, err := client.GetCollectionInfo(ctx, st.collectionName)
+	if err != nil {
+		return MigrationResult{}, fmt.Errorf("getting collection info: %w", err)
+	}
+
+	result := MigrationResult{From: schemaVersion(info), To: PayloadSchemaVersion}
+
+	var fields []string
+
+	for _, m := range payloadMigrations {
+		if m.version > result.From {
+			slog.InfoContext(ctx, "pending payload migration", slog.Int("version", m.version), slog.String("description", m.description))
+			fields = append(fields, m.fields...)
+		}
+	}
+
+	if dryRun {
+		if len(fields) > 0 {
+			result.Points = int(info.GetPointsCount())
+		}
+
+		return result, nil
+	}
+
+	// Missing indexes are created even when the payloads are current, so a
+	// collection missing some, e.g. after a failed seed, can be repaired.
+	if err := createMissingIndexes(ctx, client, st.collectionName, info); err != nil {
+		return result, err
+	}
+
+	if len(fields) == 0 {
+		return result, nil
+	}
+
+	var offset *qdrantclient.PointId
+
+	scrollLimit := uint32(256)
+	wait := true
+
+	for {
+		pageCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		points, next, err := client.ScrollAndOffset(pageCtx, &qdrantclient.ScrollPoints{
+			CollectionName: st.collectionName,
+			Limit:          &scrollLimit,
+			Offset:         offset,
+			WithPayload:    qdrantclient.NewWithPayload(true),
+			WithVectors:    qdrantclient.NewWithVectors(false),
+		})
+		cancel()
+
+		if err != nil {
+			return result, fmt.Errorf("scrolling phones: %w", err)
+		}
+
+		ops := make([]*qdrantclient.PointsUpdateOperation, len(points))
+		for i, p := range points {
+			payload, err := migratePayload(p.Payload, fields)
+			if err != nil {
+				return result, fmt.Errorf("migrating point %d: %w", p.GetId().GetNum(), err)
+			}
+
+			ops[i] = qdrantclient.NewPointsUpdateOverwritePayload(&qdrantclient.PointsUpdateOperation_OverwritePayload{
+				Payload:        payload,
+				PointsSelector: qdrantclient.NewPointsSelector(p.GetId()),
+			})
+		}
+
+		if len(ops) > 0 {
+			updateCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			_, err = client.UpdateBatch(updateCtx, &qdrantclient.UpdateBatchPoints{
+				CollectionName: st.collectionName,
+				Operations:     ops,
+				Wait:           &wait,
+			})
+			cancel()
+
+			if err != nil {
+				return result, fmt.Errorf("updating payloads: %w", err)
+			}
+		}
+
+		result.Points += len(points)
+		slog.InfoContext(ctx, "migrated payloads", slog.Int("points", result.Points))
+
+		if next == nil {
+			break
+		}
+
+		offset = next
+	}
+
+	err = client.UpdateCollection(ctx, &qdrantclient.UpdateCollection{
+		CollectionName: st.collectionName,
+		Metadata:       schemaMetadata(PayloadSchemaVersion),
+	})
+	if err != nil {
+		return result, fmt.Errorf("recording schema version: %w", err)
+	}
+
+	return result, nil
+}
+
+// migratePayload returns payload with fields recomputed from the raw spec
+// strings; fields the parsers no longer produce are removed.
+func migratePayload(payload map[string]*qdrantclient.Value, fields []string) (map[string]*qdrantclient.Value, error) {
+	fresh := payloadToSmartphone(payload).PayloadMap()
+	out := maps.Clone(payload)
+
+	for _, f := range fields {
+		v, ok := fresh[f]
+		if !ok {
+			delete(out, f)
+			continue
+		}
+
+		value, err := qdrantclient.NewValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f, err)
+		}
+
+		out[f] = value
+	}
+
+	return out, nil
+}
+
+// schemaVersion returns the payload schema version recorded in the
+// collection metadata. Collections created before versioning count as 1.
+func schemaVersion(info *qdrantclient.CollectionInfo) int {
+	v, ok := info.GetConfig().GetMetadata()[schemaVersionKey]
+	if !ok {
+		return 1
+	}
+
+	return int(v.GetIntegerValue())
+}
+
+func schemaMetadata(version int) map[string]*qdrantclient.Value {
+	return map[string]*qdrantclient.Value{schemaVersionKey: qdrantclient.NewValueInt(int64(version))}
+}
@@ -0,0 +1,58 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+)
+
+// Recommend returns phones close to the average of the stored text vectors of
+// the liked phones, skipping the liked and excluded ones.
+func (s *Searcher) Recommend(ctx context.Context, liked, exclude []uint64, limit uint64, filters SearchFilters) ([]model.Smartphone, error) {
+	// Qdrant rejects examples that are not indexed, such as favorites of
+	// phones deleted since.
+	indexed, err := s.Phones(ctx, liked)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(indexed) == 0 {
+		return nil, nil
+	}
+
+	positive := make([]*qdrantclient.VectorInput, len(indexed))
+	for i, p := range indexed {
+		positive[i] = qdrantclient.NewVectorInputID(qdrantclient.NewIDNum(p.ID))
+	}
+
+	strategy := qdrantclient.RecommendStrategy_AverageVector
+
+	filter := buildFilter(filters)
+
+	if len(exclude) > 0 {
+		ids := make([]*qdrantclient.PointId, len(exclude))
+		for i, id := range exclude {
+			ids[i] = qdrantclient.NewIDNum(id)
+		}
+
+		if filter == nil {
+			filter = &qdrantclient.Filter{}
+		}
+
+		filter.MustNot = append(filter.MustNot, qdrantclient.NewHasID(ids...))
+	}
+
+	using := "text"
+
+	phones, err := s.query(ctx, qdrantclient.NewQueryRecommend(&qdrantclient.RecommendInput{
+		Positive: positive,
+		Strategy: &strategy,
+	}), &using, limit, filter)
+	if err != nil {
+		return nil, fmt.Errorf("recommending: %w", err)
+	}
+
+	return collect(phones, limit), nil
+}
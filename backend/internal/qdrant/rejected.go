@@ -0,0 +1,60 @@
+package qdrant
+
+import (
+	"log/slog"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+// RejectedPhone is a CSV row the seeder left out of the index because it
+// failed data validation.
+type RejectedPhone struct {
+	// Row is the 1-based data row of the CSV, after the header.
+	Row     int               `json:"row"`
+	Brand   string            `json:"brand"`
+	Model   string            `json:"model"`
+	Reasons []model.Rejection `json:"reasons"`
+}
+
+// Rejected returns the phones the last seed of this process rejected, or
+// nil when the collection was already seeded at startup.
+func (s *Seeder) Rejected() []RejectedPhone {
+	if s == nil {
+		return nil
+	}
+
+	if r := s.rejected.Load(); r != nil {
+		return *r
+	}
+
+	return nil
+}
+
+// validatePhones splits phones into the valid ones and the rejected ones.
+func validatePhones(phones []model.Smartphone) ([]model.Smartphone, []RejectedPhone) {
+	var (
+		valid    = make([]model.Smartphone, 0, len(phones))
+		rejected []RejectedPhone
+	)
+
+	for i, phone := range phones {
+		reasons := phone.Validate()
+		if len(reasons) == 0 {
+			valid = append(valid, phone)
+			continue
+		}
+
+		rejected = append(rejected, RejectedPhone{Row: i + 1, Brand: phone.Brand, Model: phone.Model, Reasons: reasons})
+
+		for _, r := range reasons {
+			slog.Warn("rejected phone",
+				slog.String("brand", phone.Brand),
+				slog.String("model", phone.Model),
+				slog.String("field", r.Field),
+				slog.String("reason", r.Message),
+			)
+		}
+	}
+
+	return valid, rejected
+}
@@ -0,0 +1,444 @@
+package qdrant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+)
+
+// Remedies of a schema change, from the cheapest.
+const (
+	// RemedyInPlace changes are applied to the live collection.
+	RemedyInPlace = "in-place"
+	// RemedyReindex changes are applied by copying the points into a new
+	// collection, computing the vectors that cannot be copied, and pointing
+	// the collection name at it as an alias.
+	RemedyReindex = "reindex"
+	// RemedyReseed changes need the collection deleted and seeded again.
+	RemedyReseed = "reseed"
+)
+
+// ErrReseedRequired reports schema changes ApplySchema cannot make.
+var ErrReseedRequired = errors.New("schema changes need a full reseed")
+
+// SchemaChange is a difference between the live collection and the vectors
+// and payload indexes the code expects.
+type SchemaChange struct {
+	Remedy      string
+	Description string
+
+	// index is the payload index an in-place change creates, deleting the
+	// existing one first when recreate is set.
+	index    payloadIndex
+	recreate bool
+	// vector and vectorDiff are the vector parameters an in-place change
+	// updates.
+	vector     string
+	vectorDiff *qdrantclient.VectorParamsDiff
+	// compute is the vector a reindex embeds again instead of copying.
+	compute string
+}
+
+// DiffSchema compares the collection with the expected schema. Missing or
+// mistyped payload indexes and binary quantization are fixed in place; a missing named vector, a
+// dense tokens vector, another distance or datatype need a reindex; vectors of
+// another size come from other embedding models and need a reseed. Vectors
+// and indexes the code does not use are left alone.
+func (s *Seeder) DiffSchema(ctx context.Context) ([]SchemaChange, error) {
+	info, err := s.client.GetCollectionInfo(ctx, s.collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("getting collection info: %w", err)
+	}
+
+	var changes []SchemaChange
+
+	params := info.GetConfig().GetParams().GetVectorsConfig().GetParamsMap().GetMap()
+	expected := s.expectedVectors()
+
+	for _, name := range s.vectorNames() {
+		want := expected[name]
+		got, ok := params[name]
+
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{
+				Remedy:      RemedyReindex,
+				Description: fmt.Sprintf("add the %s vector", name),
+				compute:     name,
+			})
+		case got.GetSize() != want.GetSize():
+			changes = append(changes, SchemaChange{
+				Remedy:      RemedyReseed,
+				Description: fmt.Sprintf("%s vector has %d dimensions, want %d", name, got.GetSize(), want.GetSize()),
+			})
+		case want.GetMultivectorConfig() != nil && got.GetMultivectorConfig() == nil:
+			changes = append(changes, SchemaChange{
+				Remedy:      RemedyReindex,
+				Description: fmt.Sprintf("make the %s vector a multivector", name),
+				compute:     name,
+			})
+		case got.GetDistance() != want.GetDistance():
+			changes = append(changes, SchemaChange{
+				Remedy: RemedyReindex,
+				Description: fmt.Sprintf("change the %s vector distance from %s to %s",
+					name, strings.ToLower(got.GetDistance().String()), strings.ToLower(want.GetDistance().String())),
+			})
+		case storedDatatype(got) != want.GetDatatype():
+			changes = append(changes, SchemaChange{
+				Remedy: RemedyReindex,
+				Description: fmt.Sprintf("store the %s vector as %s instead of %s",
+					name, strings.ToLower(want.GetDatatype().String()), strings.ToLower(storedDatatype(got).String())),
+			})
+		case isBinaryQuantized(got) != isBinaryQuantized(want):
+			changes = append(changes, quantizationChange(name, want))
+		}
+	}
+
+	schema := info.GetPayloadSchema()
+
+	for _, idx := range payloadIndexes {
+		want := strings.TrimPrefix(idx.fieldType.String(), "FieldType")
+
+		switch got, ok := schema[idx.field]; {
+		case !ok:
+			changes = append(changes, SchemaChange{
+				Remedy:      RemedyInPlace,
+				Description: fmt.Sprintf("create the payload index on %s (%s)", idx.field, strings.ToLower(want)),
+				index:       idx,
+			})
+		case got.GetDataType().String() != want:
+			changes = append(changes, SchemaChange{
+				Remedy: RemedyInPlace,
+				Description: fmt.Sprintf("recreate the payload index on %s as %s, was %s",
+					idx.field, strings.ToLower(want), strings.ToLower(got.GetDataType().String())),
+				index:    idx,
+				recreate: true,
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// ApplySchema makes the changes DiffSchema returned. It fails with
+// ErrReseedRequired, changing nothing, when any of them needs a reseed. A
+// reindex creates every payload index on the new collection, so it also
+// covers the in-place changes.
+func (s *Seeder) ApplySchema(ctx context.Context, changes []SchemaChange) error {
+	var reseed, compute []string
+
+	reindex := false
+
+	for _, c := range changes {
+		switch c.Remedy {
+		case RemedyReseed:
+			reseed = append(reseed, c.Description)
+		case RemedyReindex:
+			reindex = true
+
+			if c.compute != "" {
+				compute = append(compute, c.compute)
+			}
+		}
+	}
+
+	if len(reseed) > 0 {
+		return fmt.Errorf("%w: %s", ErrReseedRequired, strings.Join(reseed, "; "))
+	}
+
+	if reindex {
+		return s.reindex(ctx, compute)
+	}
+
+	var indexes []payloadIndex
+
+	vectors := map[string]*qdrantclient.VectorParamsDiff{}
+	wait := true
+
+	for _, c := range changes {
+		if c.vectorDiff != nil {
+			vectors[c.vector] = c.vectorDiff
+			continue
+		}
+
+		if c.recreate {
+			_, err := s.client.DeleteFieldIndex(ctx, &qdrantclient.DeleteFieldIndexCollection{
+				CollectionName: s.collectionName,
+				FieldName:      c.index.field,
+				Wait:           &wait,
+			})
+			if err != nil {
+				return fmt.Errorf("deleting index on %s: %w", c.index.field, err)
+			}
+		}
+
+		indexes = append(indexes, c.index)
+	}
+
+	if len(vectors) > 0 {
+		err := s.client.UpdateCollection(ctx, &qdrantclient.UpdateCollection{
+			CollectionName: s.collectionName,
+			VectorsConfig:  qdrantclient.NewVectorsConfigDiffMap(vectors),
+		})
+		if err != nil {
+			return fmt.Errorf("updating vectors: %w", err)
+		}
+	}
+
+	return createIndexes(ctx, s.client, s.collectionName, indexes)
+}
+
+// reindex copies the points of the live collection into a new one with the
+// expected vectors and payload indexes, embedding the compute vectors again,
+// then points the collection name at the new collection and deletes the old
+// one. The payload schema version carries over.
+func (s *Seeder) reindex(ctx context.Context, compute []string) error {
+	live, err := resolveCollection(ctx, s.client, s.collectionName)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.client.GetCollectionInfo(ctx, live)
+	if err != nil {
+		return fmt.Errorf("getting collection info: %w", err)
+	}
+
+	next := fmt.Sprintf("%s_%s", s.collectionName, time.Now().UTC().Format("20060102150405"))
+
+	slog.InfoContext(ctx, "reindexing collection",
+		slog.String("from", live),
+		slog.String("to", next),
+		slog.Any("compute", compute),
+	)
+
+	if err := s.client.CreateCollection(ctx, &qdrantclient.CreateCollection{
+		CollectionName: next,
+		VectorsConfig:  qdrantclient.NewVectorsConfigMap(s.expectedVectors()),
+		Metadata:       schemaMetadata(schemaVersion(info)),
+	}); err != nil {
+		return fmt.Errorf("creating collection %s: %w", next, err)
+	}
+
+	err = createIndexes(ctx, s.client, next, payloadIndexes)
+	if err == nil {
+		err = s.copyPoints(ctx, live, next, compute)
+	}
+
+	if err != nil {
+		// The live collection is untouched; drop the partial copy.
+		if dropErr := s.client.DeleteCollection(context.WithoutCancel(ctx), next); dropErr != nil {
+			slog.Warn("failed to delete partial collection", slog.String("collection", next), slog.String("error", dropErr.Error()))
+		}
+
+		return err
+	}
+
+	return s.switchAlias(ctx, live, next)
+}
+
+// copyPoints copies every point of from into to, keeping the payloads and
+// the expected vectors not listed in compute, and embedding the compute
+// ones from the payloads and the downloaded images.
+func (s *Seeder) copyPoints(ctx context.Context, from, to string, compute []string) error {
+	var offset *qdrantclient.PointId
+
+	limit := min(uint32(s.batchSize), s.vectorPageSize())
+	expected := s.expectedVectors()
+	copied := 0
+
+	for {
+		pageCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		points, next, err := s.client.ScrollAndOffset(pageCtx, &qdrantclient.ScrollPoints{
+			CollectionName: from,
+			Limit:          &limit,
+			Offset:         offset,
+			WithPayload:    qdrantclient.NewWithPayload(true),
+			WithVectors:    qdrantclient.NewWithVectors(true),
+		})
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("scrolling phones: %w", err)
+		}
+
+		var computed []map[string]*qdrantclient.Vector
+
+		if len(compute) > 0 {
+			phones := make([]model.Smartphone, len(points))
+			for i, p := range points {
+				phones[i] = payloadToSmartphone(p.GetPayload())
+			}
+
+			computed, err = s.embed(ctx, phones, compute)
+			if err != nil {
+				return err
+			}
+		}
+
+		batch := make([]*qdrantclient.PointStruct, len(points))
+
+		for i, p := range points {
+			vectors := map[string]*qdrantclient.Vector{}
+
+			for name, v := range p.GetVectors().GetVectors().GetVectors() {
+				if _, ok := expected[name]; ok && !slices.Contains(compute, name) {
+					vectors[name] = vectorInput(v)
+				}
+			}
+
+			if computed != nil {
+				for name, v := range computed[i] {
+					vectors[name] = v
+				}
+			}
+
+			batch[i] = &qdrantclient.PointStruct{
+				Id:      p.GetId(),
+				Vectors: qdrantclient.NewVectorsMap(vectors),
+				Payload: p.GetPayload(),
+			}
+		}
+
+		if len(batch) > 0 {
+			if err := s.upsert(ctx, to, batch); err != nil {
+				return fmt.Errorf("copying points: %w", err)
+			}
+		}
+
+		copied += len(points)
+		slog.InfoContext(ctx, "copied points", slog.Int("points", copied))
+
+		if next == nil {
+			return nil
+		}
+
+		offset = next
+	}
+}
+
+// isBinaryQuantized reports whether the vector p describes is binary
+// quantized.
+func isBinaryQuantized(p *qdrantclient.VectorParams) bool {
+	return p.GetQuantizationConfig().GetBinary() != nil
+}
+
+// quantizationChange returns the in-place change giving the name vector the
+// binary quantization and storage of want.
+func quantizationChange(name string, want *qdrantclient.VectorParams) SchemaChange {
+	onDisk := want.GetOnDisk()
+	diff := &qdrantclient.VectorParamsDiff{
+		QuantizationConfig: qdrantclient.NewQuantizationDiffDisabled(),
+		OnDisk:             &onDisk,
+	}
+	description := fmt.Sprintf("disable binary quantization of the %s vector", name)
+
+	if isBinaryQuantized(want) {
+		diff.QuantizationConfig = qdrantclient.NewQuantizationDiffBinary(want.GetQuantizationConfig().GetBinary())
+		description = fmt.Sprintf("enable binary quantization of the %s vector", name)
+	}
+
+	return SchemaChange{
+		Remedy:      RemedyInPlace,
+		Description: description,
+		vector:      name,
+		vectorDiff:  diff,
+	}
+}
+
+// storedDatatype returns the datatype of a vector, reading the default of
+// collections created without one as float32.
+func storedDatatype(p *qdrantclient.VectorParams) qdrantclient.Datatype {
+	if t := p.GetDatatype(); t != qdrantclient.Datatype_Default {
+		return t
+	}
+
+	return qdrantclient.Datatype_Float32
+}
+
+// vectorInput converts a stored vector back into one to upsert.
+func vectorInput(v *qdrantclient.VectorOutput) *qdrantclient.Vector {
+	data := vectorData(v)
+	if multi, ok := data.([][]float32); ok {
+		return qdrantclient.NewVectorMulti(multi)
+	}
+
+	return qdrantclient.NewVectorDense(data.([]float32))
+}
+
+// switchAlias points the collection name at next and deletes the live
+// collection it replaces. When the name still is a collection rather than
+// an alias, the collection has to be deleted before the alias can take its
+// name, and searches fail in between.
+func (s *Seeder) switchAlias(ctx context.Context, live, next string) error {
+	if live == s.collectionName {
+		slog.WarnContext(ctx, "replacing the collection with an alias; searches fail until it is created",
+			slog.String("collection", s.collectionName),
+		)
+
+		if err := s.client.DeleteCollection(ctx, live); err != nil {
+			return fmt.Errorf("deleting collection %s: %w", live, err)
+		}
+
+		if err := s.client.CreateAlias(ctx, s.collectionName, next); err != nil {
+			return fmt.Errorf("creating alias %s: %w", s.collectionName, err)
+		}
+
+		return nil
+	}
+
+	err := s.client.UpdateAliases(ctx, []*qdrantclient.AliasOperations{
+		qdrantclient.NewAliasDelete(s.collectionName),
+		qdrantclient.NewAliasCreate(s.collectionName, next),
+	})
+	if err != nil {
+		return fmt.Errorf("switching alias %s: %w", s.collectionName, err)
+	}
+
+	if err := s.client.DeleteCollection(ctx, live); err != nil {
+		return fmt.Errorf("deleting collection %s: %w", live, err)
+	}
+
+	return nil
+}
+
+// resolveCollection returns the collection name refers to: the aliased
+// one, or the name itself.
+func resolveCollection(ctx context.Context, client *qdrantclient.Client, name string) (string, error) {
+	aliases, err := client.ListAliases(ctx)
+	if err != nil {
+		return "", fmt.Errorf("listing aliases: %w", err)
+	}
+
+	for _, a := range aliases {
+		if a.GetAliasName() == name {
+			return a.GetCollectionName(), nil
+		}
+	}
+
+	return name, nil
+}
+
+// collectionExists reports whether name is a collection or an alias of one,
+// as after a reindex.
+func collectionExists(ctx context.Context, client *qdrantclient.Client, name string) (bool, error) {
+	exists, err := client.CollectionExists(ctx, name)
+	if err != nil || exists {
+		return exists, err
+	}
+
+	aliases, err := client.ListAliases(ctx)
+	if err != nil {
+		return false, fmt.Errorf("listing aliases: %w", err)
+	}
+
+	return slices.ContainsFunc(aliases, func(a *qdrantclient.AliasDescription) bool {
+		return a.GetAliasName() == name
+	}), nil
+}
@@ -4,12 +4,20 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"iter"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
 	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
 	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	"github.com/alessandrolattao/qdrant-experiment/internal/specs"
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
 	qdrantclient "github.com/qdrant/go-client/qdrant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // SearchFilters holds optional filters for narrowing search results.
@@ -19,19 +27,51 @@ type SearchFilters struct {
 	NetGen      string  // "5G", "LTE", "3G", "2G" or ""
 	OS          string  // "Android", "iOS", "Windows", "Other" or ""
 	DisplayType string  // "AMOLED", "OLED", "IPS", "TFT", "LCD", "Other" or ""
+	Lens        string  // one of specs.LensTypes or ""
+	SoCFamily   string  // one of model.SoCFamilies or ""
+	SoCTier     string  // one of model.SoCTiers or ""
+	Color       string  // one of model.ColorFamilies or ""
+	Sensor      string  // one of model.Sensors or ""
+	DualSIM     *bool   // nil = no filter
+	ESIM        *bool   // nil = no filter
+	SIMSize     string  // one of model.SIMSizes or ""
 	PriceMin    float64 // 0 = no lower bound
 	PriceMax    float64 // 0 = no upper bound
+	// LTEBands and NRBands are band numbers the phone must all support.
+	LTEBands []int
+	NRBands  []int
+	// Bounds on the parsed numeric specs; 0 = no bound.
+	BatteryMin float64
+	// RAMMin and StorageMin need some variant at least that large, RAMMax
+	// and StorageMax some variant at most that large.
+	RAMMin     float64
+	RAMMax     float64
+	StorageMin float64
+	StorageMax float64
+	ScreenMin  float64
+	ScreenMax  float64
+	WeightMax  float64
+	// AnnouncedAfter and AnnouncedBefore bound the announcement date; zero
+	// = no bound.
+	AnnouncedAfter  time.Time
+	AnnouncedBefore time.Time
+	// TextMatch requires every word of it in the model name or the
+	// description, through the full-text indexes; "" = no filter.
+	TextMatch string
 }
 
 // Searcher performs vector search in Qdrant using CLIP and MiniLM embeddings.
 type Searcher struct {
+	settings
+
 	client   *qdrantclient.Client
 	embedder *embedder.Client
 }
 
-// NewSearcher creates a new Searcher.
-func NewSearcher(client *qdrantclient.Client, embedder *embedder.Client) *Searcher {
+// NewSearcher creates a Searcher over the collection o describes.
+func NewSearcher(client *qdrantclient.Client, embedder *embedder.Client, o Options) *Searcher {
 	return &Searcher{
+		settings: newSettings(o),
 		client:   client,
 		embedder: embedder,
 	}
@@ -39,19 +79,64 @@ func NewSearcher(client *qdrantclient.Client, embedder *embedder.Client) *Search
 
 // SearchByText embeds the query with MiniLM and searches the "text" named vector.
 func (s *Searcher) SearchByText(ctx context.Context, query string, limit uint64, filters SearchFilters) ([]model.Smartphone, error) {
+	phones, err := s.StreamByText(ctx, query, limit, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return collect(phones, limit), nil
+}
+
+// SearchByImage embeds the image with CLIP and searches the "image" named vector.
+func (s *Searcher) SearchByImage(ctx context.Context, imageData io.Reader, filename string, limit uint64, filters SearchFilters) ([]model.Smartphone, error) {
+	phones, err := s.StreamByImage(ctx, imageData, filename, limit, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return collect(phones, limit), nil
+}
+
+// StreamByText is like SearchByText but yields each result as it is mapped
+// from its Qdrant point, so callers can write results incrementally.
+func (s *Searcher) StreamByText(ctx context.Context, query string, limit uint64, filters SearchFilters) (iter.Seq[model.Smartphone], error) {
+	start := time.Now()
 	embedding, err := s.embedder.EmbedText(ctx, query)
+	recordEmbed(ctx, start)
+
 	if err != nil {
 		return nil, fmt.Errorf("embedding text: %w", err)
 	}
 
-	using := "text"
+	var tokens [][]float32
 
-	return s.searchByVector(ctx, embedding, &using, limit, filters)
+	if s.multivector {
+		start := time.Now()
+		tokens, err = s.embedder.EmbedTextTokens(ctx, query)
+		recordEmbed(ctx, start)
+
+		if err != nil {
+			return nil, fmt.Errorf("embedding text tokens: %w", err)
+		}
+	}
+
+	filter := buildFilter(filters)
+	ranking := s.textRanking(embedding, tokens, filter, limit)
+
+	// Euclidean scores are distances, which a boost added to would demote.
+	if s.modelMatchBoost > 0 && s.distance != qdrantclient.Distance_Euclid {
+		return s.query(ctx, s.modelBoostFormula(query), nil, limit, filter, ranking, modelMatches(embedding, query, filter, limit))
+	}
+
+	return s.query(ctx, ranking.GetQuery(), ranking.Using, limit, filter, ranking.GetPrefetch()...)
 }
 
-// SearchByImage embeds the image with CLIP and searches the "image" named vector.
-func (s *Searcher) SearchByImage(ctx context.Context, imageData io.Reader, filename string, limit uint64, filters SearchFilters) ([]model.Smartphone, error) {
+// StreamByImage is like SearchByImage but yields results incrementally.
+func (s *Searcher) StreamByImage(ctx context.Context, imageData io.Reader, filename string, limit uint64, filters SearchFilters) (iter.Seq[model.Smartphone], error) {
+	start := time.Now()
 	embedding, err := s.embedder.EmbedImage(ctx, imageData, filename)
+	recordEmbed(ctx, start)
+
 	if err != nil {
 		return nil, fmt.Errorf("embedding image: %w", err)
 	}
@@ -61,79 +146,442 @@ func (s *Searcher) SearchByImage(ctx context.Context, imageData io.Reader, filen
 	return s.searchByVector(ctx, embedding, &using, limit, filters)
 }
 
-// AvailableBrands returns all unique brand values from the collection.
+// Phones fetches phones by ID in the given order, skipping IDs that are no
+// longer indexed.
+func (s *Searcher) Phones(ctx context.Context, ids []uint64) ([]model.Smartphone, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "qdrant.Get", trace.WithAttributes(attribute.Int("qdrant.ids", len(ids))))
+	defer span.End()
+
+	pointIDs := make([]*qdrantclient.PointId, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = qdrantclient.NewIDNum(id)
+	}
+
+	points, err := s.client.Get(ctx, &qdrantclient.GetPoints{
+		CollectionName: s.collectionName,
+		Ids:            pointIDs,
+		WithPayload:    qdrantclient.NewWithPayload(true),
+		WithVectors:    qdrantclient.NewWithVectors(false),
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("getting points: %w", err)
+	}
+
+	byID := make(map[uint64]model.Smartphone, len(points))
+
+	for _, p := range points {
+		phone := payloadToSmartphone(p.Payload)
+		phone.ID = p.GetId().GetNum()
+		byID[phone.ID] = phone
+	}
+
+	phones := make([]model.Smartphone, 0, len(points))
+
+	for _, id := range ids {
+		if phone, ok := byID[id]; ok {
+			phones = append(phones, phone)
+		}
+	}
+
+	return phones, nil
+}
+
+// PhoneBySlug returns the phone with slug, or false if none is indexed.
+func (s *Searcher) PhoneBySlug(ctx context.Context, slug string) (model.Smartphone, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "qdrant.ScrollSlug")
+	defer span.End()
+
+	limit := uint32(1)
+
+	points, err := s.client.Scroll(ctx, &qdrantclient.ScrollPoints{
+		CollectionName: s.collectionName,
+		Filter:         &qdrantclient.Filter{Must: []*qdrantclient.Condition{qdrantclient.NewMatch("slug", slug)}},
+		Limit:          &limit,
+		WithPayload:    qdrantclient.NewWithPayload(true),
+		WithVectors:    qdrantclient.NewWithVectors(false),
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return model.Smartphone{}, false, fmt.Errorf("looking up slug %s: %w", slug, err)
+	}
+
+	if len(points) == 0 {
+		return model.Smartphone{}, false, nil
+	}
+
+	phone := payloadToSmartphone(points[0].Payload)
+	phone.ID = points[0].GetId().GetNum()
+
+	return phone, true, nil
+}
+
+// AvailableBrands returns the brands of the indexed phones, counted from
+// the brand payload index.
 func (s *Searcher) AvailableBrands(ctx context.Context) ([]string, error) {
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	brands := map[string]struct{}{}
-	var offset *qdrantclient.PointId
+	counts, err := s.facet(ctx, "brand", nil)
+	if err != nil {
+		return nil, err
+	}
 
-	scrollLimit := uint32(1000)
+	brands := make([]string, 0, len(counts))
+	for _, c := range counts {
+		if c.value != "" {
+			brands = append(brands, c.value)
+		}
+	}
 
-	for {
-		points, err := s.client.Scroll(ctx, &qdrantclient.ScrollPoints{
-			CollectionName:  collectionName,
-			Limit:           &scrollLimit,
-			Offset:          offset,
-			WithPayload:     qdrantclient.NewWithPayloadInclude("brand"),
-			WithVectors:     qdrantclient.NewWithVectors(false),
-		})
-		if err != nil {
-			return nil, fmt.Errorf("scrolling brands: %w", err)
+	return brands, nil
+}
+
+// All yields every indexed phone in ID order, without vectors.
+func (s *Searcher) All(ctx context.Context) iter.Seq2[model.Smartphone, error] {
+	return func(yield func(model.Smartphone, error) bool) {
+		for p, err := range s.scroll(ctx, false) {
+			if err != nil {
+				yield(model.Smartphone{}, err)
+				return
+			}
+
+			phone := payloadToSmartphone(p.Payload)
+			phone.ID = p.GetId().GetNum()
+
+			if !yield(phone, nil) {
+				return
+			}
 		}
+	}
+}
+
+// scroll yields every point in ID order with its payload, and its vectors
+// when withVectors is set, a page at a time.
+func (s *Searcher) scroll(ctx context.Context, withVectors bool) iter.Seq2[*qdrantclient.RetrievedPoint, error] {
+	return func(yield func(*qdrantclient.RetrievedPoint, error) bool) {
+		var offset *qdrantclient.PointId
+
+		scrollLimit := uint32(scrollPageSize)
+		if withVectors {
+			scrollLimit = s.vectorPageSize()
+		}
+
+		for {
+			pageCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			points, next, err := s.client.ScrollAndOffset(pageCtx, &qdrantclient.ScrollPoints{
+				CollectionName: s.collectionName,
+				Limit:          &scrollLimit,
+				Offset:         offset,
+				WithPayload:    qdrantclient.NewWithPayload(true),
+				WithVectors:    qdrantclient.NewWithVectors(withVectors),
+			})
+			cancel()
+
+			if err != nil {
+				yield(nil, fmt.Errorf("scrolling phones: %w", err))
+				return
+			}
 
-		for _, p := range points {
-			if b := payloadString(p.Payload, "brand"); b != "" {
-				brands[b] = struct{}{}
+			for _, p := range points {
+				if !yield(p, nil) {
+					return
+				}
 			}
+
+			if next == nil {
+				return
+			}
+
+			offset = next
 		}
+	}
+}
+
+// Matching returns up to limit phones matching filters, in ID order, for
+// lookups that have no query to rank by.
+func (s *Searcher) Matching(ctx context.Context, filters SearchFilters, limit uint64) ([]model.Smartphone, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	scrollLimit := uint32(limit)
+
+	points, err := s.client.Scroll(ctx, &qdrantclient.ScrollPoints{
+		CollectionName: s.collectionName,
+		Filter:         buildFilter(filters),
+		Limit:          &scrollLimit,
+		WithPayload:    qdrantclient.NewWithPayload(true),
+		WithVectors:    qdrantclient.NewWithVectors(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scrolling phones: %w", err)
+	}
+
+	phones := make([]model.Smartphone, len(points))
+
+	for i, p := range points {
+		phones[i] = payloadToSmartphone(p.Payload)
+		phones[i].ID = p.GetId().GetNum()
+	}
+
+	return phones, nil
+}
+
+// Count returns the exact number of phones matching filters.
+func (s *Searcher) Count(ctx context.Context, filters SearchFilters) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "qdrant.Count")
+	defer span.End()
+
+	exact := true
+
+	n, err := s.client.Count(ctx, &qdrantclient.CountPoints{
+		CollectionName: s.collectionName,
+		Filter:         buildFilter(filters),
+		Exact:          &exact,
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("counting points: %w", err)
+	}
+
+	return n, nil
+}
+
+// Random returns up to limit phones matching filters, sampled at random by
+// Qdrant, for discovery features that have no query to rank by.
+func (s *Searcher) Random(ctx context.Context, limit uint64, filters SearchFilters) ([]model.Smartphone, error) {
+	phones, err := s.query(ctx, qdrantclient.NewQuerySample(qdrantclient.Sample_Random), nil, limit, buildFilter(filters))
+	if err != nil {
+		return nil, err
+	}
+
+	return collect(phones, limit), nil
+}
+
+func (s *Searcher) searchByVector(ctx context.Context, vector []float32, using *string, limit uint64, filters SearchFilters) (iter.Seq[model.Smartphone], error) {
+	return s.query(ctx, qdrantclient.NewQuery(vector...), using, limit, buildFilter(filters))
+}
+
+// searchParams returns the parameters of a search by the using vector:
+// oversampling and rescoring when it is binary quantized, nil otherwise.
+func (st settings) searchParams(using string) *qdrantclient.SearchParams {
+	if !slices.Contains(st.binaryQuantized, using) {
+		return nil
+	}
+
+	rescore := true
+
+	return &qdrantclient.SearchParams{
+		Quantization: &qdrantclient.QuantizationSearchParams{
+			Rescore:      &rescore,
+			Oversampling: &st.oversampling,
+		},
+	}
+}
+
+// withRescoring sets the searchParams of each prefetch and of the
+// prefetches nested in it.
+func (st settings) withRescoring(prefetch []*qdrantclient.PrefetchQuery) {
+	for _, p := range prefetch {
+		p.Params = st.searchParams(p.GetUsing())
+		st.withRescoring(p.GetPrefetch())
+	}
+}
+
+// textRanking returns the query ranking the phones for a text query
+// embedded as dense and, with multivector set, as tokens: textCandidates,
+// reranked by MaxSim between the query tokens and the "tokens" multivector
+// of each description when tokens are given.
+func (st settings) textRanking(dense []float32, tokens [][]float32, filter *qdrantclient.Filter, limit uint64) *qdrantclient.PrefetchQuery {
+	if tokens == nil {
+		return st.textCandidates(dense, filter, limit)
+	}
+
+	using := "tokens"
+
+	return &qdrantclient.PrefetchQuery{
+		Prefetch: []*qdrantclient.PrefetchQuery{st.textCandidates(dense, filter, limit*tokenCandidates)},
+		Query:    qdrantclient.NewQueryMulti(tokens),
+		Using:    &using,
+		Filter:   filter,
+		Limit:    &limit,
+	}
+}
+
+// modelMatches retrieves by the text vector up to limit phones whose model
+// name contains the query as a phrase, so an exact model name lookup finds
+// the phone even when its description ranks low.
+func modelMatches(dense []float32, query string, filter *qdrantclient.Filter, limit uint64) *qdrantclient.PrefetchQuery {
+	text := "text"
+	conditions := []*qdrantclient.Condition{qdrantclient.NewMatchPhrase("model", query)}
 
-		if len(points) < 1000 {
-			break
+	if filter != nil {
+		conditions = append(conditions, qdrantclient.NewFilterAsCondition(filter))
+	}
+
+	return &qdrantclient.PrefetchQuery{
+		Query:  qdrantclient.NewQueryDense(dense),
+		Using:  &text,
+		Filter: &qdrantclient.Filter{Must: conditions},
+		Limit:  &limit,
+	}
+}
+
+// modelBoostFormula scores the candidates of textRanking and modelMatches
+// by their textRanking score plus modelMatchBoost when their model name
+// contains the query as a phrase. A model match textRanking did not
+// retrieve scores the boost alone.
+func (st settings) modelBoostFormula(query string) *qdrantclient.Query {
+	return qdrantclient.NewQueryFormula(&qdrantclient.Formula{
+		Expression: qdrantclient.NewExpressionSum(&qdrantclient.SumExpression{
+			Sum: []*qdrantclient.Expression{
+				qdrantclient.NewExpressionVariable("$score[0]"),
+				qdrantclient.NewExpressionMult(&qdrantclient.MultExpression{
+					Mult: []*qdrantclient.Expression{
+						qdrantclient.NewExpressionConstant(float32(st.modelMatchBoost)),
+						qdrantclient.NewExpressionCondition(qdrantclient.NewMatchPhrase("model", query)),
+					},
+				}),
+			},
+		}),
+		Defaults: map[string]*qdrantclient.Value{
+			"$score[0]": qdrantclient.NewValueDouble(0),
+		},
+	})
+}
+
+// textCandidates returns the prefetch retrieving limit candidates for a text
+// query embedded as dense: by the text vector, or by the text and specs
+// vectors combined by hybridFormula when specsVector is set.
+func (st settings) textCandidates(dense []float32, filter *qdrantclient.Filter, limit uint64) *qdrantclient.PrefetchQuery {
+	if !st.specsVector {
+		text := "text"
+
+		return &qdrantclient.PrefetchQuery{
+			Query:  qdrantclient.NewQueryDense(dense),
+			Using:  &text,
+			Filter: filter,
+			Limit:  &limit,
 		}
+	}
 
-		offset = points[len(points)-1].Id
+	return &qdrantclient.PrefetchQuery{
+		Prefetch: hybridPrefetch(dense, filter, limit*hybridCandidates),
+		Query:    st.hybridFormula(),
+		Filter:   filter,
+		Limit:    &limit,
 	}
+}
 
-	result := make([]string, 0, len(brands))
-	for b := range brands {
-		result = append(result, b)
+// hybridPrefetch retrieves limit candidates by the text vector, then limit
+// by the specs vector.
+func hybridPrefetch(dense []float32, filter *qdrantclient.Filter, limit uint64) []*qdrantclient.PrefetchQuery {
+	text, specs := "text", "specs"
+
+	return []*qdrantclient.PrefetchQuery{
+		{Query: qdrantclient.NewQueryDense(dense), Using: &text, Filter: filter, Limit: &limit},
+		{Query: qdrantclient.NewQueryDense(dense), Using: &specs, Filter: filter, Limit: &limit},
 	}
+}
 
-	return result, nil
+// hybridFormula scores the candidates of hybridPrefetch by their specs
+// score weighted by specsWeight plus their text score weighted by the rest.
+// A candidate retrieved by one vector only scores 0 for the other.
+func (st settings) hybridFormula() *qdrantclient.Query {
+	weighted := func(variable string, weight float64) *qdrantclient.Expression {
+		return qdrantclient.NewExpressionMult(&qdrantclient.MultExpression{
+			Mult: []*qdrantclient.Expression{
+				qdrantclient.NewExpressionConstant(float32(weight)),
+				qdrantclient.NewExpressionVariable(variable),
+			},
+		})
+	}
+
+	return qdrantclient.NewQueryFormula(&qdrantclient.Formula{
+		Expression: qdrantclient.NewExpressionSum(&qdrantclient.SumExpression{
+			Sum: []*qdrantclient.Expression{
+				weighted("$score[0]", 1-st.specsWeight),
+				weighted("$score[1]", st.specsWeight),
+			},
+		}),
+		Defaults: map[string]*qdrantclient.Value{
+			"$score[0]": qdrantclient.NewValueDouble(0),
+			"$score[1]": qdrantclient.NewValueDouble(0),
+		},
+	})
 }
 
-func (s *Searcher) searchByVector(ctx context.Context, vector []float32, using *string, limit uint64, filters SearchFilters) ([]model.Smartphone, error) {
+// query runs q against the using named vector, over the results of
+// prefetch if any, and yields the mapped results.
+func (s *Searcher) query(ctx context.Context, q *qdrantclient.Query, using *string, limit uint64, filter *qdrantclient.Filter, prefetch ...*qdrantclient.PrefetchQuery) (iter.Seq[model.Smartphone], error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	qp := &qdrantclient.QueryPoints{
-		CollectionName: collectionName,
-		Query:          qdrantclient.NewQuery(vector...),
+		CollectionName: s.collectionName,
+		Prefetch:       prefetch,
+		Query:          q,
 		Using:          using,
 		Limit:          &limit,
 		WithPayload:    qdrantclient.NewWithPayload(true),
+		Filter:         filter,
 	}
 
-	if f := buildFilter(filters); f != nil {
-		qp.Filter = f
-	}
+	qp.Params = s.searchParams(qp.GetUsing())
+	s.withRescoring(prefetch)
 
+	ctx, span := tracer.Start(ctx, "qdrant.Query", trace.WithAttributes(
+		attribute.String("qdrant.collection", s.collectionName),
+		attribute.String("qdrant.using", qp.GetUsing()),
+		attribute.Int64("qdrant.limit", int64(limit)),
+	))
+	start := time.Now()
 	results, err := s.client.Query(ctx, qp)
+	recordQdrant(ctx, start)
+	tracing.RecordError(span, err)
+	span.End()
+
 	if err != nil {
 		return nil, fmt.Errorf("querying qdrant: %w", err)
 	}
 
-	phones := make([]model.Smartphone, 0, len(results))
+	// Euclidean nearest and recommend scores are distances; they are turned
+	// into similarities so a higher score is a better match for every
+	// distance, as reranking and clients expect.
+	distances := s.distance == qdrantclient.Distance_Euclid && (q.GetNearest() != nil || q.GetRecommend() != nil)
 
-	for _, point := range results {
-		phone := payloadToSmartphone(point.Payload)
-		phone.Score = point.Score
-		phones = append(phones, phone)
-	}
+	return func(yield func(model.Smartphone) bool) {
+		for _, point := range results {
+			phone := payloadToSmartphone(point.Payload)
+			phone.ID = point.GetId().GetNum()
+			phone.Score = point.Score
 
-	return phones, nil
+			if distances {
+				phone.Score = 1 / (1 + point.Score)
+			}
+
+			if !yield(phone) {
+				return
+			}
+		}
+	}, nil
+}
+
+func collect(phones iter.Seq[model.Smartphone], limit uint64) []model.Smartphone {
+	return slices.AppendSeq(make([]model.Smartphone, 0, limit), phones)
 }
 
 func buildFilter(filters SearchFilters) *qdrantclient.Filter {
@@ -163,6 +611,46 @@ func buildFilter(filters SearchFilters) *qdrantclient.Filter {
 		conditions = append(conditions, qdrantclient.NewMatch("display_type", filters.DisplayType))
 	}
 
+	if filters.SoCFamily != "" {
+		conditions = append(conditions, qdrantclient.NewMatch("soc_family", filters.SoCFamily))
+	}
+
+	if filters.SoCTier != "" {
+		conditions = append(conditions, qdrantclient.NewMatch("soc_tier", filters.SoCTier))
+	}
+
+	if filters.Color != "" {
+		conditions = append(conditions, qdrantclient.NewMatch("color_families", filters.Color))
+	}
+
+	if filters.Sensor != "" {
+		conditions = append(conditions, qdrantclient.NewMatch("sensor_list", filters.Sensor))
+	}
+
+	if filters.DualSIM != nil {
+		conditions = append(conditions, qdrantclient.NewMatchBool("dual_sim", *filters.DualSIM))
+	}
+
+	if filters.ESIM != nil {
+		conditions = append(conditions, qdrantclient.NewMatchBool("esim", *filters.ESIM))
+	}
+
+	if filters.SIMSize != "" {
+		conditions = append(conditions, qdrantclient.NewMatch("sim_sizes", filters.SIMSize))
+	}
+
+	for _, b := range filters.LTEBands {
+		conditions = append(conditions, qdrantclient.NewMatchInt("lte_bands", int64(b)))
+	}
+
+	for _, b := range filters.NRBands {
+		conditions = append(conditions, qdrantclient.NewMatchInt("nr_bands", int64(b)))
+	}
+
+	if filters.Lens != "" {
+		conditions = append(conditions, qdrantclient.NewMatch("camera_lenses[].type", filters.Lens))
+	}
+
 	if filters.PriceMin > 0 || filters.PriceMax > 0 {
 		r := &qdrantclient.Range{}
 		if filters.PriceMin > 0 {
@@ -174,6 +662,36 @@ func buildFilter(filters SearchFilters) *qdrantclient.Filter {
 		conditions = append(conditions, qdrantclient.NewRange("price_eur", r))
 	}
 
+	conditions = appendRange(conditions, "battery_mah", filters.BatteryMin, 0)
+	conditions = appendRange(conditions, "ram_gb", filters.RAMMin, 0)
+	conditions = appendRange(conditions, "ram_min_gb", 0, filters.RAMMax)
+	conditions = appendRange(conditions, "storage_gb", filters.StorageMin, 0)
+	conditions = appendRange(conditions, "storage_min_gb", 0, filters.StorageMax)
+	conditions = appendRange(conditions, "screen_inches", filters.ScreenMin, filters.ScreenMax)
+	conditions = appendRange(conditions, "weight_g", 0, filters.WeightMax)
+
+	if !filters.AnnouncedAfter.IsZero() || !filters.AnnouncedBefore.IsZero() {
+		r := &qdrantclient.DatetimeRange{}
+		if !filters.AnnouncedAfter.IsZero() {
+			r.Gte = timestamppb.New(filters.AnnouncedAfter)
+		}
+
+		if !filters.AnnouncedBefore.IsZero() {
+			r.Lt = timestamppb.New(filters.AnnouncedBefore)
+		}
+
+		conditions = append(conditions, qdrantclient.NewDatetimeRange("announced_date", r))
+	}
+
+	if filters.TextMatch != "" {
+		conditions = append(conditions, qdrantclient.NewFilterAsCondition(&qdrantclient.Filter{
+			Should: []*qdrantclient.Condition{
+				qdrantclient.NewMatchText("model", filters.TextMatch),
+				qdrantclient.NewMatchText("description", filters.TextMatch),
+			},
+		}))
+	}
+
 	if len(conditions) == 0 {
 		return nil
 	}
@@ -181,6 +699,27 @@ func buildFilter(filters SearchFilters) *qdrantclient.Filter {
 	return &qdrantclient.Filter{Must: conditions}
 }
 
+// appendRange appends a range condition on a numeric spec field when either
+// bound is set. Upper bounds also exclude unknown values, stored as zero.
+func appendRange(conditions []*qdrantclient.Condition, field string, lo, hi float64) []*qdrantclient.Condition {
+	if lo <= 0 && hi <= 0 {
+		return conditions
+	}
+
+	r := &qdrantclient.Range{}
+	if lo > 0 {
+		r.Gte = &lo
+	}
+
+	if hi > 0 {
+		gt := 0.0
+		r.Lte = &hi
+		r.Gt = &gt
+	}
+
+	return append(conditions, qdrantclient.NewRange(field, r))
+}
+
 func matchPrefix(field, prefix string) *qdrantclient.Condition {
 	return &qdrantclient.Condition{
 		ConditionOneOf: &qdrantclient.Condition_Field{
@@ -211,14 +750,69 @@ func matchContains(field, substring string) *qdrantclient.Condition {
 	}
 }
 
-
 func payloadToSmartphone(payload map[string]*qdrantclient.Value) model.Smartphone {
-	return model.Smartphone{
+	var price *model.Money
+	if eur := payload["price_eur"].GetDoubleValue(); eur > 0 {
+		price = &model.Money{Amount: eur, Currency: currency.EUR}
+	}
+
+	size := model.NewMeasurements(
+		payload["weight_g"].GetDoubleValue(),
+		payload["height_mm"].GetDoubleValue(),
+		payload["width_mm"].GetDoubleValue(),
+		payload["depth_mm"].GetDoubleValue(),
+	)
+	if size == nil {
+		// Points indexed before the numeric fields existed.
+		size = model.ParseMeasurements(payloadString(payload, "weight"), payloadString(payload, "dimensions"))
+	}
+
+	parsed := specs.Specs{
+		BatteryMAh:       payload["battery_mah"].GetDoubleValue(),
+		WeightG:          payload["weight_g"].GetDoubleValue(),
+		ScreenInches:     payload["screen_inches"].GetDoubleValue(),
+		RAMGB:            payload["ram_gb"].GetDoubleValue(),
+		StorageGB:        payload["storage_gb"].GetDoubleValue(),
+		RAMMinGB:         payload["ram_min_gb"].GetDoubleValue(),
+		StorageMinGB:     payload["storage_min_gb"].GetDoubleValue(),
+		ResolutionWidth:  int(payload["resolution_width"].GetIntegerValue()),
+		ResolutionHeight: int(payload["resolution_height"].GetIntegerValue()),
+		ResolutionPixels: int(payload["resolution_pixels"].GetIntegerValue()),
+		CameraMP:         payload["camera_mp"].GetDoubleValue(),
+	}
+
+	if announced, err := time.Parse(time.RFC3339, payloadString(payload, "announced_date")); err == nil {
+		parsed.Announced = announced
+	}
+
+	for _, v := range payload["memory_variants"].GetListValue().GetValues() {
+		variant := v.GetStructValue().GetFields()
+		parsed.MemoryVariants = append(parsed.MemoryVariants, specs.MemoryVariant{
+			StorageGB: variant["storage_gb"].GetDoubleValue(),
+			RAMGB:     variant["ram_gb"].GetDoubleValue(),
+		})
+	}
+
+	for _, v := range payload["camera_lenses"].GetListValue().GetValues() {
+		lens := v.GetStructValue().GetFields()
+		parsed.Lenses = append(parsed.Lenses, specs.Lens{
+			MP:       lens["mp"].GetDoubleValue(),
+			Type:     payloadString(lens, "type"),
+			Aperture: lens["aperture"].GetDoubleValue(),
+		})
+	}
+
+	phone := model.Smartphone{
 		Brand:      payloadString(payload, "brand"),
 		Model:      payloadString(payload, "model"),
+		Slug:       payloadString(payload, "slug"),
 		ImageURL:   payloadString(payload, "image_url"),
 		ImageFile:  payloadString(payload, "image_file"),
 		Technology: payloadString(payload, "technology"),
+		Bands2G:    payloadString(payload, "bands_2g"),
+		Bands3G:    payloadString(payload, "bands_3g"),
+		Bands4G:    payloadString(payload, "bands_4g"),
+		Bands5G:    payloadString(payload, "bands_5g"),
 		Announced:  payloadString(payload, "announced"),
 		Status:     payloadString(payload, "status"),
 		Dimensions: payloadString(payload, "dimensions"),
@@ -245,9 +839,78 @@ func payloadToSmartphone(payload map[string]*qdrantclient.Value) model.Smartphon
 		NFC:        payloadString(payload, "nfc"),
 		USB:        payloadString(payload, "usb"),
 		Sensors:    payloadString(payload, "sensors"),
+		Infrared:   payloadString(payload, "infrared"),
 		Colors:     payloadString(payload, "colors"),
 		Price:      payloadString(payload, "price"),
+
+		PriceNormalized: price,
+		Measurements:    size,
+	}
+
+	if family := payloadString(payload, "soc_family"); family != "" {
+		phone.SoC = &model.SoC{
+			Name:   payloadString(payload, "soc_name"),
+			Family: family,
+			Tier:   payloadString(payload, "soc_tier"),
+		}
+	} else if soc, ok := model.NormalizeChipset(phone.Chipset); ok {
+		// Points indexed before chipsets were normalized.
+		phone.SoC = &soc
+	}
+
+	if dual, ok := payload["dual_sim"]; ok {
+		phone.SIMConfig = &model.SIMConfig{
+			DualSIM: dual.GetBoolValue(),
+			ESIM:    payload["esim"].GetBoolValue(),
+			Sizes:   payloadStrings(payload, "sim_sizes"),
+		}
+	} else if sim, ok := model.ParseSIM(phone.SIM); ok {
+		// Points indexed before SIM configurations were classified.
+		phone.SIMConfig = &sim
+	}
+
+	if _, ok := payload["lte_bands"]; ok {
+		phone.Bands = &model.Bands{
+			MHz2G: payloadInts(payload, "bands_2g_mhz"),
+			MHz3G: payloadInts(payload, "bands_3g_mhz"),
+			LTE:   payloadInts(payload, "lte_bands"),
+			NR:    payloadInts(payload, "nr_bands"),
+		}
+	} else {
+		// Points indexed before the bands were parsed.
+		phone.Bands = model.ParseBands(phone.Bands2G, phone.Bands3G, phone.Bands4G, phone.Bands5G)
+	}
+
+	if parsed.IsZero() {
+		// Points indexed before the numeric specs existed.
+		parsed = phone.ParseSpecs()
 	}
+
+	if !parsed.IsZero() {
+		phone.SpecsParsed = &parsed
+	}
+
+	return phone
+}
+
+// payloadStrings returns the string list stored under key, or nil.
+func payloadStrings(payload map[string]*qdrantclient.Value, key string) []string {
+	var out []string
+	for _, v := range payload[key].GetListValue().GetValues() {
+		out = append(out, v.GetStringValue())
+	}
+
+	return out
+}
+
+// payloadInts returns the integer list stored under key, or nil.
+func payloadInts(payload map[string]*qdrantclient.Value, key string) []int {
+	var out []int
+	for _, v := range payload[key].GetListValue().GetValues() {
+		out = append(out, int(v.GetIntegerValue()))
+	}
+
+	return out
 }
 
 func payloadString(payload map[string]*qdrantclient.Value, key string) string {
@@ -2,79 +2,262 @@ package qdrant
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alessandrolattao/qdrant-experiment/internal/csvparser"
+	"github.com/alessandrolattao/qdrant-experiment/internal/downloader"
 	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
 	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
 	qdrantclient "github.com/qdrant/go-client/qdrant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	collectionName = "smartphones"
-	batchSize      = 64
-	imageVectorSize = 512 // CLIP ViT-B/32
+	imageVectorSize = 512  // CLIP ViT-B/32
 	textVectorSize  = 1024 // BAAI/bge-m3
-	downloadConcurrency = 10
 )
 
+// Retry delays while waiting for Qdrant at startup.
+const (
+	minConnectBackoff = time.Second
+	maxConnectBackoff = 30 * time.Second
+)
+
+// errNotConnected is the connection error before the first attempt.
+var errNotConnected = errors.New("connecting to qdrant")
+
+// distances and datatypes map the names Options accepts to the vector
+// parameters of new collections.
+var (
+	distances = map[string]qdrantclient.Distance{
+		"cosine": qdrantclient.Distance_Cosine,
+		"dot":    qdrantclient.Distance_Dot,
+		"euclid": qdrantclient.Distance_Euclid,
+	}
+	datatypes = map[string]qdrantclient.Datatype{
+		"float32": qdrantclient.Datatype_Float32,
+		"float16": qdrantclient.Datatype_Float16,
+		"uint8":   qdrantclient.Datatype_Uint8,
+	}
+)
+
+// tokenCandidates is how many times the requested results are retrieved by
+// the dense text vector for reranking by the token vectors.
+const tokenCandidates = 4
+
+// hybridCandidates is how many times the requested results are retrieved by
+// each of the text and specs vectors for the weighted combination.
+const hybridCandidates = 2
+
+// Options tunes the collection of a Searcher or Seeder and its seeding;
+// zero fields keep the defaults. A Searcher and a Seeder sharing a
+// collection need the same Options.
+type Options struct {
+	Collection string
+	BatchSize  int
+	// DownloadConcurrency, DownloadUserAgent, DownloadHostInterval and
+	// DownloadBytesPerSecond set up the image downloader; see
+	// downloader.Options.
+	DownloadConcurrency    int
+	DownloadUserAgent      string
+	DownloadHostInterval   time.Duration
+	DownloadBytesPerSecond int64
+	// Multivector stores the token-level embeddings of each description as
+	// a third named vector, "tokens", and reranks text searches by MaxSim
+	// over it. Existing collections gain the vector through a reindex by
+	// ApplySchema.
+	Multivector bool
+	// SpecsVector stores the embedding of the facts-only SpecsText of each
+	// phone as the "specs" vector, and text searches combine the text and
+	// specs scores, the specs score weighted by SpecsWeight between 0 and 1
+	// and the text score by the rest. Zero SpecsWeight keeps 0.5.
+	SpecsVector bool
+	SpecsWeight float64
+	// ModelMatchBoost is added to the score of the phones whose model name
+	// contains a text query as a phrase, "Redmi Note 12 Pro" ranking the
+	// phones of that name first; zero disables the boost.
+	ModelMatchBoost float64
+	// BinaryQuantization names the vectors stored binary quantized in RAM,
+	// their originals moving to disk, for about 30 times less memory.
+	// Searches over them retrieve Oversampling times the requested results
+	// by the quantized vectors and rescore them by the originals. Zero
+	// Oversampling keeps 3.
+	BinaryQuantization []string
+	Oversampling       float64
+	// Distance is the metric of every vector: cosine (the default), dot or
+	// euclid. ImageDatatype and TextDatatype store the image vectors and
+	// the text and token vectors as float32 (the default), float16 or
+	// uint8.
+	// Existing collections keep their parameters until ApplySchema
+	// reindexes them.
+	Distance      string
+	ImageDatatype string
+	TextDatatype  string
+}
+
+// settings are the Options of a Searcher or Seeder with the defaults
+// applied.
+type settings struct {
+	collectionName  string
+	batchSize       int
+	multivector     bool
+	specsVector     bool
+	specsWeight     float64
+	binaryQuantized []string
+	modelMatchBoost float64
+	oversampling    float64
+	distance        qdrantclient.Distance
+	imageDatatype   qdrantclient.Datatype
+	textDatatype    qdrantclient.Datatype
+}
+
+// newSettings applies the defaults to the zero fields of o.
+func newSettings(o Options) settings {
+	st := settings{
+		collectionName:  "smartphones",
+		batchSize:       64,
+		multivector:     o.Multivector,
+		specsVector:     o.SpecsVector,
+		specsWeight:     0.5,
+		binaryQuantized: o.BinaryQuantization,
+		modelMatchBoost: o.ModelMatchBoost,
+		oversampling:    3,
+		distance:        qdrantclient.Distance_Cosine,
+		imageDatatype:   qdrantclient.Datatype_Float32,
+		textDatatype:    qdrantclient.Datatype_Float32,
+	}
+
+	if o.Collection != "" {
+		st.collectionName = o.Collection
+	}
+
+	if o.BatchSize > 0 {
+		st.batchSize = o.BatchSize
+	}
+
+	if o.SpecsWeight > 0 {
+		st.specsWeight = o.SpecsWeight
+	}
+
+	if o.Oversampling > 0 {
+		st.oversampling = o.Oversampling
+	}
+
+	if d, ok := distances[o.Distance]; ok {
+		st.distance = d
+	}
+
+	if t, ok := datatypes[o.ImageDatatype]; ok {
+		st.imageDatatype = t
+	}
+
+	if t, ok := datatypes[o.TextDatatype]; ok {
+		st.textDatatype = t
+	}
+
+	return st
+}
+
 // Seeder handles loading smartphone data into Qdrant.
 type Seeder struct {
+	settings
+
 	client    *qdrantclient.Client
 	embedder  *embedder.Client
 	csvPath   string
 	imagesDir string
+	downloads *downloader.Downloader
+	onSeeded  []func(context.Context)
+	seeded    atomic.Bool
+	rejected  atomic.Pointer[[]RejectedPhone]
+	// connErr is the last failed attempt to reach the collection at startup,
+	// nil once it succeeded.
+	connErr atomic.Pointer[error]
 }
 
-// NewSeeder creates a new Seeder.
-func NewSeeder(client *qdrantclient.Client, embedder *embedder.Client, csvPath, imagesDir string) *Seeder {
-	return &Seeder{
+// NewSeeder creates a Seeder importing the CSV dataset at csvPath into the
+// collection o describes, downloading the images into imagesDir.
+func NewSeeder(client *qdrantclient.Client, embedder *embedder.Client, csvPath, imagesDir string, o Options) *Seeder {
+	s := &Seeder{
+		settings:  newSettings(o),
 		client:    client,
 		embedder:  embedder,
 		csvPath:   csvPath,
 		imagesDir: imagesDir,
+		downloads: downloader.New(downloader.Options{
+			Concurrency:    o.DownloadConcurrency,
+			UserAgent:      o.DownloadUserAgent,
+			HostInterval:   o.DownloadHostInterval,
+			BytesPerSecond: o.DownloadBytesPerSecond,
+		}),
 	}
+
+	s.connErr.Store(&errNotConnected)
+
+	return s
 }
 
-// SeedIfNeeded checks if data is already loaded, and imports from CSV if not.
-func (s *Seeder) SeedIfNeeded() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// OnSeeded registers fn to run after a seed completes, e.g. to invalidate caches.
+// It must be called before SeedIfNeeded.
+func (s *Seeder) OnSeeded(fn func(context.Context)) {
+	s.onSeeded = append(s.onSeeded, fn)
+}
 
-	exists, err := s.client.CollectionExists(ctx, collectionName)
-	if err != nil {
-		return fmt.Errorf("checking collection: %w", err)
+// Seeded reports whether the collection is fully loaded, either because it
+// already existed or because SeedIfNeeded finished importing it.
+func (s *Seeder) Seeded() bool {
+	return s.seeded.Load()
+}
+
+// ConnectionError returns nil once SeedIfNeeded or CheckSeeded reached the
+// collection, and until then the error of the last attempt.
+func (s *Seeder) ConnectionError() error {
+	if err := s.connErr.Load(); err != nil {
+		return *err
 	}
 
-	if exists {
-		info, err := s.client.GetCollectionInfo(ctx, collectionName)
-		if err != nil {
-			return fmt.Errorf("getting collection info: %w", err)
-		}
+	return nil
+}
 
-		var points uint64
-		if info.PointsCount != nil {
-			points = *info.PointsCount
+// SeedIfNeeded checks if data is already loaded, and imports from CSV if not.
+// An existing collection gets the payload indexes it lacks. It waits for
+// Qdrant as connect does. Cancelling ctx stops the import between batches;
+// the collection is only marked complete after the last one, so the next
+// call deletes a partly imported collection and seeds it again.
+func (s *Seeder) SeedIfNeeded(ctx context.Context) error {
+	exists, err := s.connect(ctx)
+
+	switch {
+	case errors.Is(err, errSeedIncomplete):
+		slog.Warn("collection seed was interrupted, reseeding", slog.String("collection", s.collectionName))
+
+		if err := s.client.DeleteCollection(ctx, s.collectionName); err != nil {
+			return fmt.Errorf("deleting incomplete collection: %w", err)
 		}
-
-		slog.Info("collection already seeded, skipping",
-			slog.String("collection", collectionName),
-			slog.Uint64("points", points),
-		)
-
+	case err != nil:
+		return err
+	case exists:
+		s.indexExisting(ctx)
 		return nil
+	default:
+		slog.Info("collection not found, starting seed", slog.String("collection", s.collectionName))
 	}
 
-	slog.Info("collection not found, starting seed", slog.String("collection", collectionName))
+	ctx, span := tracer.Start(ctx, "qdrant.Seed")
+	defer span.End()
 
-	if err := s.createCollection(); err != nil {
+	if err := s.createCollection(ctx); err != nil {
 		return err
 	}
 
@@ -85,11 +268,20 @@ func (s *Seeder) SeedIfNeeded() error {
 
 	slog.Info("parsed smartphones from csv", slog.Int("count", len(phones)))
 
+	phones, rejected := validatePhones(phones)
+	s.rejected.Store(&rejected)
+
+	if len(rejected) > 0 {
+		slog.Warn("phones failing data validation are not indexed", slog.Int("rejected", len(rejected)))
+	}
+
+	model.AssignSlugs(phones)
+
 	if err := os.MkdirAll(s.imagesDir, 0o755); err != nil {
 		return fmt.Errorf("creating images dir: %w", err)
 	}
 
-	waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer waitCancel()
 
 	if err := s.embedder.WaitReady(waitCtx); err != nil {
@@ -98,15 +290,21 @@ func (s *Seeder) SeedIfNeeded() error {
 
 	total := len(phones)
 
-	for i := 0; i < total; i += batchSize {
-		end := min(i+batchSize, total)
+	for i := 0; i < total; i += s.batchSize {
+		if err := ctx.Err(); err != nil {
+			slog.Warn("seed interrupted", slog.Int("imported", i), slog.Int("total", total))
+			return fmt.Errorf("seed interrupted: %w", err)
+		}
+
+		end := min(i+s.batchSize, total)
 		batch := phones[i:end]
 
 		slog.Info("processing",
 			slog.String("embeddings", fmt.Sprintf("%d/%d", end, total)),
 		)
 
-		if err := s.processBatch(batch, uint64(i)); err != nil {
+		if err := s.processBatch(ctx, batch, uint64(i)); err != nil {
+			tracing.RecordError(span, err)
 			return fmt.Errorf("processing batch %d-%d: %w", i, end, err)
 		}
 
@@ -115,53 +313,349 @@ func (s *Seeder) SeedIfNeeded() error {
 		)
 	}
 
+	// Only now is the collection complete; until then a restart reseeds it.
+	if err := s.client.UpdateCollection(ctx, &qdrantclient.UpdateCollection{
+		CollectionName: s.collectionName,
+		Metadata:       map[string]*qdrantclient.Value{seedCompleteKey: qdrantclient.NewValueBool(true)},
+	}); err != nil {
+		return fmt.Errorf("recording seed completion: %w", err)
+	}
+
 	slog.Info("seed complete", slog.Int("total", total))
 
+	for _, fn := range s.onSeeded {
+		fn(ctx)
+	}
+
+	s.seeded.Store(true)
+
 	return nil
 }
 
-func (s *Seeder) createCollection() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// indexExisting creates the payload indexes declared since the collection
+// was created. Searches work without them, only slower, so failures are
+// logged rather than returned.
+func (s *Seeder) indexExisting(ctx context.Context) {
+	infoCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	info, err := s.client.GetCollectionInfo(infoCtx, s.collectionName)
+	cancel()
+
+	if err == nil {
+		err = createMissingIndexes(ctx, s.client, s.collectionName, info)
+	}
+
+	if err != nil {
+		slog.Warn("creating missing payload indexes failed", slog.String("error", err.Error()))
+	}
+}
+
+// CheckSeeded marks the collection as seeded when it exists, without
+// importing anything when it does not or when its seed was interrupted. It waits for Qdrant as connect does.
+func (s *Seeder) CheckSeeded(ctx context.Context) error {
+	exists, err := s.connect(ctx)
+
+	switch {
+	case errors.Is(err, errSeedIncomplete):
+		return fmt.Errorf("%w, run seed to reimport it", err)
+	case err == nil && !exists:
+		slog.Warn("collection not found and seeding is disabled", slog.String("collection", s.collectionName))
+	}
+
+	return err
+}
+
+// connect runs checkExisting until Qdrant answers, retrying with exponential
+// backoff from minConnectBackoff to maxConnectBackoff, as Qdrant often
+// starts after the server. A collection with mismatched vectors or an
+// interrupted seed is not retried.
+func (s *Seeder) connect(ctx context.Context) (bool, error) {
+	backoff := minConnectBackoff
+
+	for {
+		exists, err := s.checkExisting(ctx)
+		if err == nil {
+			s.connErr.Store(nil)
+			return exists, nil
+		}
+
+		// Qdrant answered; the seed decides what to do with the collection.
+		if errors.Is(err, errSeedIncomplete) {
+			s.connErr.Store(nil)
+			return true, err
+		}
+
+		s.connErr.Store(&err)
+
+		if errors.Is(err, ErrVectorMismatch) {
+			return false, err
+		}
+
+		slog.Warn("qdrant unavailable, retrying",
+			slog.String("error", err.Error()),
+			slog.Duration("backoff", backoff),
+		)
+
+		if !sleep(ctx, backoff) {
+			return false, ctx.Err()
+		}
+
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// sleep waits for d, returning false if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// checkExisting reports whether the collection exists, marking it seeded
+// and warning about an outdated payload schema when it does. An existing
+// collection with mismatched vectors is an ErrVectorMismatch, and one whose
+// seed never finished is an errSeedIncomplete.
+func (s *Seeder) checkExisting(ctx context.Context) (bool, error) {
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	if err := s.client.CreateCollection(ctx, &qdrantclient.CreateCollection{
-		CollectionName: collectionName,
-		VectorsConfig: qdrantclient.NewVectorsConfigMap(map[string]*qdrantclient.VectorParams{
-			"image": {Size: imageVectorSize, Distance: qdrantclient.Distance_Cosine},
-			"text":  {Size: textVectorSize, Distance: qdrantclient.Distance_Cosine},
-		}),
+	exists, err := collectionExists(checkCtx, s.client, s.collectionName)
+	if err != nil {
+		return false, fmt.Errorf("checking collection: %w", err)
+	}
+
+	if !exists {
+		return false, nil
+	}
+
+	info, err := s.client.GetCollectionInfo(checkCtx, s.collectionName)
+	if err != nil {
+		return false, fmt.Errorf("getting collection info: %w", err)
+	}
+
+	if err := s.checkVectors(info); err != nil {
+		return false, err
+	}
+
+	if !seedComplete(info) {
+		return true, errSeedIncomplete
+	}
+
+	slog.Info("collection already seeded, skipping",
+		slog.String("collection", s.collectionName),
+		slog.Uint64("points", info.GetPointsCount()),
+	)
+
+	if version := schemaVersion(info); version < PayloadSchemaVersion {
+		slog.Warn("payload schema is outdated, run the migrate command to upgrade it",
+			slog.Int("version", version),
+			slog.Int("current", PayloadSchemaVersion),
+		)
+	}
+
+	s.seeded.Store(true)
+
+	return true, nil
+}
+
+// seedCompleteKey is the collection metadata key recording whether the seed
+// that created the collection imported every phone. Collections created
+// before it was introduced, or by a reindex, do not have it and count as
+// complete.
+const seedCompleteKey = "seed_complete"
+
+// errSeedIncomplete reports a collection whose seed was interrupted, e.g. by
+// a shutdown, and holds only part of the catalog.
+var errSeedIncomplete = errors.New("collection seed was interrupted")
+
+// seedingMetadata returns the metadata of a collection created by a seed,
+// marked incomplete until the last batch is imported.
+func seedingMetadata() map[string]*qdrantclient.Value {
+	metadata := schemaMetadata(PayloadSchemaVersion)
+	metadata[seedCompleteKey] = qdrantclient.NewValueBool(false)
+
+	return metadata
+}
+
+// seedComplete reports whether the seed that created the collection of info
+// finished.
+func seedComplete(info *qdrantclient.CollectionInfo) bool {
+	v, ok := info.GetConfig().GetMetadata()[seedCompleteKey]
+
+	return !ok || v.GetBoolValue()
+}
+
+func (s *Seeder) createCollection(ctx context.Context) error {
+	createCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := s.client.CreateCollection(createCtx, &qdrantclient.CreateCollection{
+		CollectionName: s.collectionName,
+		VectorsConfig:  qdrantclient.NewVectorsConfigMap(s.expectedVectors()),
+		Metadata:       seedingMetadata(),
 	}); err != nil {
 		return fmt.Errorf("creating collection: %w", err)
 	}
 
-	// Create payload indexes for filtering
-	keywordType := qdrantclient.FieldType_FieldTypeKeyword
-	textType := qdrantclient.FieldType_FieldTypeText
-	floatType := qdrantclient.FieldType_FieldTypeFloat
-	wait := true
+	return createIndexes(ctx, s.client, s.collectionName, payloadIndexes)
+}
 
-	indexes := []struct {
-		field     string
-		fieldType *qdrantclient.FieldType
-	}{
-		{"brand", &keywordType},
-		{"nfc", &textType},
-		{"technology", &textType},
-		{"os_family", &keywordType},
-		{"display_type", &keywordType},
-		{"price_eur", &floatType},
+// expectedVectors returns the named vectors of a new collection: the image
+// and description embeddings, plus the specs embedding when specsVector is
+// set and the description token embeddings when multivector is set, with
+// the configured distance, datatypes and quantization.
+func (st settings) expectedVectors() map[string]*qdrantclient.VectorParams {
+	imageType, textType := st.imageDatatype, st.textDatatype
+
+	vectors := map[string]*qdrantclient.VectorParams{
+		"image": {Size: imageVectorSize, Distance: st.distance, Datatype: &imageType},
+		"text":  {Size: textVectorSize, Distance: st.distance, Datatype: &textType},
 	}
 
-	for _, idx := range indexes {
-		idxCtx, idxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if st.specsVector {
+		vectors["specs"] = &qdrantclient.VectorParams{Size: textVectorSize, Distance: st.distance, Datatype: &textType}
+	}
+
+	if st.multivector {
+		vectors["tokens"] = &qdrantclient.VectorParams{
+			Size:     textVectorSize,
+			Distance: st.distance,
+			Datatype: &textType,
+			MultivectorConfig: &qdrantclient.MultiVectorConfig{
+				Comparator: qdrantclient.MultiVectorComparator_MaxSim,
+			},
+		}
+	}
+
+	for name, params := range vectors {
+		if slices.Contains(st.binaryQuantized, name) {
+			alwaysRAM, onDisk := true, true
+			params.QuantizationConfig = qdrantclient.NewQuantizationBinary(&qdrantclient.BinaryQuantization{AlwaysRam: &alwaysRAM})
+			params.OnDisk = &onDisk
+		}
+	}
+
+	return vectors
+}
+
+// payloadIndex is a payload field indexed for filtering, sorting or
+// faceting.
+type payloadIndex struct {
+	field     string
+	fieldType qdrantclient.FieldType
+}
+
+// payloadIndexes declares the payload indexes of the collection. New
+// collections get all of them; indexes added here later are created on
+// existing collections at startup and by Migrate.
+var payloadIndexes = []payloadIndex{
+	// Identity and classification.
+	{"brand", qdrantclient.FieldType_FieldTypeKeyword},
+	{"slug", qdrantclient.FieldType_FieldTypeKeyword},
+	{"nfc", qdrantclient.FieldType_FieldTypeText},
+	{"technology", qdrantclient.FieldType_FieldTypeText},
+	{"os_family", qdrantclient.FieldType_FieldTypeKeyword},
+	{"display_type", qdrantclient.FieldType_FieldTypeKeyword},
+	{"soc_family", qdrantclient.FieldType_FieldTypeKeyword},
+	{"soc_tier", qdrantclient.FieldType_FieldTypeKeyword},
+	{"color_names", qdrantclient.FieldType_FieldTypeKeyword},
+	{"color_families", qdrantclient.FieldType_FieldTypeKeyword},
+	{"sensor_list", qdrantclient.FieldType_FieldTypeKeyword},
+	{"camera_lenses[].type", qdrantclient.FieldType_FieldTypeKeyword},
+
+	// Full text, for text_match and the model name boost.
+	{"model", qdrantclient.FieldType_FieldTypeText},
+	{"description", qdrantclient.FieldType_FieldTypeText},
+
+	// Connectivity.
+	{"dual_sim", qdrantclient.FieldType_FieldTypeBool},
+	{"esim", qdrantclient.FieldType_FieldTypeBool},
+	{"sim_sizes", qdrantclient.FieldType_FieldTypeKeyword},
+	{"lte_bands", qdrantclient.FieldType_FieldTypeInteger},
+	{"nr_bands", qdrantclient.FieldType_FieldTypeInteger},
+
+	// Parsed numeric specs.
+	{"price_eur", qdrantclient.FieldType_FieldTypeFloat},
+	{"battery_mah", qdrantclient.FieldType_FieldTypeFloat},
+	{"weight_g", qdrantclient.FieldType_FieldTypeFloat},
+	{"depth_mm", qdrantclient.FieldType_FieldTypeFloat},
+	{"screen_inches", qdrantclient.FieldType_FieldTypeFloat},
+	{"ram_gb", qdrantclient.FieldType_FieldTypeFloat},
+	{"storage_gb", qdrantclient.FieldType_FieldTypeFloat},
+	{"ram_min_gb", qdrantclient.FieldType_FieldTypeFloat},
+	{"storage_min_gb", qdrantclient.FieldType_FieldTypeFloat},
+	{"resolution_width", qdrantclient.FieldType_FieldTypeInteger},
+	{"resolution_height", qdrantclient.FieldType_FieldTypeInteger},
+	{"resolution_pixels", qdrantclient.FieldType_FieldTypeInteger},
+	{"camera_mp", qdrantclient.FieldType_FieldTypeFloat},
+
+	// Announcement.
+	{"announced_date", qdrantclient.FieldType_FieldTypeDatetime},
+	{"announced_at", qdrantclient.FieldType_FieldTypeInteger},
+	{"announced_year", qdrantclient.FieldType_FieldTypeInteger},
+}
+
+// textIndexParams configure the tokenizer of the full-text indexes listed;
+// the others keep the Qdrant defaults. Model names and descriptions are
+// split into lowercase words, keeping short tokens such as the "5g" or "s"
+// of a model name, and model names also index word positions for phrase
+// matches.
+var textIndexParams = map[string]*qdrantclient.TextIndexParams{
+	"model":       textIndex(true),
+	"description": textIndex(false),
+}
+
+func textIndex(phrases bool) *qdrantclient.TextIndexParams {
+	lowercase, minLen, maxLen := true, uint64(1), uint64(40)
+
+	return &qdrantclient.TextIndexParams{
+		Tokenizer:      qdrantclient.TokenizerType_Word,
+		Lowercase:      &lowercase,
+		MinTokenLen:    &minLen,
+		MaxTokenLen:    &maxLen,
+		PhraseMatching: &phrases,
+	}
+}
+
+// createMissingIndexes creates the payload indexes collection, described by
+// info, lacks.
+func createMissingIndexes(ctx context.Context, client *qdrantclient.Client, collection string, info *qdrantclient.CollectionInfo) error {
+	schema := info.GetPayloadSchema()
+
+	var missing []payloadIndex
+
+	for _, idx := range payloadIndexes {
+		if _, ok := schema[idx.field]; !ok {
+			missing = append(missing, idx)
+		}
+	}
 
-		_, err := s.client.CreateFieldIndex(idxCtx, &qdrantclient.CreateFieldIndexCollection{
-			CollectionName: collectionName,
+	return createIndexes(ctx, client, collection, missing)
+}
+
+// createIndexes creates the given payload indexes on collection. Creating an
+// index that already exists is a no-op.
+func createIndexes(ctx context.Context, client *qdrantclient.Client, collection string, indexes []payloadIndex) error {
+	wait := true
+
+	for _, idx := range indexes {
+		req := &qdrantclient.CreateFieldIndexCollection{
+			CollectionName: collection,
 			FieldName:      idx.field,
-			FieldType:      idx.fieldType,
+			FieldType:      &idx.fieldType,
 			Wait:           &wait,
-		})
+		}
+		if params, ok := textIndexParams[idx.field]; ok {
+			req.FieldIndexParams = qdrantclient.NewPayloadIndexParamsText(params)
+		}
 
+		idxCtx, idxCancel := context.WithTimeout(ctx, 30*time.Second)
+		_, err := client.CreateFieldIndex(idxCtx, req)
 		idxCancel()
 
 		if err != nil {
@@ -174,38 +668,126 @@ func (s *Seeder) createCollection() error {
 	return nil
 }
 
-func (s *Seeder) processBatch(batch []model.Smartphone, offset uint64) error {
-	// Phase 1: download images concurrently
+func (s *Seeder) processBatch(ctx context.Context, batch []model.Smartphone, offset uint64) error {
+	ctx, span := tracer.Start(ctx, "qdrant.SeedBatch", trace.WithAttributes(
+		attribute.Int64("seed.offset", int64(offset)),
+		attribute.Int("seed.size", len(batch)),
+	))
+	defer span.End()
+
+	for i := range batch {
+		batch[i].ID = offset + uint64(i) + 1
+	}
+
+	return s.index(ctx, batch)
+}
+
+// index downloads images, embeds and upserts phones under their ID.
+func (s *Seeder) index(ctx context.Context, batch []model.Smartphone) error {
+	// Phase 1: download images, as concurrently as the downloader allows
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, downloadConcurrency)
 
 	for i := range batch {
-		wg.Add(1)
-		go func(idx int) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-			batch[idx].ImageFile = s.downloadImage(&batch[idx])
-		}(i)
+		wg.Go(func() {
+			batch[i].ImageFile = s.downloadImage(ctx, &batch[i])
+		})
 	}
 
 	wg.Wait()
 
-	// Phase 2: text embeddings (batch)
+	// Phase 2: embeddings
+	vectors, err := s.embed(ctx, batch, s.vectorNames())
+	if err != nil {
+		return err
+	}
+
+	// Phase 3: build points and upsert
+	points := make([]*qdrantclient.PointStruct, 0, len(batch))
+
+	for i, phone := range batch {
+		points = append(points, &qdrantclient.PointStruct{
+			Id:      qdrantclient.NewIDNum(phone.ID),
+			Vectors: qdrantclient.NewVectorsMap(vectors[i]),
+			Payload: qdrantclient.NewValueMap(phone.PayloadMap()),
+		})
+	}
+
+	return s.upsert(ctx, s.collectionName, points)
+}
+
+// vectorNames returns the names of expectedVectors, sorted.
+func (st settings) vectorNames() []string {
+	return slices.Sorted(maps.Keys(st.expectedVectors()))
+}
+
+// embed computes the named vectors of each phone: text and tokens from the
+// description, specs from the SpecsText, image from the downloaded image
+// file. Phones without an
+// image, or all of them when the image embeddings fail, get no image
+// vector.
+func (s *Seeder) embed(ctx context.Context, batch []model.Smartphone, names []string) ([]map[string]*qdrantclient.Vector, error) {
+	vectors := make([]map[string]*qdrantclient.Vector, len(batch))
+	for i := range vectors {
+		vectors[i] = map[string]*qdrantclient.Vector{}
+	}
+
 	descriptions := make([]string, len(batch))
 	for i, phone := range batch {
 		descriptions[i] = phone.Description()
 	}
 
-	embedCtx, embedCancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	textEmbeddings, err := s.embedder.EmbedTexts(embedCtx, descriptions)
-	embedCancel()
+	if slices.Contains(names, "text") {
+		embedCtx, embedCancel := context.WithTimeout(ctx, 2*time.Minute)
+		textEmbeddings, err := s.embedder.EmbedTexts(embedCtx, descriptions)
+		embedCancel()
 
-	if err != nil {
-		return fmt.Errorf("text embeddings: %w", err)
+		if err != nil {
+			return nil, fmt.Errorf("text embeddings: %w", err)
+		}
+
+		for i, e := range textEmbeddings {
+			vectors[i]["text"] = &qdrantclient.Vector{Data: e}
+		}
+	}
+
+	if slices.Contains(names, "specs") {
+		specsTexts := make([]string, len(batch))
+		for i, phone := range batch {
+			specsTexts[i] = phone.SpecsText()
+		}
+
+		specsCtx, specsCancel := context.WithTimeout(ctx, 2*time.Minute)
+		specsEmbeddings, err := s.embedder.EmbedTexts(specsCtx, specsTexts)
+		specsCancel()
+
+		if err != nil {
+			return nil, fmt.Errorf("specs embeddings: %w", err)
+		}
+
+		for i, e := range specsEmbeddings {
+			vectors[i]["specs"] = &qdrantclient.Vector{Data: e}
+		}
 	}
 
-	// Phase 3: image embeddings (batch via file paths)
+	if slices.Contains(names, "tokens") {
+		tokensCtx, tokensCancel := context.WithTimeout(ctx, 2*time.Minute)
+		tokenEmbeddings, err := s.embedder.EmbedTextsTokens(tokensCtx, descriptions)
+		tokensCancel()
+
+		if err != nil {
+			return nil, fmt.Errorf("token embeddings: %w", err)
+		}
+
+		for i, e := range tokenEmbeddings {
+			vectors[i]["tokens"] = qdrantclient.NewVectorMulti(e)
+		}
+	}
+
+	if !slices.Contains(names, "image") {
+		return vectors, nil
+	}
+
+	// Image embeddings (batch via file paths)
 	var imagePaths []string
 	imageIndexes := map[int]int{}
 
@@ -219,56 +801,42 @@ func (s *Seeder) processBatch(batch []model.Smartphone, offset uint64) error {
 		imagePaths = append(imagePaths, imgPath)
 	}
 
-	var imageEmbeddings [][]float32
-
-	if len(imagePaths) > 0 {
-		imgCtx, imgCancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		imageEmbeddings, err = s.embedder.EmbedImagePaths(imgCtx, imagePaths)
-		imgCancel()
-
-		if err != nil {
-			slog.Warn("image embeddings failed, continuing with text only", slog.String("error", err.Error()))
-			imageEmbeddings = nil
-		}
+	if len(imagePaths) == 0 {
+		return vectors, nil
 	}
 
-	// Phase 4: build points and upsert
-	points := make([]*qdrantclient.PointStruct, 0, len(batch))
-
-	for i, phone := range batch {
-		id := offset + uint64(i) + 1
+	imgCtx, imgCancel := context.WithTimeout(ctx, 5*time.Minute)
+	imageEmbeddings, err := s.embedder.EmbedImagePaths(imgCtx, imagePaths)
+	imgCancel()
 
-		vectors := map[string]*qdrantclient.Vector{
-			"text": {Data: textEmbeddings[i]},
-		}
+	if err != nil {
+		slog.Warn("image embeddings failed, continuing with text only", slog.String("error", err.Error()))
+		return vectors, nil
+	}
 
-		if imgIdx, ok := imageIndexes[i]; ok && imageEmbeddings != nil && imgIdx < len(imageEmbeddings) {
-			vectors["image"] = &qdrantclient.Vector{Data: imageEmbeddings[imgIdx]}
+	for i, imgIdx := range imageIndexes {
+		if imgIdx < len(imageEmbeddings) {
+			vectors[i]["image"] = &qdrantclient.Vector{Data: imageEmbeddings[imgIdx]}
 		}
-
-		points = append(points, &qdrantclient.PointStruct{
-			Id:      qdrantclient.NewIDNum(id),
-			Vectors: qdrantclient.NewVectorsMap(vectors),
-			Payload: qdrantclient.NewValueMap(phone.PayloadMap()),
-		})
 	}
 
-	upsertCtx, upsertCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	return vectors, nil
+}
+
+// upsert writes points to collection.
+func (s *Seeder) upsert(ctx context.Context, collection string, points []*qdrantclient.PointStruct) error {
+	upsertCtx, upsertCancel := context.WithTimeout(ctx, 30*time.Second)
 	defer upsertCancel()
 
-	_, err = s.client.Upsert(upsertCtx, &qdrantclient.UpsertPoints{
-		CollectionName: collectionName,
+	_, err := s.client.Upsert(upsertCtx, &qdrantclient.UpsertPoints{
+		CollectionName: collection,
 		Points:         points,
 	})
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
-func (s *Seeder) downloadImage(phone *model.Smartphone) string {
+func (s *Seeder) downloadImage(ctx context.Context, phone *model.Smartphone) string {
 	if phone.ImageURL == "" {
 		return ""
 	}
@@ -285,28 +853,12 @@ func (s *Seeder) downloadImage(phone *model.Smartphone) string {
 		return filename
 	}
 
-	resp, err := http.Get(phone.ImageURL) //nolint:noctx // fire-and-forget download during seed
-	if err != nil {
-		slog.Warn("failed to download image", slog.String("url", phone.ImageURL), slog.String("error", err.Error()))
-		return ""
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		slog.Warn("image download bad status", slog.String("file", filename), slog.Int("status", resp.StatusCode))
-		return ""
-	}
+	ctx, span := tracer.Start(ctx, "image.download", trace.WithAttributes(attribute.String("image.file", filename)))
+	defer span.End()
 
-	f, err := os.Create(dest)
-	if err != nil {
-		slog.Warn("failed to create image file", slog.String("path", dest), slog.String("error", err.Error()))
-		return ""
-	}
-	defer func() { _ = f.Close() }()
-
-	if _, err := io.Copy(f, resp.Body); err != nil {
-		slog.Warn("failed to write image", slog.String("path", dest), slog.String("error", err.Error()))
-		_ = os.Remove(dest)
+	if err := s.downloads.Download(ctx, phone.ImageURL, dest); err != nil {
+		tracing.RecordError(span, err)
+		slog.Warn("failed to download image", slog.String("url", phone.ImageURL), slog.String("error", err.Error()))
 
 		return ""
 	}
@@ -0,0 +1,254 @@
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	qdrantclient "github.com/qdrant/go-client/qdrant"
+)
+
+// CollectionStatus describes the state of the smartphones collection.
+type CollectionStatus struct {
+	Exists bool
+	Points uint64
+	// SchemaVersion is the payload schema version the points were indexed
+	// with.
+	SchemaVersion int
+}
+
+// Status reports whether the collection exists and how many points it holds.
+func (s *Searcher) Status(ctx context.Context) (CollectionStatus, error) {
+	exists, err := collectionExists(ctx, s.client, s.collectionName)
+	if err != nil {
+		return CollectionStatus{}, fmt.Errorf("checking collection: %w", err)
+	}
+
+	if !exists {
+		return CollectionStatus{}, nil
+	}
+
+	info, err := s.client.GetCollectionInfo(ctx, s.collectionName)
+	if err != nil {
+		return CollectionStatus{}, fmt.Errorf("getting collection info: %w", err)
+	}
+
+	return CollectionStatus{
+		Exists:        true,
+		Points:        info.GetPointsCount(),
+		SchemaVersion: schemaVersion(info),
+	}, nil
+}
+
+// ErrVectorMismatch reports a collection whose vectors differ from the ones
+// the embedding models produce, e.g. one created for other models.
+var ErrVectorMismatch = errors.New("collection vectors do not match the embedding models")
+
+// CheckVectors verifies that an existing collection has the named vectors
+// of the sizes the embedding models produce. A missing collection passes.
+func (s *Searcher) CheckVectors(ctx context.Context) error {
+	exists, err := collectionExists(ctx, s.client, s.collectionName)
+	if err != nil || !exists {
+		return err
+	}
+
+	info, err := s.client.GetCollectionInfo(ctx, s.collectionName)
+	if err != nil {
+		return fmt.Errorf("getting collection info: %w", err)
+	}
+
+	return s.checkVectors(info)
+}
+
+// checkVectors compares the vectors of a collection with the models'.
+func (st settings) checkVectors(info *qdrantclient.CollectionInfo) error {
+	params := info.GetConfig().GetParams().GetVectorsConfig().GetParamsMap().GetMap()
+
+	expected := st.expectedVectors()
+
+	var errs []error
+
+	for _, name := range st.vectorNames() {
+		want := expected[name]
+		p, ok := params[name]
+
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%w: %s vector missing", ErrVectorMismatch, name))
+		case p.GetSize() != want.GetSize():
+			errs = append(errs, fmt.Errorf("%w: %s vector has %d dimensions, want %d", ErrVectorMismatch, name, p.GetSize(), want.GetSize()))
+		case want.GetMultivectorConfig() != nil && p.GetMultivectorConfig() == nil:
+			errs = append(errs, fmt.Errorf("%w: %s vector is not a multivector", ErrVectorMismatch, name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CheckSchema verifies that the collection has the vectors of CheckVectors,
+// with the configured distance, datatypes and quantization, and every payload index the
+// filters use, with the expected types. Unlike CheckVectors it fails when
+// the collection is missing.
+func (s *Searcher) CheckSchema(ctx context.Context) error {
+	info, err := s.client.GetCollectionInfo(ctx, s.collectionName)
+	if err != nil {
+		return fmt.Errorf("getting collection info: %w", err)
+	}
+
+	errs := []error{s.checkVectors(info)}
+	params := info.GetConfig().GetParams().GetVectorsConfig().GetParamsMap().GetMap()
+
+	expected := s.expectedVectors()
+
+	for _, name := range s.vectorNames() {
+		want, got := expected[name], params[name]
+
+		switch {
+		case got == nil:
+		case got.GetDistance() != want.GetDistance():
+			errs = append(errs, fmt.Errorf("%s vector distance is %s, want %s",
+				name, strings.ToLower(got.GetDistance().String()), strings.ToLower(want.GetDistance().String())))
+		case storedDatatype(got) != want.GetDatatype():
+			errs = append(errs, fmt.Errorf("%s vector datatype is %s, want %s",
+				name, strings.ToLower(storedDatatype(got).String()), strings.ToLower(want.GetDatatype().String())))
+		case isBinaryQuantized(got) != isBinaryQuantized(want):
+			errs = append(errs, fmt.Errorf("%s vector binary quantization is %s, want %s",
+				name, onOff(isBinaryQuantized(got)), onOff(isBinaryQuantized(want))))
+		}
+	}
+
+	schema := info.GetPayloadSchema()
+
+	for _, idx := range payloadIndexes {
+		want := strings.TrimPrefix(idx.fieldType.String(), "FieldType")
+
+		switch got, ok := schema[idx.field]; {
+		case !ok:
+			errs = append(errs, fmt.Errorf("payload index on %s missing", idx.field))
+		case got.GetDataType().String() != want:
+			errs = append(errs, fmt.Errorf("payload index on %s is %s, want %s",
+				idx.field, strings.ToLower(got.GetDataType().String()), strings.ToLower(want)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// EmbeddingSizes embeds a sample image and a sample text and returns the
+// sizes of the vectors, wrapping ErrVectorMismatch when they differ from
+// the sizes the collection is created with.
+func (s *Searcher) EmbeddingSizes(ctx context.Context) (imageSize, textSize int, err error) {
+	textVec, err := s.embedder.EmbedText(ctx, "smartphone")
+	if err != nil {
+		return 0, 0, fmt.Errorf("embedding a text: %w", err)
+	}
+
+	var sample bytes.Buffer
+	if err := png.Encode(&sample, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		return 0, 0, fmt.Errorf("encoding sample image: %w", err)
+	}
+
+	imageVec, err := s.embedder.EmbedImage(ctx, &sample, "sample.png")
+	if err != nil {
+		return 0, 0, fmt.Errorf("embedding an image: %w", err)
+	}
+
+	var errs []error
+
+	if len(imageVec) != imageVectorSize {
+		errs = append(errs, fmt.Errorf("%w: image embeddings have %d dimensions, want %d", ErrVectorMismatch, len(imageVec), imageVectorSize))
+	}
+
+	if len(textVec) != textVectorSize {
+		errs = append(errs, fmt.Errorf("%w: text embeddings have %d dimensions, want %d", ErrVectorMismatch, len(textVec), textVectorSize))
+	}
+
+	if s.multivector {
+		tokens, err := s.embedder.EmbedTextTokens(ctx, "smartphone")
+
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("embedding text tokens: %w", err))
+		case len(tokens) == 0 || len(tokens[0]) != textVectorSize:
+			errs = append(errs, fmt.Errorf("%w: token embeddings do not have %d dimensions", ErrVectorMismatch, textVectorSize))
+		}
+	}
+
+	return len(imageVec), len(textVec), errors.Join(errs...)
+}
+
+// EmbedderHealth checks that the embedding service responds.
+func (s *Searcher) EmbedderHealth(ctx context.Context) error {
+	return s.embedder.Health(ctx)
+}
+
+// CollectionInfo summarizes the collection configuration and indexing state.
+type CollectionInfo struct {
+	Status         string                      `json:"status"`
+	Points         uint64                      `json:"points"`
+	IndexedVectors uint64                      `json:"indexed_vectors"`
+	Segments       uint64                      `json:"segments"`
+	OptimizerOK    bool                        `json:"optimizer_ok"`
+	OptimizerError string                      `json:"optimizer_error,omitempty"`
+	Vectors        map[string]VectorInfo       `json:"vectors"`
+	PayloadIndexes map[string]PayloadIndexInfo `json:"payload_indexes"`
+}
+
+// VectorInfo describes a named vector.
+type VectorInfo struct {
+	Size     uint64 `json:"size"`
+	Distance string `json:"distance"`
+}
+
+// PayloadIndexInfo describes an indexed payload field.
+type PayloadIndexInfo struct {
+	Type   string `json:"type"`
+	Points uint64 `json:"points"`
+}
+
+// Info returns collection statistics as reported by Qdrant.
+func (s *Searcher) Info(ctx context.Context) (CollectionInfo, error) {
+	info, err := s.client.GetCollectionInfo(ctx, s.collectionName)
+	if err != nil {
+		return CollectionInfo{}, fmt.Errorf("getting collection info: %w", err)
+	}
+
+	out := CollectionInfo{
+		Status:         strings.ToLower(info.GetStatus().String()),
+		Points:         info.GetPointsCount(),
+		IndexedVectors: info.GetIndexedVectorsCount(),
+		Segments:       info.GetSegmentsCount(),
+		OptimizerOK:    info.GetOptimizerStatus().GetOk(),
+		OptimizerError: info.GetOptimizerStatus().GetError(),
+		Vectors:        map[string]VectorInfo{},
+		PayloadIndexes: map[string]PayloadIndexInfo{},
+	}
+
+	for name, params := range info.GetConfig().GetParams().GetVectorsConfig().GetParamsMap().GetMap() {
+		out.Vectors[name] = VectorInfo{
+			Size:     params.GetSize(),
+			Distance: strings.ToLower(params.GetDistance().String()),
+		}
+	}
+
+	for field, schema := range info.GetPayloadSchema() {
+		out.PayloadIndexes[field] = PayloadIndexInfo{
+			Type:   strings.ToLower(schema.GetDataType().String()),
+			Points: schema.GetPoints(),
+		}
+	}
+
+	return out, nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
@@ -0,0 +1,46 @@
+package qdrant
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Timings accumulates the time searches spend embedding their input and
+// querying Qdrant. It is safe for concurrent use.
+type Timings struct {
+	embed  atomic.Int64
+	qdrant atomic.Int64
+}
+
+type timingsKey struct{}
+
+// WithTimings returns a context under which the searches add their
+// embedding and query times to t.
+func WithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t)
+}
+
+// Embed returns the time spent in the embedder.
+func (t *Timings) Embed() time.Duration {
+	return time.Duration(t.embed.Load())
+}
+
+// Qdrant returns the time spent in Qdrant vector queries.
+func (t *Timings) Qdrant() time.Duration {
+	return time.Duration(t.qdrant.Load())
+}
+
+// recordEmbed adds the time since start to the embedding time of ctx.
+func recordEmbed(ctx context.Context, start time.Time) {
+	if t, ok := ctx.Value(timingsKey{}).(*Timings); ok {
+		t.embed.Add(int64(time.Since(start)))
+	}
+}
+
+// recordQdrant adds the time since start to the query time of ctx.
+func recordQdrant(ctx context.Context, start time.Time) {
+	if t, ok := ctx.Value(timingsKey{}).(*Timings); ok {
+		t.qdrant.Add(int64(time.Since(start)))
+	}
+}
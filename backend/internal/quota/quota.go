@@ -0,0 +1,375 @@
+// Package quota meters the requests of API keys handed to external partners
+// against daily and monthly quotas. The keys are read from a JSON file
+// reloaded when it changes; the counts are kept in memory and persisted to
+// the store, so each server instance enforces its own share.
+package quota
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+)
+
+// Period layouts of the daily and monthly counts, in UTC.
+const (
+	dayLayout   = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+// Key is an API key entry of the keys file. A zero quota is unlimited.
+type Key struct {
+	Name    string `json:"name"`
+	Key     string `json:"key"`
+	Daily   int64  `json:"daily"`
+	Monthly int64  `json:"monthly"`
+}
+
+// Period is the usage of a key in a day or month.
+type Period struct {
+	// Period is the UTC day (2006-01-02) or month (2006-01) counted.
+	Period string `json:"period"`
+	Used   int64  `json:"used"`
+	// Limit is zero for an unlimited quota, which has no Remaining.
+	Limit     int64     `json:"limit"`
+	Remaining *int64    `json:"remaining,omitempty"`
+	Resets    time.Time `json:"resets"`
+}
+
+// exceeded reports whether the quota of p is used up.
+func (p Period) exceeded() bool {
+	return p.Limit > 0 && p.Used >= p.Limit
+}
+
+// Usage is the usage of a key in the current day and month.
+type Usage struct {
+	Key   string `json:"key"`
+	Day   Period `json:"day"`
+	Month Period `json:"month"`
+}
+
+// Quota periods, as reported by Usage.Exceeded.
+const (
+	Daily   = "daily"
+	Monthly = "monthly"
+)
+
+// Exceeded returns the quota of u that is used up, Daily before Monthly, or
+// "" when requests are still allowed.
+func (u Usage) Exceeded() string {
+	switch {
+	case u.Day.exceeded():
+		return Daily
+	case u.Month.exceeded():
+		return Monthly
+	}
+
+	return ""
+}
+
+// Tracker counts the requests of the configured API keys. A nil Tracker
+// knows no keys.
+type Tracker struct {
+	path  string
+	store *store.Store
+
+	mu      sync.Mutex
+	keys    map[[sha256.Size]byte]Key
+	modTime time.Time
+	usage   map[string]store.APIUsage
+	// dirty are the names whose counts changed since the last Flush.
+	dirty map[string]bool
+}
+
+// Load reads the API keys file at path and the counts persisted in st,
+// which may be nil to keep them in memory only. An empty path returns a nil
+// Tracker, disabling API keys.
+func Load(path string, st *store.Store) (*Tracker, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	t := &Tracker{path: path, store: st, usage: map[string]store.APIUsage{}, dirty: map[string]bool{}}
+
+	if _, err := t.reload(); err != nil {
+		return nil, err
+	}
+
+	if st != nil {
+		usage, err := st.APIUsage()
+		if err != nil {
+			return nil, fmt.Errorf("loading API key usage: %w", err)
+		}
+
+		t.usage = usage
+	}
+
+	return t, nil
+}
+
+// Lookup returns the entry of the API key key.
+func (t *Tracker) Lookup(key string) (Key, bool) {
+	if t == nil {
+		return Key{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k, ok := t.keys[sha256.Sum256([]byte(key))]
+
+	return k, ok
+}
+
+// Allow counts a request of k at now unless its daily or monthly quota is
+// used up, and returns the usage including it. Rejected requests are not
+// counted.
+func (t *Tracker) Allow(k Key, now time.Time) (Usage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.current(k.Name, now)
+	if current := usage(k, u, now); current.Exceeded() != "" {
+		return current, false
+	}
+
+	u.DayCount++
+	u.MonthCount++
+	t.usage[k.Name] = u
+	t.dirty[k.Name] = true
+
+	return usage(k, u, now), true
+}
+
+// Usage returns the usage of k at now.
+func (t *Tracker) Usage(k Key, now time.Time) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return usage(k, t.current(k.Name, now), now)
+}
+
+// All returns the usage at now of every configured key, by name.
+func (t *Tracker) All(now time.Time) []Usage {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	all := make([]Usage, 0, len(t.keys))
+	for _, k := range t.keys {
+		all = append(all, usage(k, t.current(k.Name, now), now))
+	}
+
+	slices.SortFunc(all, func(a, b Usage) int { return cmp.Compare(a.Key, b.Key) })
+
+	return all
+}
+
+// current returns the counts of name, reset when the day or month of now
+// differs from the counted one.
+func (t *Tracker) current(name string, now time.Time) store.APIUsage {
+	u := t.usage[name]
+	now = now.UTC()
+
+	if day := now.Format(dayLayout); u.Day != day {
+		u.Day, u.DayCount = day, 0
+	}
+
+	if month := now.Format(monthLayout); u.Month != month {
+		u.Month, u.MonthCount = month, 0
+	}
+
+	return u
+}
+
+// usage reports the counts u of k against its quotas.
+func usage(k Key, u store.APIUsage, now time.Time) Usage {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return Usage{
+		Key:   k.Name,
+		Day:   period(u.Day, u.DayCount, k.Daily, day.AddDate(0, 0, 1)),
+		Month: period(u.Month, u.MonthCount, k.Monthly, month.AddDate(0, 1, 0)),
+	}
+}
+
+func period(name string, used, limit int64, resets time.Time) Period {
+	p := Period{Period: name, Used: used, Limit: limit, Resets: resets}
+
+	if limit > 0 {
+		remaining := max(limit-used, 0)
+		p.Remaining = &remaining
+	}
+
+	return p
+}
+
+// Flush persists the counts changed since the last Flush. Without a store
+// it does nothing.
+func (t *Tracker) Flush() error {
+	if t == nil || t.store == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	changed := make(map[string]store.APIUsage, len(t.dirty))
+
+	for name := range t.dirty {
+		changed[name] = t.usage[name]
+	}
+
+	t.dirty = map[string]bool{}
+	t.mu.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if err := t.store.SaveAPIUsage(changed); err != nil {
+		// Keep the counts for the next Flush, unless newer ones replaced them.
+		t.mu.Lock()
+		for name := range changed {
+			t.dirty[name] = true
+		}
+		t.mu.Unlock()
+
+		return fmt.Errorf("saving API key usage: %w", err)
+	}
+
+	return nil
+}
+
+// Watch reloads the keys file when its modification time changes and
+// persists the counts, every interval until ctx is cancelled, then persists
+// them a last time. An invalid file keeps the previous keys.
+func (t *Tracker) Watch(ctx context.Context, interval time.Duration) {
+	if t == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := t.Flush(); err != nil {
+				slog.Error("persisting API key usage failed", slog.String("error", err.Error()))
+			}
+
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := t.reload()
+
+		switch {
+		case err != nil:
+			slog.WarnContext(ctx, "reloading API keys failed", slog.String("path", t.path), slog.String("error", err.Error()))
+		case changed:
+			slog.InfoContext(ctx, "API keys reloaded", slog.Int("keys", t.count()))
+		}
+
+		if err := t.Flush(); err != nil {
+			slog.WarnContext(ctx, "persisting API key usage failed", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (t *Tracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.keys)
+}
+
+// reload re-reads the keys file if it changed since the last read. Unlike
+// the optional files of other settings, the keys file must exist.
+func (t *Tracker) reload() (bool, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return false, fmt.Errorf("reading API keys: %w", err)
+	}
+
+	t.mu.Lock()
+	unchanged := info.ModTime().Equal(t.modTime)
+	t.mu.Unlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	b, err := os.ReadFile(t.path)
+	if err != nil {
+		return false, fmt.Errorf("reading API keys: %w", err)
+	}
+
+	keys, err := parse(b)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Remember a broken version too, so it is reported only once.
+	t.modTime = info.ModTime()
+
+	if err != nil {
+		return false, fmt.Errorf("parsing API keys %s: %w", t.path, err)
+	}
+
+	changed := !maps.Equal(t.keys, keys)
+	t.keys = keys
+
+	return changed, nil
+}
+
+// parse decodes the JSON list of keys in b, indexed by the hash of each key
+// so lookups do not compare secrets byte by byte.
+func parse(b []byte) (map[[sha256.Size]byte]Key, error) {
+	var list []Key
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[[sha256.Size]byte]Key, len(list))
+	names := map[string]bool{}
+
+	for i, k := range list {
+		switch {
+		case k.Name == "":
+			return nil, fmt.Errorf("key %d has no name", i+1)
+		case names[k.Name]:
+			return nil, fmt.Errorf("duplicate key name %q", k.Name)
+		case len(k.Key) < 16:
+			return nil, fmt.Errorf("key %q must be at least 16 characters", k.Name)
+		case k.Daily < 0 || k.Monthly < 0:
+			return nil, fmt.Errorf("quotas of key %q must not be negative", k.Name)
+		}
+
+		hash := sha256.Sum256([]byte(k.Key))
+		if _, ok := keys[hash]; ok {
+			return nil, errors.New("two keys share the same secret")
+		}
+
+		// The secret is needed no more once hashed.
+		k.Key = ""
+		keys[hash] = k
+		names[k.Name] = true
+	}
+
+	return keys, nil
+}
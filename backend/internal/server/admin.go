@@ -0,0 +1,169 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/cache"
+	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+	"github.com/alessandrolattao/qdrant-experiment/internal/images"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+	"github.com/alessandrolattao/qdrant-experiment/internal/webhook"
+)
+
+// requireAdmin rejects requests that do not carry the admin bearer token.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeProblem(w, r, http.StatusUnauthorized, codeUnauthorized, "missing or invalid admin token")
+
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// adminStats is the /api/admin/stats response body.
+type adminStats struct {
+	Collection *appqdrant.CollectionInfo `json:"collection"`
+	// CollectionError is set when Qdrant could not be queried.
+	CollectionError string           `json:"collection_error,omitempty"`
+	Seeded          bool             `json:"seeded"`
+	Images          images.DiskUsage `json:"images"`
+	ImageVariants   images.DiskUsage `json:"image_variants"`
+	Caches          adminCacheStats  `json:"caches"`
+}
+
+type adminCacheStats struct {
+	// Search is nil when no response cache is configured.
+	Search  *cache.StatsSnapshot `json:"search"`
+	Filters cache.StatsSnapshot  `json:"filters"`
+}
+
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	stats := adminStats{
+		Seeded: s.seeded == nil || s.seeded(),
+		Caches: adminCacheStats{Filters: s.brands.Stats()},
+	}
+
+	info, err := s.searcher.Info(r.Context())
+	if err != nil {
+		slog.WarnContext(r.Context(), "loading collection info failed", slog.String("error", err.Error()))
+		stats.CollectionError = err.Error()
+	} else {
+		stats.Collection = &info
+	}
+
+	if stats.Images, err = images.Usage(s.imagesDir); err != nil {
+		slog.WarnContext(r.Context(), "counting images failed", slog.String("error", err.Error()))
+	}
+
+	if stats.ImageVariants, err = images.Usage(s.imageCacheDir); err != nil {
+		slog.WarnContext(r.Context(), "counting image variants failed", slog.String("error", err.Error()))
+	}
+
+	if s.cache != nil {
+		search := s.searchStats.Snapshot()
+		stats.Caches.Search = &search
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleAdminFlags returns the feature flag values currently in effect.
+func (s *Server) handleAdminFlags(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"flags": s.flags.All()})
+}
+
+// upsertPhonesRequest is the body of POST /api/admin/phones.
+type upsertPhonesRequest struct {
+	Phones []model.Smartphone `json:"phones"`
+}
+
+func (s *Server) handleUpsertPhones(w http.ResponseWriter, r *http.Request) {
+	var req upsertPhonesRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	v := newValidator(nil)
+	if len(req.Phones) == 0 {
+		v.fail("phones", "must not be empty")
+	}
+
+	for i, p := range req.Phones {
+		for _, reason := range p.Validate() {
+			format, args := reason.Format()
+			v.fail(fmt.Sprintf("phones[%d].%s", i, reason.Field), format, args...)
+		}
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	results, err := s.catalog.Upsert(r.Context(), req.Phones)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "upserting phones failed", slog.String("error", err.Error()))
+		writeCatalogError(w, r, err)
+
+		return
+	}
+
+	s.InvalidateCaches(r.Context())
+
+	phones := make([]model.Smartphone, len(results))
+
+	for i, res := range results {
+		phones[i] = res.Phone
+
+		event := webhook.PhoneUpdated
+		if res.Created {
+			event = webhook.PhoneAdded
+		}
+
+		s.webhooks.Send(r.Context(), event, res.Phone)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"phones": phones})
+}
+
+func (s *Server) handleDeletePhone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequest, "phone id must be a positive integer")
+		return
+	}
+
+	if err := s.catalog.Delete(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "deleting phone failed", slog.Uint64("id", id), slog.String("error", err.Error()))
+		writeCatalogError(w, r, err)
+
+		return
+	}
+
+	s.InvalidateCaches(r.Context())
+	s.webhooks.Send(r.Context(), webhook.PhoneDeleted, map[string]uint64{"id": id})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeCatalogError reports a failed catalog change.
+func writeCatalogError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, embedder.ErrUnavailable) {
+		writeSearchError(w, r, err)
+		return
+	}
+
+	writeProblem(w, r, http.StatusInternalServerError, codeInternal, "updating the catalog failed")
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookie     = "phoneseek_session"
+	minPasswordLength = 8
+	// bcrypt ignores input beyond 72 bytes.
+	maxPasswordLength = 72
+)
+
+// dummyHash is compared against when a login names an unknown email, so both
+// failure paths take the same time.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("phone-seek-dummy-password"), bcrypt.DefaultCost)
+
+// credentials is the body of the register and login endpoints.
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// currentUser returns the user of the session in the cookie or bearer token.
+func (s *Server) currentUser(r *http.Request) (store.User, bool) {
+	if s.store == nil || !s.accounts {
+		return store.User{}, false
+	}
+
+	token := sessionToken(r)
+	if token == "" {
+		return store.User{}, false
+	}
+
+	u, err := s.store.SessionUser(token)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			slog.WarnContext(r.Context(), "loading session failed", slog.String("error", err.Error()))
+		}
+
+		return store.User{}, false
+	}
+
+	return u, true
+}
+
+func sessionToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		return c.Value
+	}
+
+	return ""
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var creds credentials
+	if !s.decodeJSON(w, r, &creds) {
+		return
+	}
+
+	v := newValidator(nil)
+	if _, err := mail.ParseAddress(creds.Email); err != nil || len(creds.Email) > 254 {
+		v.fail("email", "must be a valid email address")
+	}
+
+	if len(creds.Password) < minPasswordLength || len(creds.Password) > maxPasswordLength {
+		v.fail("password", "must be between %d and %d characters", minPasswordLength, maxPasswordLength)
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "hashing password failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "internal server error")
+
+		return
+	}
+
+	u, err := s.store.CreateUser(creds.Email, hash)
+	if errors.Is(err, store.ErrEmailTaken) {
+		writeProblem(w, r, http.StatusConflict, codeEmailTaken, "email is already registered")
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "creating user failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "internal server error")
+
+		return
+	}
+
+	s.startSession(w, r, u, http.StatusCreated)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var creds credentials
+	if !s.decodeJSON(w, r, &creds) {
+		return
+	}
+
+	u, err := s.store.UserByEmail(creds.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		slog.ErrorContext(r.Context(), "loading user failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "internal server error")
+
+		return
+	}
+
+	hash := u.PasswordHash
+	if err != nil {
+		hash = dummyHash
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(creds.Password)) != nil || err != nil {
+		writeProblem(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid email or password")
+		return
+	}
+
+	s.startSession(w, r, u, http.StatusOK)
+}
+
+// startSession issues a session for u, moves any anonymous favorites, saved
+// searches and history to the account and writes the user with the session
+// token.
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, u store.User, status int) {
+	token, err := s.store.CreateSession(u.ID, s.sessionTTL)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "creating session failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "internal server error")
+
+		return
+	}
+
+	if anon, _ := anonymousOwner(r); anon != "" {
+		if err := s.store.MergeFavorites(anon, userOwner(u)); err != nil {
+			slog.WarnContext(r.Context(), "merging favorites failed", slog.String("error", err.Error()))
+		}
+
+		if err := s.store.MergeSavedSearches(anon, userOwner(u)); err != nil {
+			slog.WarnContext(r.Context(), "merging saved searches failed", slog.String("error", err.Error()))
+		}
+
+		if err := s.store.MergePriceWatches(anon, userOwner(u)); err != nil {
+			slog.WarnContext(r.Context(), "merging price watches failed", slog.String("error", err.Error()))
+		}
+
+		if err := s.store.MergeHistory(anon, userOwner(u)); err != nil {
+			slog.WarnContext(r.Context(), "merging history failed", slog.String("error", err.Error()))
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/api/",
+		MaxAge:   int(s.sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	writeJSON(w, status, map[string]any{"user": u, "token": token})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if token := sessionToken(r); token != "" {
+		if err := s.store.DeleteSession(token); err != nil {
+			slog.WarnContext(r.Context(), "deleting session failed", slog.String("error", err.Error()))
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/api/", MaxAge: -1, HttpOnly: true})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.currentUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, codeUnauthorized, "not logged in")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"user": u})
+}
+
+func userOwner(u store.User) string {
+	return "user:" + u.ID
+}
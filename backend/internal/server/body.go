@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/i18n"
+)
+
+const (
+	defaultMaxUploadBytes = 10 << 20
+	defaultMaxJSONBytes   = 1 << 20
+)
+
+// writeTooLarge reports a request body over limit bytes as 413 problem+json.
+func writeTooLarge(w http.ResponseWriter, r *http.Request, format string, limit int64) {
+	detail := i18n.Sprintf(i18n.Lang(r.Context()), format, formatBytes(limit))
+	writeProblem(w, r, http.StatusRequestEntityTooLarge, codePayloadTooLarge, detail)
+}
+
+// decodeJSON reads a JSON request body of at most the configured size into
+// dst. On failure it writes the problem response and returns false.
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxJSONBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeTooLarge(w, r, "request body exceeds the %s limit", tooLarge.Limit)
+			return false
+		}
+
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequest, "request body is not valid JSON")
+
+		return false
+	}
+
+	return true
+}
+
+// formatBytes renders a size limit using the largest whole binary unit.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return strconv.FormatInt(n>>20, 10) + "MB"
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return strconv.FormatInt(n>>10, 10) + "KB"
+	default:
+		return strconv.FormatInt(n, 10) + "B"
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+// brandNewest is how many of a brand's latest phones its overview lists.
+const brandNewest = 5
+
+// priceRange is the span of a set of prices in one currency.
+type priceRange struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Currency string  `json:"currency"`
+}
+
+// handleBrand returns aggregates over one brand's phones: how many are
+// indexed, their price range, their OS families and the newest models.
+func (s *Server) handleBrand(w http.ResponseWriter, r *http.Request) {
+	v := newValidator(r.FormValue)
+	params := s.presentationParams(v)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	overview, err := s.searcher.BrandOverview(r.Context(), model.CanonicalBrand(r.PathValue("brand")), brandNewest)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading brand overview failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	if overview.Phones == 0 {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "brand not found")
+		return
+	}
+
+	var price *priceRange
+
+	if overview.Priced > 0 {
+		cur := params.Currency
+		if cur == "" {
+			cur = currency.EUR
+		}
+
+		price = &priceRange{Currency: cur}
+		price.Min, _ = s.rates.FromEUR(overview.PriceMin, cur)
+		price.Max, _ = s.rates.FromEUR(overview.PriceMax, cur)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"brand":  overview.Brand,
+		"phones": overview.Phones,
+		"priced": overview.Priced,
+		"price":  price,
+		"os":     overview.OS,
+		"newest": params.present(overview.Newest),
+	})
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+// handleCompare scores two phones head-to-head on their numeric specs. The
+// phones are returned in the order of the ids parameter, so side "a" of the
+// comparison is the first id and "b" the second.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	v := newValidator(r.FormValue)
+	ids := v.idList("ids", 2)
+
+	if len(v.errors) == 0 && (len(ids) != 2 || ids[0] == ids[1]) {
+		v.fail("ids", "must contain two different phone ids")
+	}
+
+	params := s.presentationParams(v)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	phones, err := s.searcher.Phones(r.Context(), ids)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading phones failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	byID := make(map[uint64]model.Smartphone, len(phones))
+	for _, p := range phones {
+		byID[p.ID] = p
+	}
+
+	a, okA := byID[ids[0]]
+	b, okB := byID[ids[1]]
+
+	if !okA || !okB {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "phone not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"phones":     params.present([]model.Smartphone{a, b}),
+		"comparison": model.Compare(a, b),
+	})
+}
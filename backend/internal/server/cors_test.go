@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSAnyOriginWithoutCredentials(t *testing.T) {
+	cases := []struct {
+		name        string
+		opts        CORSOptions
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"any origin", CORSOptions{AllowedOrigins: []string{"*"}}, "https://evil.example", "*", ""},
+		{"any origin with credentials", CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}, "https://evil.example", "*", ""},
+		{"listed origin with credentials", CORSOptions{AllowedOrigins: []string{"https://app.example"}, AllowCredentials: true}, "https://app.example", "https://app.example", "true"},
+		{"unlisted origin with credentials", CORSOptions{AllowedOrigins: []string{"https://app.example"}, AllowCredentials: true}, "https://evil.example", "", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Server{}
+			s.Reload(Settings{CORS: tc.opts})
+
+			h := s.corsMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+			req.Header.Set("Origin", tc.origin)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tc.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tc.allowOrigin)
+			}
+
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tc.credentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tc.credentials)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// recentYears is how many years before the newest phone in the catalog
+// count as recent for the phone of the day.
+const recentYears = 2
+
+// dailyPools are the IDs of the phones the phone of the day is drawn from,
+// in ID order, indexed by [available][recent].
+type dailyPools [2][2][]uint64
+
+// loadDailyPools scans the catalog for the candidate phones of each
+// combination of the available and recent filters.
+func (s *Server) loadDailyPools(ctx context.Context) (dailyPools, error) {
+	type candidate struct {
+		id        uint64
+		announced time.Time
+		available bool
+	}
+
+	var (
+		candidates []candidate
+		newest     time.Time
+	)
+
+	for phone, err := range s.searcher.All(ctx) {
+		if err != nil {
+			return dailyPools{}, err
+		}
+
+		c := candidate{
+			id:        phone.ID,
+			announced: phone.Specs().Announced,
+			available: strings.HasPrefix(phone.Status, "Available"),
+		}
+
+		if c.announced.After(newest) {
+			newest = c.announced
+		}
+		candidates = append(candidates, c)
+	}
+
+	var pools dailyPools
+
+	cutoff := newest.AddDate(-recentYears, 0, 0)
+
+	for _, c := range candidates {
+		recent := !c.announced.IsZero() && !c.announced.Before(cutoff)
+
+		pools[0][0] = append(pools[0][0], c.id)
+
+		if c.available {
+			pools[1][0] = append(pools[1][0], c.id)
+		}
+
+		if recent {
+			pools[0][1] = append(pools[0][1], c.id)
+		}
+
+		if c.available && recent {
+			pools[1][1] = append(pools[1][1], c.id)
+		}
+	}
+
+	return pools, nil
+}
+
+// handlePhoneOfTheDay returns the same phone to every caller for a UTC day,
+// drawn from the whole catalog or, with available=true and recent=true, from
+// phones on sale or announced in the last years of the catalog.
+func (s *Server) handlePhoneOfTheDay(w http.ResponseWriter, r *http.Request) {
+	v := newValidator(r.FormValue)
+	params := s.presentationParams(v)
+	available := v.enum("available", boolValues) == "true"
+	recent := v.enum("recent", boolValues) == "true"
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	pools, err := s.daily.Get(r.Context(), s.loadDailyPools)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading phone of the day candidates failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	pool := pools[b2i(available)][b2i(recent)]
+	if len(pool) == 0 {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "phone not found")
+		return
+	}
+
+	date := time.Now().UTC().Format(time.DateOnly)
+
+	// Hashing the date spreads consecutive days across the catalog while
+	// keeping the pick stable across restarts and replicas.
+	h := fnv.New64a()
+	h.Write([]byte(date))
+
+	id := pool[h.Sum64()%uint64(len(pool))]
+
+	phones, err := s.searcher.Phones(r.Context(), []uint64{id})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading phone failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	if len(phones) == 0 {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "phone not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"date":  date,
+		"phone": params.presentOne(phones[0]),
+	})
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
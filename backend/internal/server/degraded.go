@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+	"github.com/alessandrolattao/qdrant-experiment/internal/flags"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// degradedHeader marks searches answered by browseFallback in responses
+// without a JSON envelope, such as NDJSON streams.
+const degradedHeader = "X-Search-Degraded"
+
+// translatedFromHeader carries the language a query was translated from in
+// responses without a JSON envelope.
+const translatedFromHeader = "X-Translated-From"
+
+// browseFallback answers a search that failed with err with the phones
+// matching its filters alone, in ID order, so an embedder outage degrades
+// searches rather than failing them. It reports false when err is not an
+// embedder outage, the fallback is switched off or the browse fails too.
+func (s *Server) browseFallback(ctx context.Context, err error, limit uint64, filters appqdrant.SearchFilters) ([]model.Smartphone, bool) {
+	if !errors.Is(err, embedder.ErrUnavailable) || !s.flags.Enabled(flags.BrowseFallback) {
+		return nil, false
+	}
+
+	phones, browseErr := s.searcher.Matching(ctx, filters, limit)
+	if browseErr != nil {
+		slog.WarnContext(ctx, "browse fallback failed", slog.String("error", browseErr.Error()))
+		return nil, false
+	}
+
+	slog.WarnContext(ctx, "embedder unavailable, serving filter-only results", slog.Int("results", len(phones)))
+
+	return phones, true
+}
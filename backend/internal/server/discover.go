@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// maxContextPairs bounds the context pairs of a discovery request.
+const maxContextPairs = 10
+
+// discoverExample is a phone by id or a text, e.g. {"id": 1234} or
+// {"text": "cheap"}.
+type discoverExample struct {
+	ID   uint64 `json:"id,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+type discoverPair struct {
+	Positive discoverExample `json:"positive"`
+	Negative discoverExample `json:"negative"`
+}
+
+// discoverRequest is the body of POST /api/discover. Limit, filters and
+// presentation are query parameters, as for /api/search.
+type discoverRequest struct {
+	Target  *discoverExample `json:"target"`
+	Context []discoverPair   `json:"context"`
+}
+
+// handleDiscover ranks phones by similarity to the target among those on the
+// positive side of the context pairs, such as "like the S23, in the direction
+// of cheaper, away from heavy phones".
+func (s *Server) handleDiscover(w http.ResponseWriter, r *http.Request) {
+	var req discoverRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	params, v := s.parseSearchParams(r)
+
+	var ids []uint64
+
+	example := func(field string, e discoverExample) appqdrant.Example {
+		if (e.ID == 0) == (e.Text == "") {
+			v.fail(field, "must set either id or text")
+		}
+
+		if e.ID != 0 {
+			ids = append(ids, e.ID)
+		}
+
+		return appqdrant.Example{ID: e.ID, Text: e.Text}
+	}
+
+	var target *appqdrant.Example
+
+	if req.Target != nil {
+		t := example("target", *req.Target)
+		target = &t
+	}
+
+	if len(req.Context) < 1 || len(req.Context) > maxContextPairs {
+		v.fail("context", "must contain between %d and %d items", 1, maxContextPairs)
+	}
+
+	pairs := make([]appqdrant.ContextPair, len(req.Context))
+	for i, p := range req.Context {
+		pairs[i] = appqdrant.ContextPair{
+			Positive: example(fmt.Sprintf("context[%d].positive", i), p.Positive),
+			Negative: example(fmt.Sprintf("context[%d].negative", i), p.Negative),
+		}
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	// Qdrant rejects examples that are not indexed.
+	if ids = dedupIDs(ids); len(ids) > 0 {
+		indexed, err := s.searcher.Phones(r.Context(), ids)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "loading phones failed", slog.String("error", err.Error()))
+			writeSearchError(w, r, err)
+
+			return
+		}
+
+		if len(indexed) != len(ids) {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "phone not found")
+			return
+		}
+	}
+
+	start := time.Now()
+
+	phones, err := s.searcher.Discover(r.Context(), target, pairs, params.Limit, params.Filters)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "discovery failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	phones = nonNil(phones)
+
+	recordResults(r.Context(), len(phones))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": params.present(phones),
+		"total":   len(phones),
+		"time_ms": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// writeJSONWithETag writes data with a weak ETag derived from key, answering
+// 304 Not Modified when the client already holds that version. key should
+// cover only the stable part of the response (e.g. results but not timings).
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, key, data any) {
+	b, err := json.Marshal(key)
+	if err != nil {
+		writeJSON(w, http.StatusOK, data)
+		return
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+	etag := `W/"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, data)
+}
+
+// etagMatches implements the weak comparison used by If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"iter"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+const maxEventsPerRequest = 100
+
+// queryIDHeader carries the analytics ID of a search for responses without a
+// JSON envelope, such as NDJSON streams.
+const queryIDHeader = "X-Query-ID"
+
+var clientEventTypes = []string{analytics.TypeImpression, analytics.TypeClick, analytics.TypeDwell}
+
+// eventsRequest is the body of POST /api/events.
+type eventsRequest struct {
+	Events []clientEvent `json:"events"`
+}
+
+// clientEvent is an interaction with a result of the search identified by
+// QueryID, as returned in the search response.
+type clientEvent struct {
+	Type     string `json:"type"`
+	QueryID  string `json:"query_id"`
+	PhoneID  uint64 `json:"phone_id"`
+	Position int    `json:"position"`
+	DwellMs  int64  `json:"dwell_ms"`
+}
+
+// newQueryID assigns an analytics ID to a search, announcing it in the
+// X-Query-ID header; clients send it back with their interaction events. It
+// returns an empty ID when analytics are disabled.
+func (s *Server) newQueryID(w http.ResponseWriter) string {
+	if s.analytics == nil {
+		return ""
+	}
+
+	id := rand.Text()
+	if w != nil {
+		w.Header().Set(queryIDHeader, id)
+	}
+
+	return id
+}
+
+// logQuery records a completed search in the analytics log.
+func (s *Server) logQuery(ctx context.Context, id, mode, query, language string, filters map[string]string, results []uint64, start time.Time) {
+	if id == "" {
+		return
+	}
+
+	s.analytics.Record(ctx, analytics.Event{
+		Type:      analytics.TypeQuery,
+		QueryID:   id,
+		At:        start,
+		Variant:   variantFrom(ctx),
+		Mode:      mode,
+		Query:     query,
+		Lang:      language,
+		Filters:   filters,
+		Results:   results,
+		LatencyMs: time.Since(start).Milliseconds(),
+	})
+}
+
+// withSearchTags adds the analytics ID of a search and its experiment
+// variant to its response, if any.
+func withSearchTags(ctx context.Context, resp map[string]any, queryID string) map[string]any {
+	if queryID != "" {
+		resp["query_id"] = queryID
+	}
+
+	if v := variantFrom(ctx); v != "" {
+		resp["variant"] = v
+	}
+
+	return resp
+}
+
+// collectIDs passes phones through, appending each phone ID to ids.
+func collectIDs(phones iter.Seq[model.Smartphone], ids *[]uint64) iter.Seq[model.Smartphone] {
+	return func(yield func(model.Smartphone) bool) {
+		for p := range phones {
+			*ids = append(*ids, p.ID)
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// handleEvents records impression, click and dwell events reported by the
+// client for earlier searches.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var req eventsRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	v := newValidator(nil)
+	if len(req.Events) == 0 || len(req.Events) > maxEventsPerRequest {
+		v.fail("events", "must contain between %d and %d items", 1, maxEventsPerRequest)
+	}
+
+	for i, e := range req.Events {
+		field := "events[" + strconv.Itoa(i) + "]"
+
+		switch {
+		case !slices.Contains(clientEventTypes, e.Type):
+			v.fail(field+".type", "must be one of %s", strings.Join(clientEventTypes, ", "))
+		case e.QueryID == "":
+			v.fail(field+".query_id", "must not be empty")
+		case e.PhoneID == 0:
+			v.fail(field+".phone_id", "must be a positive integer")
+		case e.Type == analytics.TypeDwell && e.DwellMs <= 0:
+			v.fail(field+".dwell_ms", "must be a positive integer")
+		}
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	s.recordViews(r, req.Events)
+
+	now := time.Now()
+	variant := variantFrom(r.Context())
+
+	for _, e := range req.Events {
+		s.analytics.Record(r.Context(), analytics.Event{
+			Type:     e.Type,
+			QueryID:  e.QueryID,
+			At:       now,
+			Variant:  variant,
+			PhoneID:  e.PhoneID,
+			Position: e.Position,
+			DwellMs:  e.DwellMs,
+		})
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleAdminCTR returns the latest per-query click-through report.
+func (s *Server) handleAdminCTR(w http.ResponseWriter, r *http.Request) {
+	v := newValidator(r.FormValue)
+	limit := v.intRange("limit", defaultLimit, 1, 1000)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	report := s.analytics.CTR()
+	if report == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"queries": []any{}})
+		return
+	}
+
+	queries := report.Queries[:min(limit, len(report.Queries))]
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"generated_at": report.GeneratedAt,
+		"since":        report.Since,
+		"queries":      queries,
+		"total":        len(report.Queries),
+	})
+}
+
+// summaryWindows are the selectable windows of the analytics summary, with
+// the interval its search volume is split into.
+var summaryWindows = map[string]struct{ span, bucket time.Duration }{
+	"1h":  {time.Hour, 5 * time.Minute},
+	"24h": {24 * time.Hour, time.Hour},
+	"7d":  {7 * 24 * time.Hour, 24 * time.Hour},
+	"30d": {30 * 24 * time.Hour, 24 * time.Hour},
+}
+
+func (s *Server) handleAdminSummary(w http.ResponseWriter, r *http.Request) {
+	v := newValidator(r.FormValue)
+	window := cmp.Or(v.enum("window", []string{"1h", "24h", "7d", "30d"}), "24h")
+	limit := v.intRange("limit", defaultLimit, 1, 100)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	win := summaryWindows[window]
+
+	summary, err := s.analytics.Summary(time.Now().Add(-win.span), win.bucket, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "summarizing analytics failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "summarizing analytics failed")
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// defaultExportWindow is how far back the query log export reaches when no
+// start is given.
+const defaultExportWindow = 7 * 24 * time.Hour
+
+// handleAdminQueryLog exports the searches logged between since and until,
+// joined with the clicks on their results, as NDJSON.
+func (s *Server) handleAdminQueryLog(w http.ResponseWriter, r *http.Request) {
+	v := newValidator(r.FormValue)
+	since := v.timestamp("since", time.Now().Add(-defaultExportWindow))
+	until := v.timestamp("until", time.Time{})
+
+	if !until.IsZero() && !until.After(since) {
+		v.fail("until", "must be after since")
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	records, err := s.analytics.ExportQueries(since, until)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "exporting query log failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "exporting the query log failed")
+
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="queries.ndjson"`)
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/experiment"
+	"github.com/alessandrolattao/qdrant-experiment/internal/flags"
+)
+
+// variantHeader tags search responses with the ranking variant they were
+// served with.
+const variantHeader = "X-Experiment-Variant"
+
+type variantKey struct{}
+
+// withVariant assigns the caller to a variant of the ranking experiment, if
+// one is running. Callers are identified by their account or favorites token
+// so they keep their variant across requests, falling back to the client IP.
+func (s *Server) withVariant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.experiment == nil || !s.flags.Enabled(flags.Experiment) {
+			next(w, r)
+			return
+		}
+
+		unit, _ := s.owner(nil, r, false)
+		if unit == "" {
+			unit = r.RemoteAddr
+			if host, _, err := net.SplitHostPort(unit); err == nil {
+				unit = host
+			}
+		}
+
+		variant := s.experiment.Assign(unit)
+		w.Header().Set(variantHeader, variant)
+
+		next(w, r.WithContext(context.WithValue(r.Context(), variantKey{}, variant)))
+	}
+}
+
+// variantFrom returns the experiment variant assigned to the request, or an
+// empty string outside an experiment.
+func variantFrom(ctx context.Context) string {
+	v, _ := ctx.Value(variantKey{}).(string)
+	return v
+}
+
+// ctrBoostFor returns the CTR boost of the ranking in ctx: the configured
+// boost, unless reranking is switched off or the request was assigned to
+// the dense-only variant.
+func (s *Server) ctrBoostFor(ctx context.Context) float64 {
+	if !s.flags.Enabled(flags.CTRRerank) || variantFrom(ctx) == experiment.RankingDense {
+		return 0
+	}
+
+	return s.settings.Load().ctrBoost
+}
+
+// handleAdminExperiments returns the running experiment and the latest
+// per-variant metrics.
+func (s *Server) handleAdminExperiments(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{
+		"name":     s.experiment.Name(),
+		"variants": nonNil(s.experiment.Variants()),
+		"metrics":  []any{},
+	}
+
+	if report := s.analytics.CTR(); report != nil {
+		resp["generated_at"] = report.GeneratedAt
+		resp["since"] = report.Since
+		resp["metrics"] = nonNil(report.Variants)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"iter"
+	"log/slog"
+	"net/http"
+)
+
+// exportFlushEvery is how many points are written between flushes of an
+// export.
+const exportFlushEvery = 100
+
+// handleAdminExport streams every point of the collection, with its payload
+// as stored and, with vectors=true, its named vectors, as NDJSON. Errors
+// before the first point are reported as problems; later ones can only end
+// the stream early, so clients compare the count with /api/admin/stats.
+func (s *Server) handleAdminExport(w http.ResponseWriter, r *http.Request) {
+	v := newValidator(r.FormValue)
+	withVectors := v.optionalBool("vectors")
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	next, stop := iter.Pull2(s.searcher.Export(r.Context(), withVectors != nil && *withVectors))
+	defer stop()
+
+	point, err, ok := next()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "exporting collection failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "exporting the collection failed")
+
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="phones.ndjson"`)
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	n := 0
+
+	for ; ok; point, err, ok = next() {
+		if err != nil {
+			slog.ErrorContext(r.Context(), "exporting collection failed", slog.Int("points", n), slog.String("error", err.Error()))
+			return
+		}
+
+		if err := enc.Encode(point); err != nil {
+			return
+		}
+
+		if n++; n%exportFlushEvery == 0 {
+			_ = rc.Flush()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) handleListFavorites(w http.ResponseWriter, r *http.Request) {
+	owner, token := s.owner(w, r, false)
+	if owner == "" {
+		writeJSON(w, http.StatusOK, map[string]any{"results": []any{}, "total": 0})
+		return
+	}
+
+	ids, err := s.store.Favorites(owner)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading favorites failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "loading favorites failed")
+
+		return
+	}
+
+	phones, err := s.searcher.Phones(r.Context(), ids)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "hydrating favorites failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	recordResults(r.Context(), len(phones))
+
+	writeJSON(w, http.StatusOK, withToken(map[string]any{
+		"results": phones,
+		"total":   len(phones),
+	}, token))
+}
+
+func (s *Server) handleAddFavorite(w http.ResponseWriter, r *http.Request) {
+	s.updateFavorites(w, r, true)
+}
+
+func (s *Server) handleRemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	s.updateFavorites(w, r, false)
+}
+
+// updateFavorites adds or removes the {id} phone and returns the resulting IDs.
+func (s *Server) updateFavorites(w http.ResponseWriter, r *http.Request, add bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequest, "phone id must be a positive integer")
+		return
+	}
+
+	owner, token := s.owner(w, r, add)
+	if owner == "" {
+		writeJSON(w, http.StatusOK, map[string]any{"ids": []uint64{}})
+		return
+	}
+
+	if add {
+		err = s.store.AddFavorite(owner, id)
+	} else {
+		err = s.store.RemoveFavorite(owner, id)
+	}
+
+	var ids []uint64
+	if err == nil {
+		ids, err = s.store.Favorites(owner)
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "updating favorites failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "updating favorites failed")
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, withToken(map[string]any{"ids": ids}, token))
+}
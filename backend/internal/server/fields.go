@@ -0,0 +1,90 @@
+package server
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+// smartphoneFields maps the JSON names of model.Smartphone to struct field indexes.
+var smartphoneFields = jsonFieldIndexes(reflect.TypeFor[model.Smartphone]())
+
+func jsonFieldIndexes(t reflect.Type) map[string]int {
+	idx := make(map[string]int, t.NumField())
+
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			idx[name] = i
+		}
+	}
+
+	return idx
+}
+
+// smartphoneFieldNames returns the JSON names of model.Smartphone, sorted.
+func smartphoneFieldNames() []string {
+	return slices.Sorted(maps.Keys(smartphoneFields))
+}
+
+// fieldList parses a comma-separated list of smartphone JSON field names.
+// A missing parameter returns nil, meaning all fields.
+func (v *validator) fieldList(field string) []string {
+	val := v.get(field)
+	if val == "" {
+		return nil
+	}
+
+	var fields []string
+
+	for name := range strings.SplitSeq(val, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if _, ok := smartphoneFields[name]; !ok {
+			v.fail(field, "unknown field %q", name)
+			continue
+		}
+
+		fields = append(fields, name)
+	}
+
+	return fields
+}
+
+// projectPhones returns phones unchanged when fields is empty, or a list of
+// objects holding only the requested fields otherwise.
+func projectPhones(phones []model.Smartphone, fields []string) any {
+	if len(fields) == 0 {
+		return phones
+	}
+
+	out := make([]any, len(phones))
+	for i := range phones {
+		out[i] = projectPhone(phones[i], fields)
+	}
+
+	return out
+}
+
+// projectPhone returns phone itself when fields is empty, or an object
+// holding only the requested fields otherwise.
+func projectPhone(phone model.Smartphone, fields []string) any {
+	if len(fields) == 0 {
+		return phone
+	}
+
+	v := reflect.ValueOf(phone)
+	m := make(map[string]any, len(fields))
+
+	for _, name := range fields {
+		m[name] = v.Field(smartphoneFields[name]).Interface()
+	}
+
+	return m
+}
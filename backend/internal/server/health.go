@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds each dependency probe so a hung dependency
+// cannot stall orchestrator health checks.
+const healthCheckTimeout = 2 * time.Second
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+	statusSeeding     = "seeding"
+	statusConnecting  = "connecting"
+)
+
+// probePaths are polled by orchestrators and logged at debug level only.
+var probePaths = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// dependencyHealth is the result of probing one dependency.
+type dependencyHealth struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Points    *uint64 `json:"points,omitempty"`
+}
+
+// healthReport is the /health response body.
+type healthReport struct {
+	Status string                      `json:"status"`
+	Error  string                      `json:"error,omitempty"`
+	Checks map[string]dependencyHealth `json:"checks,omitempty"`
+}
+
+// checkDependencies probes Qdrant and the embedder concurrently.
+func (s *Server) checkDependencies(ctx context.Context) healthReport {
+	checks := map[string]func(context.Context) dependencyHealth{
+		"qdrant":   s.checkQdrant,
+		"embedder": s.checkEmbedder,
+	}
+
+	report := healthReport{Status: statusOK, Checks: make(map[string]dependencyHealth, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for name, check := range checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			result := check(ctx)
+			result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[name] = result
+			if result.Status != statusOK {
+				report.Status = statusUnavailable
+			}
+		})
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (s *Server) checkQdrant(ctx context.Context) dependencyHealth {
+	status, err := s.searcher.Status(ctx)
+	if err != nil {
+		return dependencyHealth{Status: statusUnavailable, Error: err.Error()}
+	}
+
+	if !status.Exists {
+		return dependencyHealth{Status: statusUnavailable, Error: "collection not found"}
+	}
+
+	return dependencyHealth{Status: statusOK, Points: &status.Points}
+}
+
+func (s *Server) checkEmbedder(ctx context.Context) dependencyHealth {
+	if err := s.searcher.EmbedderHealth(ctx); err != nil {
+		return dependencyHealth{Status: statusUnavailable, Error: err.Error()}
+	}
+
+	return dependencyHealth{Status: statusOK}
+}
+
+// handleHealth reports per-dependency status and latency, answering 503 when
+// any dependency is unavailable.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	report := s.checkDependencies(r.Context())
+
+	code := http.StatusOK
+	if report.Status != statusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, report)
+}
+
+// handleLiveness reports that the process is up and serving requests,
+// without touching any dependency.
+func (s *Server) handleLiveness(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// handleReadiness answers 200 only once Qdrant was reached, the collection
+// is seeded and all dependencies respond, so traffic is not routed to an
+// instance that would return empty or failing searches.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if s.connected != nil {
+		if err := s.connected(); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, healthReport{Status: statusConnecting, Error: err.Error()})
+			return
+		}
+	}
+
+	if s.seeded != nil && !s.seeded() {
+		writeJSON(w, http.StatusServiceUnavailable, healthReport{Status: statusSeeding})
+		return
+	}
+
+	s.handleHealth(w, r)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+)
+
+const defaultHistoryLimit = 50
+
+// recordHistory adds a successful text query to the caller's search history.
+// Nothing is recorded unless the caller enabled history with PUT /api/history.
+func (s *Server) recordHistory(r *http.Request, query string) {
+	if s.store == nil || s.historyTTL <= 0 {
+		return
+	}
+
+	owner, _ := s.owner(nil, r, false)
+	if owner == "" {
+		return
+	}
+
+	e := store.HistoryEntry{Query: query, Filters: filterValues(r.FormValue), At: time.Now().UTC()}
+	if err := s.store.AddHistory(owner, e, s.historyTTL, s.historyLimit); err != nil {
+		slog.WarnContext(r.Context(), "recording search history failed", slog.String("error", err.Error()))
+	}
+}
+
+// handleHistory returns the caller's recent queries, newest first.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	owner, token := s.owner(w, r, false)
+	if owner == "" {
+		writeJSON(w, http.StatusOK, map[string]any{"enabled": false, "entries": []any{}})
+		return
+	}
+
+	entries, enabled, err := s.store.History(owner, s.historyTTL)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading search history failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "loading search history failed")
+
+		return
+	}
+
+	if entries == nil {
+		entries = []store.HistoryEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, withToken(map[string]any{"enabled": enabled, "entries": entries}, token))
+}
+
+// handleEnableHistory opts the caller in to search history.
+func (s *Server) handleEnableHistory(w http.ResponseWriter, r *http.Request) {
+	owner, token := s.owner(w, r, true)
+
+	if err := s.store.EnableHistory(owner); err != nil {
+		slog.ErrorContext(r.Context(), "enabling search history failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "updating search history failed")
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, withToken(map[string]any{"enabled": true}, token))
+}
+
+// handleDisableHistory opts the caller out and forgets their history.
+func (s *Server) handleDisableHistory(w http.ResponseWriter, r *http.Request) {
+	owner, _ := s.owner(w, r, false)
+	if owner != "" {
+		if err := s.store.DisableHistory(owner); err != nil {
+			slog.ErrorContext(r.Context(), "disabling search history failed", slog.String("error", err.Error()))
+			writeProblem(w, r, http.StatusInternalServerError, codeInternal, "updating search history failed")
+
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	idempotencyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// idempotentResponse is the outcome of the first request seen with a key.
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// idempotencyStore remembers responses to mutating requests by their
+// Idempotency-Key for a short time, so retried requests are answered from
+// the first result instead of being applied again. Entries live in memory
+// and are therefore per instance.
+type idempotencyStore struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	return &idempotencyStore{ttl: ttl, entries: map[string]*idempotentResponse{}}
+}
+
+// begin returns a copy of the entry stored for key, or reserves key for a new
+// request and reports reserved.
+func (st *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte) (idempotentResponse, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+
+	for k, e := range st.entries {
+		if e.done && now.After(e.expires) {
+			delete(st.entries, k)
+		}
+	}
+
+	if e, ok := st.entries[key]; ok {
+		return *e, false
+	}
+
+	st.entries[key] = &idempotentResponse{fingerprint: fingerprint}
+
+	return idempotentResponse{}, true
+}
+
+// finish stores the response for a reserved key.
+func (st *idempotencyStore) finish(key string, status int, contentType string, body []byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if e, ok := st.entries[key]; ok {
+		e.done = true
+		e.status = status
+		e.contentType = contentType
+		e.body = body
+		e.expires = time.Now().Add(st.ttl)
+	}
+}
+
+// abandon releases a reserved key so the request can be retried.
+func (st *idempotencyStore) abandon(key string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.entries, key)
+}
+
+// responseCapture forwards a response while keeping a copy of it.
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// idempotent makes next safe to retry with an Idempotency-Key header: the
+// first response for a key is replayed to later requests with the same key
+// and body. Reusing a key for a different request is rejected, as is a retry
+// while the first attempt is still running. Server errors and panics are not
+// stored so the request can be retried.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLen {
+			writeProblem(w, r, http.StatusBadRequest, codeInvalidRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxJSONBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeTooLarge(w, r, "request body exceeds the %s limit", tooLarge.Limit)
+				return
+			}
+
+			writeProblem(w, r, http.StatusBadRequest, codeInvalidRequest, "reading the request body failed")
+
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+
+		prev, reserved := s.idempotency.begin(key, fingerprint)
+
+		switch {
+		case reserved:
+		case prev.fingerprint != fingerprint:
+			writeProblem(w, r, http.StatusUnprocessableEntity, codeIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+			return
+		case !prev.done:
+			writeProblem(w, r, http.StatusConflict, codeIdempotencyInProgress, "a request with this Idempotency-Key is still in progress")
+			return
+		default:
+			if prev.contentType != "" {
+				w.Header().Set("Content-Type", prev.contentType)
+			}
+
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(prev.status)
+			_, _ = w.Write(prev.body)
+
+			return
+		}
+
+		rec := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+
+		// A panicking handler wrote no response worth replaying: the key is
+		// released and the panic goes on to the recovery middleware.
+		returned := false
+
+		defer func() {
+			if !returned {
+				s.idempotency.abandon(key)
+			}
+		}()
+
+		next(rec, r)
+
+		returned = true
+
+		if rec.status >= http.StatusInternalServerError {
+			s.idempotency.abandon(key)
+			return
+		}
+
+		s.idempotency.finish(key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes())
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// errOverloaded is returned when both the concurrency slots and the wait
+// queue are full.
+var errOverloaded = errors.New("too many concurrent searches")
+
+// limiter bounds concurrent embed+search operations. Requests beyond the
+// slot count wait in a queue of bounded depth; anything beyond that is shed
+// immediately rather than piling up on the embedder. A nil limiter admits
+// everything.
+type limiter struct {
+	slots    chan struct{}
+	waiting  atomic.Int64
+	maxQueue int64
+}
+
+// newLimiter returns a limiter allowing maxConcurrent operations and
+// maxQueue waiters, or nil when maxConcurrent is not positive.
+func newLimiter(maxConcurrent, maxQueue int) *limiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+
+	return &limiter{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: int64(max(maxQueue, 0)),
+	}
+}
+
+// acquire takes a slot, waiting in the queue if there is room. The returned
+// function releases the slot.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	if l.waiting.Add(1) > l.maxQueue {
+		l.waiting.Add(-1)
+		return nil, errOverloaded
+	}
+	defer l.waiting.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *limiter) release() {
+	<-l.slots
+}
+
+// limitSearch runs next while holding a search slot, answering 503 with
+// Retry-After when the server is saturated.
+func (s *Server) limitSearch(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		release, err := s.settings.Load().limiter.acquire(r.Context())
+		if err != nil {
+			// A cancelled wait means the client went away; there is no one to answer.
+			if errors.Is(err, errOverloaded) {
+				writeSearchError(w, r, err)
+			}
+
+			return
+		}
+		defer release()
+
+		next(w, r)
+	}
+}
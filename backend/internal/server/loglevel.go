@@ -0,0 +1,51 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/logging"
+)
+
+// logLevelBody is the body of GET and PUT /api/admin/log-level.
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// handleAdminLogLevel returns the minimum level logged.
+func (s *Server) handleAdminLogLevel(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, logLevelBody{Level: logging.LevelName(s.logLevel.Level())})
+}
+
+// handleSetLogLevel changes the minimum level logged until the next restart.
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req logLevelBody
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	v := newValidator(nil)
+
+	level, err := logging.ParseLevel(req.Level)
+	switch {
+	case req.Level == "":
+		v.fail("level", "is required")
+	case err != nil:
+		v.fail("level", "must be one of %s", strings.Join(logging.Levels, ", "))
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	previous := s.logLevel.Level()
+	s.logLevel.Set(level)
+
+	// Logged at warn so the change shows whatever the new level.
+	slog.WarnContext(r.Context(), "log level changed",
+		slog.String("from", logging.LevelName(previous)), slog.String("to", logging.LevelName(level)))
+
+	writeJSON(w, http.StatusOK, logLevelBody{Level: logging.LevelName(level)})
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Bounds of PUT /api/admin/maintenance. The default Retry-After is sent
+// when the admin set none.
+const (
+	defaultMaintenanceRetryAfter = 120
+	maxMaintenanceRetryAfter     = 86400
+	maxMaintenanceMessage        = 200
+)
+
+// maintenanceState is the body of GET and PUT /api/admin/maintenance.
+type maintenanceState struct {
+	Enabled bool `json:"enabled"`
+	// Message replaces the problem detail of the rejected requests.
+	Message           string     `json:"message,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	Since             *time.Time `json:"since,omitempty"`
+}
+
+// underMaintenance reports whether requests to path are rejected during
+// maintenance. The admin endpoints stay up to end it, images are static
+// files, and the health probes and the frontend live outside /api/.
+func underMaintenance(path string) bool {
+	return strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/api/admin/") && !strings.HasPrefix(path, "/api/images/")
+}
+
+// maintenanceMiddleware answers API requests with 503 while maintenance
+// mode is on, e.g. while the collection is reindexed in place.
+func (s *Server) maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := s.maintenance.Load()
+		if m == nil || !underMaintenance(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(m.RetryAfterSeconds))
+		writeProblem(w, r, http.StatusServiceUnavailable, codeMaintenance,
+			cmp.Or(m.Message, "the service is under maintenance, try again later"))
+	})
+}
+
+// handleAdminMaintenance returns whether maintenance mode is on.
+func (s *Server) handleAdminMaintenance(w http.ResponseWriter, _ *http.Request) {
+	m := s.maintenance.Load()
+	if m == nil {
+		m = &maintenanceState{}
+	}
+
+	writeJSON(w, http.StatusOK, m)
+}
+
+// handleSetMaintenance turns maintenance mode on or off until the next
+// restart. Each server instance has its own mode.
+func (s *Server) handleSetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req maintenanceState
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	v := newValidator(nil)
+
+	if req.RetryAfterSeconds < 0 || req.RetryAfterSeconds > maxMaintenanceRetryAfter {
+		v.fail("retry_after_seconds", "must be between %d and %d", 0, maxMaintenanceRetryAfter)
+	}
+
+	if utf8.RuneCountInString(req.Message) > maxMaintenanceMessage {
+		v.fail("message", "must be at most %d characters", maxMaintenanceMessage)
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	if !req.Enabled {
+		if s.maintenance.Swap(nil) != nil {
+			slog.WarnContext(r.Context(), "maintenance mode disabled")
+		}
+
+		writeJSON(w, http.StatusOK, maintenanceState{})
+
+		return
+	}
+
+	since := time.Now().UTC()
+	if previous := s.maintenance.Load(); previous != nil {
+		since = *previous.Since
+	}
+
+	m := &maintenanceState{
+		Enabled:           true,
+		Message:           strings.TrimSpace(req.Message),
+		RetryAfterSeconds: cmp.Or(req.RetryAfterSeconds, defaultMaintenanceRetryAfter),
+		Since:             &since,
+	}
+	s.maintenance.Store(m)
+
+	slog.WarnContext(r.Context(), "maintenance mode enabled", slog.Int("retry_after_seconds", m.RetryAfterSeconds))
+
+	writeJSON(w, http.StatusOK, m)
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/i18n"
+	"github.com/alessandrolattao/qdrant-experiment/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/alessandrolattao/qdrant-experiment/internal/server")
+
+const maxRequestIDLen = 128
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requestLog collects per-request details filled in by handlers.
+type requestLog struct {
+	results int
+}
+
+type requestLogKey struct{}
+
+// recordResults stores the number of results returned for the access log.
+func recordResults(ctx context.Context, n int) {
+	if l, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		l.results = n
+	}
+}
+
+// requestIDMiddleware propagates the incoming X-Request-ID or generates a new one,
+// echoes it on the response and stores it in the request context.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(logging.RequestIDHeader)
+		if !validRequestID(id) {
+			id = rand.Text()
+		}
+
+		w.Header().Set(logging.RequestIDHeader, id)
+
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// tracingMiddleware starts a server span per request, continuing any trace
+// propagated by the caller. Spans are named after the matched route pattern.
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := s.mux.Handler(r)
+		if pattern == "" {
+			pattern = r.Method + " unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, pattern,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.HTTPRoute(pattern),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// loggingMiddleware logs method, path, status, latency and result count for every request.
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		entry := &requestLog{results: -1}
+
+		ctx := context.WithValue(r.Context(), requestLogKey{}, entry)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+		}
+
+		if entry.results >= 0 {
+			attrs = append(attrs, slog.Int("results", entry.results))
+		}
+
+		level := slog.LevelInfo
+		if probePaths[r.URL.Path] {
+			level = slog.LevelDebug
+		}
+
+		slog.LogAttrs(ctx, level, "request", attrs...)
+	})
+}
+
+// recoveryMiddleware turns handler panics into a logged stack trace and a
+// 500 problem response instead of a dropped connection.
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+
+			if v == http.ErrAbortHandler { //nolint:errorlint // sentinel panic value, not a wrapped error
+				panic(v)
+			}
+
+			slog.ErrorContext(r.Context(), "handler panic",
+				slog.String("panic", fmt.Sprint(v)),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			if rec.status == 0 {
+				writeProblem(rec, r, http.StatusInternalServerError, codeInternal, "internal server error")
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := range len(id) {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CORSOptions configures cross-origin access to the API.
+type CORSOptions struct {
+	// AllowedOrigins lists origins allowed to call the API; "*" allows any
+	// origin without credentials, even with AllowCredentials set.
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// languageMiddleware negotiates the response language from Accept-Language
+// and stores it in the request context.
+func languageMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", lang)
+
+		next.ServeHTTP(w, r.WithContext(i18n.WithLang(r.Context(), lang)))
+	})
+}
+
+// corsPolicy is a CORSOptions with its response header values precomputed.
+type corsPolicy struct {
+	CORSOptions
+	allowAny       bool
+	allowedHeaders string
+	maxAge         string
+}
+
+func newCORSPolicy(opts CORSOptions) *corsPolicy {
+	return &corsPolicy{
+		CORSOptions:    opts,
+		allowAny:       slices.Contains(opts.AllowedOrigins, "*"),
+		allowedHeaders: strings.Join(append([]string{"Content-Type", logging.RequestIDHeader, visitorHeader, apiKeyHeader}, opts.AllowedHeaders...), ", "),
+		maxAge:         strconv.Itoa(int(opts.MaxAge.Seconds())),
+	}
+}
+
+// corsMiddleware applies the current CORS policy and answers preflight
+// requests.
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts := s.settings.Load().cors
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+
+		h.Add("Vary", "Origin")
+
+		switch {
+		case origin == "":
+		case opts.allowAny:
+			// Never with credentials, which would let any site act with
+			// the session cookie or bearer token of a visitor.
+			h.Set("Access-Control-Allow-Origin", "*")
+		case slices.Contains(opts.AllowedOrigins, origin):
+			h.Set("Access-Control-Allow-Origin", origin)
+
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+		default:
+			origin = ""
+		}
+
+		if origin != "" {
+			h.Set("Access-Control-Expose-Headers", logging.RequestIDHeader+", ETag, "+dailyRemainingHeader+", "+monthlyRemainingHeader)
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if origin != "" {
+				h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				h.Set("Access-Control-Allow-Headers", opts.allowedHeaders)
+
+				if opts.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", opts.maxAge)
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
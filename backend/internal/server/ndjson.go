@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"iter"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	// maxStreamLimit bounds NDJSON exports, which may be larger than UI pages.
+	maxStreamLimit = 1000
+)
+
+// wantsNDJSON reports whether the client asked for newline-delimited JSON,
+// either with ?format=ndjson or an Accept header listing application/x-ndjson.
+func wantsNDJSON(r *http.Request) bool {
+	if r.FormValue("format") == "ndjson" {
+		return true
+	}
+
+	for part := range strings.SplitSeq(r.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mt == ndjsonContentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeNDJSON streams one JSON object per line as rendered by present,
+// flushing after each result. It returns the number of results written.
+func writeNDJSON(w http.ResponseWriter, phones iter.Seq[model.Smartphone], present func(model.Smartphone) any) int {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	n := 0
+
+	for phone := range phones {
+		if err := enc.Encode(present(phone)); err != nil {
+			return n
+		}
+
+		_ = rc.Flush()
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/rand"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Visitors without an account are identified by a random token that the
+// client keeps in a cookie or, for non-browser clients, sends in a header.
+// The names predate saved searches, which share the token with favorites.
+const (
+	visitorCookie = "phoneseek_favorites"
+	visitorHeader = "X-Favorites-Token"
+	visitorMaxAge = 365 * 24 * time.Hour
+)
+
+var visitorTokenRe = regexp.MustCompile(`^[A-Za-z0-9_-]{16,64}$`)
+
+// owner returns the store owner key of the caller's favorites and saved
+// searches: the logged-in account, or else an anonymous token. With create
+// set, an anonymous caller without a token is issued one in a cookie;
+// otherwise owner is empty. token is empty for accounts.
+func (s *Server) owner(w http.ResponseWriter, r *http.Request, create bool) (owner, token string) {
+	if u, ok := s.currentUser(r); ok {
+		return userOwner(u), ""
+	}
+
+	owner, token = anonymousOwner(r)
+	if owner != "" || !create {
+		return owner, token
+	}
+
+	token = rand.Text()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     visitorCookie,
+		Value:    token,
+		Path:     "/api/",
+		MaxAge:   int(visitorMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return "anon:" + token, token
+}
+
+// anonymousOwner returns the owner key for a valid anonymous token
+// in the header or cookie, or empty strings.
+func anonymousOwner(r *http.Request) (owner, token string) {
+	token = r.Header.Get(visitorHeader)
+	if token == "" {
+		if c, err := r.Cookie(visitorCookie); err == nil {
+			token = c.Value
+		}
+	}
+
+	if !visitorTokenRe.MatchString(token) {
+		return "", ""
+	}
+
+	return "anon:" + token, token
+}
+
+// withToken adds the anonymous visitor token to a response, if any.
+func withToken(resp map[string]any, token string) map[string]any {
+	if token != "" {
+		resp["token"] = token
+	}
+
+	return resp
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// relatedLimit is how many similar and also-viewed phones a detail response
+// carries.
+const relatedLimit = 6
+
+// handlePhone returns one phone with its vector-similar phones and, when
+// analytics are enabled, the phones users also viewed from the same searches.
+func (s *Server) handlePhone(w http.ResponseWriter, r *http.Request) {
+	params, ok := s.detailParams(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequest, "phone id must be a positive integer")
+		return
+	}
+
+	phones, err := s.searcher.Phones(r.Context(), []uint64{id})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading phone failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	if len(phones) == 0 {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "phone not found")
+		return
+	}
+
+	s.writePhoneDetail(w, r, phones[0], params)
+}
+
+// handlePhoneBySlug is handlePhone addressed by the phone's URL slug.
+func (s *Server) handlePhoneBySlug(w http.ResponseWriter, r *http.Request) {
+	params, ok := s.detailParams(w, r)
+	if !ok {
+		return
+	}
+
+	phone, found, err := s.searcher.PhoneBySlug(r.Context(), strings.ToLower(r.PathValue("slug")))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading phone failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	if !found {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "phone not found")
+		return
+	}
+
+	s.writePhoneDetail(w, r, phone, params)
+}
+
+// handleCount returns how many phones match the /api/search filters, so
+// clients can show the size of a filtered set before searching it.
+func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
+	params, v := s.parseSearchParams(r)
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	n, err := s.searcher.Count(r.Context(), params.Filters)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "counting phones failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"count": n})
+}
+
+// handleRandomPhones returns phones sampled at random among those matching
+// the /api/search filters, one unless limit asks for more.
+func (s *Server) handleRandomPhones(w http.ResponseWriter, r *http.Request) {
+	params, v := s.parseSearchParams(r)
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	if r.FormValue("limit") == "" {
+		params.Limit = 1
+	}
+
+	phones, err := s.searcher.Random(r.Context(), params.Limit, params.Filters)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "sampling phones failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	// Every call differs, so the response must not be cached.
+	w.Header().Set("Cache-Control", "no-store")
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": params.present(nonNil(phones)),
+		"total":   len(phones),
+	})
+}
+
+// detailParams validates the presentation parameters of the phone
+// detail endpoints, writing the problem response when they are invalid.
+func (s *Server) detailParams(w http.ResponseWriter, r *http.Request) (searchParams, bool) {
+	v := newValidator(r.FormValue)
+	params := s.presentationParams(v)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return params, false
+	}
+
+	return params, true
+}
+
+// presentationParams validates the currency, units and schema parameters of
+// endpoints that return phones without searching.
+func (s *Server) presentationParams(v *validator) searchParams {
+	return searchParams{
+		Currency: v.enum("currency", currency.Supported),
+		Units:    v.enum("units", unitSystems),
+		Schema:   v.schema(nil),
+		rates:    s.rates,
+	}
+}
+
+// writePhoneDetail writes phone with its similar and also-viewed phones,
+// in the currency and units of params.
+func (s *Server) writePhoneDetail(w http.ResponseWriter, r *http.Request, phone model.Smartphone, params searchParams) {
+	id := phone.ID
+
+	similar, err := s.searcher.Recommend(r.Context(), []uint64{id}, nil, relatedLimit, appqdrant.SearchFilters{})
+	if err != nil {
+		slog.WarnContext(r.Context(), "loading similar phones failed", slog.String("error", err.Error()))
+	}
+
+	coViews := s.analytics.CoViews().AlsoViewed(id, relatedLimit)
+
+	ids := make([]uint64, len(coViews))
+	for i, cv := range coViews {
+		ids[i] = cv.ID
+	}
+
+	alsoViewed, err := s.searcher.Phones(r.Context(), ids)
+	if err != nil {
+		slog.WarnContext(r.Context(), "loading also viewed phones failed", slog.String("error", err.Error()))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"phone":       params.presentOne(phone),
+		"similar":     params.present(nonNil(similar)),
+		"also_viewed": params.present(nonNil(alsoViewed)),
+	})
+}
+
+// nonNil returns an empty slice for nil so it encodes as [].
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
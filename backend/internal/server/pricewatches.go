@@ -0,0 +1,378 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+	"github.com/alessandrolattao/qdrant-experiment/internal/webhook"
+)
+
+// priceWatchRequest is the body of POST /api/price-watches. A watch targets
+// either one phone or the results of a query and filter set; Params holds the
+// same filter parameters accepted by /api/search.
+type priceWatchRequest struct {
+	PhoneID  uint64            `json:"phone_id"`
+	Query    string            `json:"q"`
+	Params   map[string]string `json:"params"`
+	Target   float64           `json:"target"`
+	Currency string            `json:"currency"`
+	Email    bool              `json:"email"`
+}
+
+// priceWatchView is a price watch as returned to its owner.
+type priceWatchView struct {
+	ID          string            `json:"id"`
+	PhoneID     uint64            `json:"phone_id,omitempty"`
+	Query       string            `json:"q,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	TargetEUR   float64           `json:"target_eur"`
+	Email       bool              `json:"email"`
+	CreatedAt   time.Time         `json:"created_at"`
+	TriggeredAt time.Time         `json:"triggered_at,omitzero"`
+}
+
+func newPriceWatchView(pw store.PriceWatch) priceWatchView {
+	return priceWatchView{
+		ID:          pw.ID,
+		PhoneID:     pw.PhoneID,
+		Query:       pw.Query,
+		Params:      pw.Params,
+		TargetEUR:   pw.TargetEUR,
+		Email:       pw.Email,
+		CreatedAt:   pw.CreatedAt,
+		TriggeredAt: pw.TriggeredAt,
+	}
+}
+
+func (s *Server) handleCreatePriceWatch(w http.ResponseWriter, r *http.Request) {
+	var req priceWatchRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	req.Query = strings.TrimSpace(req.Query)
+
+	_, v := s.savedSearchParams(req.Params)
+	checkQuery(v, req.Query)
+
+	switch filterSet := req.Query != "" || len(req.Params) > 0; {
+	case req.PhoneID == 0 && !filterSet:
+		v.fail("phone_id", "must be set unless q or params are")
+	case req.PhoneID != 0 && filterSet:
+		v.fail("phone_id", "cannot be combined with q or params")
+	}
+
+	cur := req.Currency
+	if cur == "" {
+		cur = currency.EUR
+	}
+
+	targetEUR, ok := s.rates.ToEUR(req.Target, cur)
+
+	switch {
+	case !ok:
+		v.fail("currency", "must be one of %s", strings.Join(currency.Supported, ", "))
+	case req.Target <= 0 || targetEUR <= 0:
+		v.fail("target", "must be a positive number")
+	}
+
+	if _, loggedIn := s.currentUser(r); req.Email && !loggedIn {
+		v.fail("email", "requires logging in")
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	if req.PhoneID != 0 {
+		phones, err := s.searcher.Phones(r.Context(), []uint64{req.PhoneID})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "loading phone failed", slog.String("error", err.Error()))
+			writeSearchError(w, r, err)
+
+			return
+		}
+
+		if len(phones) == 0 {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "phone not found")
+			return
+		}
+	}
+
+	owner, token := s.owner(w, r, true)
+
+	pw, err := s.store.SavePriceWatch(owner, store.PriceWatch{
+		PhoneID:   req.PhoneID,
+		Query:     req.Query,
+		Params:    req.Params,
+		TargetEUR: targetEUR,
+		Email:     req.Email,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "saving price watch failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "saving the price watch failed")
+
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, withToken(map[string]any{"watch": newPriceWatchView(pw)}, token))
+}
+
+func (s *Server) handleListPriceWatches(w http.ResponseWriter, r *http.Request) {
+	owner, token := s.owner(w, r, false)
+	if owner == "" {
+		writeJSON(w, http.StatusOK, map[string]any{"watches": []any{}})
+		return
+	}
+
+	watches, err := s.store.PriceWatches(owner)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading price watches failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "loading price watches failed")
+
+		return
+	}
+
+	views := make([]priceWatchView, len(watches))
+	for i, pw := range watches {
+		views[i] = newPriceWatchView(pw)
+	}
+
+	writeJSON(w, http.StatusOK, withToken(map[string]any{"watches": views}, token))
+}
+
+func (s *Server) handleDeletePriceWatch(w http.ResponseWriter, r *http.Request) {
+	owner, _ := s.owner(w, r, false)
+
+	err := store.ErrNotFound
+	if owner != "" {
+		err = s.store.DeletePriceWatch(owner, r.PathValue("id"))
+	}
+
+	if errors.Is(err, store.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "price watch not found")
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "deleting price watch failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "deleting the price watch failed")
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// watchedPrice is a phone reported by a triggered price watch.
+type watchedPrice struct {
+	ID       uint64  `json:"id"`
+	Brand    string  `json:"brand"`
+	Model    string  `json:"model"`
+	PriceEUR float64 `json:"price_eur"`
+}
+
+// CheckPriceWatches evaluates every price watch against the current
+// price_eur of the indexed phones, after a reseed and periodically. A phone
+// watch fires when its phone's price changes to at or below the target; a
+// filter watch fires when phones not reported before match it at or below
+// the target. Triggered watches send a price_watch.triggered webhook and,
+// when requested, an email to the owner's account.
+func (s *Server) CheckPriceWatches(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+
+	watches, err := s.store.AllPriceWatches()
+	if err != nil {
+		slog.ErrorContext(ctx, "loading price watches failed", slog.String("error", err.Error()))
+		return
+	}
+
+	triggered := 0
+
+	for _, owned := range watches {
+		if ctx.Err() != nil {
+			return
+		}
+
+		pw := owned.PriceWatch
+
+		matches, err := s.checkPriceWatch(ctx, &pw)
+		if err != nil {
+			slog.WarnContext(ctx, "checking price watch failed", slog.String("watch_id", pw.ID), slog.String("error", err.Error()))
+			continue
+		}
+
+		pw.CheckedAt = time.Now().UTC()
+		if len(matches) > 0 {
+			pw.TriggeredAt = pw.CheckedAt
+		}
+
+		if _, err := s.store.SavePriceWatch(owned.Owner, pw); err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				slog.WarnContext(ctx, "recording price watch failed", slog.String("watch_id", pw.ID), slog.String("error", err.Error()))
+			}
+
+			continue
+		}
+
+		if len(matches) == 0 {
+			continue
+		}
+
+		triggered++
+
+		s.notifyPriceWatch(ctx, owned.Owner, pw, matches)
+	}
+
+	slog.InfoContext(ctx, "price watches checked", slog.Int("watches", len(watches)), slog.Int("triggered", triggered))
+}
+
+// RunPriceWatches calls CheckPriceWatches every interval until ctx is
+// cancelled, catching price changes made through the admin API between
+// reseeds.
+func (s *Server) RunPriceWatches(ctx context.Context, interval time.Duration) {
+	if s.store == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckPriceWatches(ctx)
+		}
+	}
+}
+
+// checkPriceWatch updates pw with the current prices and returns the phones
+// it fires for.
+func (s *Server) checkPriceWatch(ctx context.Context, pw *store.PriceWatch) ([]watchedPrice, error) {
+	if pw.PhoneID != 0 {
+		phones, err := s.searcher.Phones(ctx, []uint64{pw.PhoneID})
+		if err != nil || len(phones) == 0 || phones[0].PriceNormalized == nil {
+			return nil, err
+		}
+
+		phone := phones[0]
+		price := phone.PriceNormalized.Amount
+		last := pw.LastPriceEUR
+		pw.LastPriceEUR = price
+
+		if price > pw.TargetEUR || price == last {
+			return nil, nil
+		}
+
+		return []watchedPrice{newWatchedPrice(phone)}, nil
+	}
+
+	params, _ := s.savedSearchParams(pw.Params)
+
+	filters := params.Filters
+	if filters.PriceMax == 0 || filters.PriceMax > pw.TargetEUR {
+		filters.PriceMax = pw.TargetEUR
+	}
+
+	var (
+		phones []model.Smartphone
+		err    error
+	)
+
+	if pw.Query != "" {
+		phones, err = s.searcher.SearchByText(ctx, pw.Query, params.Limit, filters)
+	} else {
+		phones, err = s.searcher.Matching(ctx, filters, params.Limit)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := appendUnseen(nil, phones, pw.MatchedIDs)
+	pw.MatchedIDs = phoneIDs(phones)
+
+	matches := make([]watchedPrice, 0, len(fresh))
+
+	for _, p := range phones {
+		if slices.Contains(fresh, p.ID) {
+			matches = append(matches, newWatchedPrice(p))
+		}
+	}
+
+	return matches, nil
+}
+
+func newWatchedPrice(p model.Smartphone) watchedPrice {
+	wp := watchedPrice{ID: p.ID, Brand: p.Brand, Model: p.Model}
+	if p.PriceNormalized != nil {
+		wp.PriceEUR = p.PriceNormalized.Amount
+	}
+
+	return wp
+}
+
+// notifyPriceWatch announces a triggered watch by webhook and, when the
+// watch asks for it, by email to the owning account.
+func (s *Server) notifyPriceWatch(ctx context.Context, owner string, pw store.PriceWatch, matches []watchedPrice) {
+	data := map[string]any{"id": pw.ID, "target_eur": pw.TargetEUR, "phones": matches}
+	if pw.PhoneID != 0 {
+		data["phone_id"] = pw.PhoneID
+	}
+
+	userID, isUser := strings.CutPrefix(owner, "user:")
+	if isUser {
+		data["user_id"] = userID
+	}
+
+	s.webhooks.Send(ctx, webhook.PriceWatchTriggered, data)
+
+	if !pw.Email || !isUser || s.mailer == nil {
+		return
+	}
+
+	u, err := s.store.User(userID)
+	if err != nil {
+		slog.WarnContext(ctx, "loading price watch owner failed", slog.String("watch_id", pw.ID), slog.String("error", err.Error()))
+		return
+	}
+
+	subject, body := priceAlertEmail(pw, matches)
+	if err := s.mailer.Send(u.Email, subject, body); err != nil {
+		slog.WarnContext(ctx, "sending price alert failed", slog.String("watch_id", pw.ID), slog.String("error", err.Error()))
+	}
+}
+
+// priceAlertEmail renders the subject and plain-text body of a price alert.
+func priceAlertEmail(pw store.PriceWatch, matches []watchedPrice) (subject, body string) {
+	if len(matches) == 1 {
+		m := matches[0]
+		subject = fmt.Sprintf("Price alert: %s %s is now %.2f EUR", m.Brand, m.Model, m.PriceEUR)
+	} else {
+		subject = fmt.Sprintf("Price alert: %d phones at or below %.2f EUR", len(matches), pw.TargetEUR)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Your price watch with a target of %.2f EUR was triggered:\n\n", pw.TargetEUR)
+
+	for _, m := range matches {
+		fmt.Fprintf(&b, "- %s %s: %.2f EUR\n", m.Brand, m.Model, m.PriceEUR)
+	}
+
+	return subject, b.String()
+}
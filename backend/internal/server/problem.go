@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+	"github.com/alessandrolattao/qdrant-experiment/internal/i18n"
+	"github.com/alessandrolattao/qdrant-experiment/internal/logging"
+)
+
+// Machine-readable error codes returned in problem responses.
+const (
+	codeInvalidRequest        = "invalid_request"
+	codeMissingQuery          = "missing_query"
+	codeMissingImage          = "missing_image"
+	codeInvalidImage          = "invalid_image"
+	codeUnsupportedImage      = "unsupported_image"
+	codeInvalidFilter         = "invalid_filter"
+	codePayloadTooLarge       = "payload_too_large"
+	codeUnauthorized          = "unauthorized"
+	codeEmailTaken            = "email_taken"
+	codeNotFound              = "not_found"
+	codeIdempotencyKeyReused  = "idempotency_key_reused"
+	codeIdempotencyInProgress = "idempotency_in_progress"
+	codeEmbedderUnavailable   = "embedder_unavailable"
+	codeSearchFailed          = "search_failed"
+	codeOverloaded            = "overloaded"
+	codeQuotaExceeded         = "quota_exceeded"
+	codeMaintenance           = "maintenance"
+	codeInternal              = "internal_error"
+)
+
+const problemTypePrefix = "urn:phone-seek:problem:"
+
+// problem is an RFC 7807 problem details body extended with a stable error code.
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []fieldError `json:"errors,omitempty"`
+}
+
+// writeProblem writes an application/problem+json response.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	writeProblemBody(w, r, problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	})
+}
+
+// writeValidationProblem reports every rejected parameter collected by v.
+func writeValidationProblem(w http.ResponseWriter, r *http.Request, v *validator) {
+	writeProblemBody(w, r, problem{
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: "one or more parameters are invalid",
+		Code:   v.code(),
+		Errors: v.localized(i18n.Lang(r.Context())),
+	})
+}
+
+func writeProblemBody(w http.ResponseWriter, r *http.Request, p problem) {
+	lang := i18n.Lang(r.Context())
+
+	p.Type = problemTypePrefix + p.Code
+	p.Title = i18n.T(lang, p.Title)
+	p.Detail = i18n.T(lang, p.Detail)
+	p.Instance = r.URL.Path
+	p.RequestID = logging.RequestID(r.Context())
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// writeImageProblem reports a failed image request with the code of its
+// status.
+func writeImageProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	code := codeInternal
+
+	switch status {
+	case http.StatusBadRequest:
+		code = codeInvalidRequest
+	case http.StatusNotFound:
+		code = codeNotFound
+	}
+
+	writeProblem(w, r, status, code, detail)
+}
+
+// writeSearchError maps a search failure to the matching problem response.
+func writeSearchError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code, detail := classifySearchError(err)
+	if status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "1")
+	}
+
+	writeProblem(w, r, status, code, detail)
+}
+
+// classifySearchError returns the status, code and detail reported for err.
+func classifySearchError(err error) (int, string, string) {
+	if errors.Is(err, embedder.ErrUnavailable) {
+		return http.StatusServiceUnavailable, codeEmbedderUnavailable, "the embedding service is unavailable, try again later"
+	}
+
+	if errors.Is(err, errOverloaded) {
+		return http.StatusServiceUnavailable, codeOverloaded, "too many concurrent searches, try again later"
+	}
+
+	return http.StatusInternalServerError, codeSearchFailed, "search failed"
+}
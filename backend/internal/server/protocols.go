@@ -0,0 +1,18 @@
+package server
+
+import "net/http"
+
+// Protocols returns the protocols the main listener accepts: HTTP/1.1, and
+// HTTP/2 negotiated via ALPN on TLS connections so browsers multiplex the
+// many parallel image requests of a results page. With h2c enabled, plain
+// connections may also speak HTTP/2 with prior knowledge; only enable it
+// behind a trusted proxy that terminates TLS and forwards HTTP/2.
+func Protocols(h2c bool) *http.Protocols {
+	var p http.Protocols
+
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(h2c)
+
+	return &p
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// qualityExamples caps the invalid indexed phones listed by the quality report.
+const qualityExamples = 50
+
+// qualityReport is the /api/admin/quality response body.
+type qualityReport struct {
+	// SeedRejected lists the CSV rows the seed run by this process left
+	// out; it is empty when the collection was already seeded at startup.
+	SeedRejected []appqdrant.RejectedPhone `json:"seed_rejected"`
+	// Indexed counts the indexed phones checked and Invalid those failing
+	// the current rules, e.g. because they predate them.
+	Indexed int            `json:"indexed"`
+	Invalid int            `json:"invalid"`
+	ByRule  map[string]int `json:"by_rule"`
+	ByField map[string]int `json:"by_field"`
+	// Examples are the first invalid indexed phones.
+	Examples []invalidPhone `json:"examples"`
+}
+
+type invalidPhone struct {
+	ID      uint64            `json:"id"`
+	Brand   string            `json:"brand"`
+	Model   string            `json:"model"`
+	Reasons []model.Rejection `json:"reasons"`
+}
+
+// handleAdminQuality checks every indexed phone against the data validation
+// rules applied at seed and admin-ingest time.
+func (s *Server) handleAdminQuality(w http.ResponseWriter, r *http.Request) {
+	report := qualityReport{
+		SeedRejected: nonNil(s.catalog.Rejected()),
+		ByRule:       map[string]int{},
+		ByField:      map[string]int{},
+		Examples:     []invalidPhone{},
+	}
+
+	for phone, err := range s.searcher.All(r.Context()) {
+		if err != nil {
+			slog.ErrorContext(r.Context(), "loading phones failed", slog.String("error", err.Error()))
+			writeSearchError(w, r, err)
+
+			return
+		}
+
+		report.Indexed++
+
+		reasons := phone.Validate()
+		if len(reasons) == 0 {
+			continue
+		}
+
+		report.Invalid++
+
+		for _, reason := range reasons {
+			report.ByRule[reason.Rule]++
+			report.ByField[reason.Field]++
+		}
+
+		if len(report.Examples) < qualityExamples {
+			report.Examples = append(report.Examples, invalidPhone{ID: phone.ID, Brand: phone.Brand, Model: phone.Model, Reasons: reasons})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	"github.com/alessandrolattao/qdrant-experiment/internal/flags"
+	"github.com/alessandrolattao/qdrant-experiment/internal/lang"
+)
+
+// translationTTL is how long translated queries are cached; translations
+// do not depend on the catalog, but cache flushes after a reseed drop them
+// too.
+const translationTTL = 24 * time.Hour
+
+// searchQuery is a text query as it is searched.
+type searchQuery struct {
+	// text is embedded, cached and logged.
+	text string
+	// lang is the detected language of the raw query.
+	lang string
+	// translatedFrom is lang when text was translated to English.
+	translatedFrom string
+}
+
+// prepareQuery turns the raw text of a search into the query that is
+// embedded, cached and logged, and detects its language. BGE-M3 embeds any
+// language, so the language tags analytics and picks the stopwords to trim;
+// queries in non-Latin scripts are first folded to their usual forms, and
+// those in a language configured for translation are translated to English,
+// falling back to the original when the translator fails.
+func (s *Server) prepareQuery(ctx context.Context, raw string) searchQuery {
+	q := searchQuery{lang: lang.Detect(raw)}
+
+	if s.flags.Enabled(flags.ScriptNormalization) && lang.NeedsScriptNormalization(raw) {
+		raw = lang.NormalizeScript(raw)
+	}
+
+	trim := q.lang
+
+	if analytics.NormalizeQuery(raw) != "" && s.translator != nil && slices.Contains(s.translateLangs, q.lang) {
+		if english, ok := s.translateQuery(ctx, raw, q.lang); ok {
+			raw, trim, q.translatedFrom = english, "en", q.lang
+		}
+	}
+
+	q.text = analytics.NormalizeQuery(raw)
+
+	if s.flags.Enabled(flags.StopwordTrim) {
+		q.text = lang.TrimStopwords(trim, q.text)
+	}
+
+	return q
+}
+
+// translateQuery translates text from language from to English through the
+// response cache; failures are logged and reported as not ok.
+func (s *Server) translateQuery(ctx context.Context, text, from string) (string, bool) {
+	sum := sha256.Sum256([]byte(analytics.NormalizeQuery(text)))
+	key := "translate:" + from + ":" + hex.EncodeToString(sum[:])
+
+	if s.cache != nil {
+		if b, ok, err := s.cache.Get(ctx, key); err == nil && ok {
+			return string(b), true
+		}
+	}
+
+	english, err := s.translator.Translate(ctx, text, from)
+	if err != nil {
+		slog.WarnContext(ctx, "query translation failed, searching the original",
+			slog.String("lang", from), slog.String("error", err.Error()))
+
+		return "", false
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, key, []byte(english), translationTTL); err != nil {
+			slog.WarnContext(ctx, "translation cache write failed", slog.String("error", err.Error()))
+		}
+	}
+
+	return english, true
+}
+
+// withQueryInfo adds the searched query, its language and the language it
+// was translated from, if any, to a search response.
+func withQueryInfo(resp map[string]any, q searchQuery) map[string]any {
+	resp["normalized_query"] = q.text
+	resp["lang"] = q.lang
+
+	if q.translatedFrom != "" {
+		resp["translated_from"] = q.translatedFrom
+	}
+
+	return resp
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxQueryLength bounds text queries in characters; real phone searches
+// are a few words, and the embedder would truncate far longer ones anyway.
+const maxQueryLength = 200
+
+// maxQueryToken bounds the words of a query written in ASCII, longer than
+// any model name but shorter than hashes, base64 and other encoded data.
+// Scripts written without spaces, such as Chinese, are not bounded.
+const maxQueryToken = 48
+
+// queryURL matches URLs, data and javascript URIs and bare domains with a
+// www prefix.
+var queryURL = regexp.MustCompile(`(?i)\b(?:https?|ftp)://|\bdata:[a-z]+/|\bjavascript:|\bwww\.[a-z0-9-]+\.`)
+
+// checkQuery fails v on q, the text query of a search or of a saved search,
+// share or price watch, when it cannot be a phone search: binary data,
+// over maxQueryLength characters, URLs, encoded blobs or no letters and
+// digits at all. Rejecting them with a 400 keeps them from the embedder and
+// the analytics. An empty q passes; callers decide whether it is required.
+func checkQuery(v *validator, q string) {
+	if q == "" {
+		return
+	}
+
+	switch {
+	case !utf8.ValidString(q) || strings.ContainsFunc(q, isControl):
+		v.fail("q", "must be text")
+	case utf8.RuneCountInString(q) > maxQueryLength:
+		v.fail("q", "must be at most %d characters", maxQueryLength)
+	case queryURL.MatchString(q):
+		v.fail("q", "must not contain URLs")
+	case strings.IndexFunc(q, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0:
+		v.fail("q", "must contain letters or digits")
+	case hasBlob(q):
+		v.fail("q", "must not contain encoded data")
+	}
+}
+
+// isControl reports control characters other than the whitespace a pasted
+// query may carry.
+func isControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
+
+// hasBlob reports whether q has an ASCII word longer than maxQueryToken.
+func hasBlob(q string) bool {
+	for word := range strings.FieldsSeq(q) {
+		if len(word) > maxQueryToken && isASCII(word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isASCII(s string) bool {
+	for i := range len(s) {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/quota"
+)
+
+// API key headers. Requests without a key are not metered, so the frontend
+// and other anonymous callers are left to the search concurrency limit.
+const (
+	apiKeyHeader           = "X-API-Key"
+	dailyRemainingHeader   = "X-Quota-Daily-Remaining"
+	monthlyRemainingHeader = "X-Quota-Monthly-Remaining"
+)
+
+// metered reports whether requests to path count against the quota of
+// their API key. The admin endpoints have their own token and reading the
+// usage is free.
+func metered(path string) bool {
+	return strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/api/admin/") && path != "/api/usage"
+}
+
+// quotaMiddleware rejects requests carrying an unknown API key or one whose
+// daily or monthly quota is used up, and counts the others.
+func (s *Server) quotaMiddleware(next http.Handler) http.Handler {
+	if s.quotas == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(apiKeyHeader)
+		if key == "" || !metered(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		k, ok := s.quotas.Lookup(key)
+		if !ok {
+			writeProblem(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid API key")
+			return
+		}
+
+		now := time.Now()
+		usage, ok := s.quotas.Allow(k, now)
+
+		setQuotaHeaders(w, usage)
+
+		if !ok {
+			resets, detail := usage.Day.Resets, "the daily quota of the API key is used up"
+			if usage.Exceeded() == quota.Monthly {
+				resets, detail = usage.Month.Resets, "the monthly quota of the API key is used up"
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(resets.Sub(now).Seconds())+1))
+			writeProblem(w, r, http.StatusTooManyRequests, codeQuotaExceeded, detail)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setQuotaHeaders reports the requests left to the key of usage, for the
+// quotas it has.
+func setQuotaHeaders(w http.ResponseWriter, usage quota.Usage) {
+	if usage.Day.Remaining != nil {
+		w.Header().Set(dailyRemainingHeader, strconv.FormatInt(*usage.Day.Remaining, 10))
+	}
+
+	if usage.Month.Remaining != nil {
+		w.Header().Set(monthlyRemainingHeader, strconv.FormatInt(*usage.Month.Remaining, 10))
+	}
+}
+
+// handleUsage returns the usage of the caller's API key in the current UTC
+// day and month, without counting the request.
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	k, ok := s.quotas.Lookup(r.Header.Get(apiKeyHeader))
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, codeUnauthorized, "missing or invalid API key")
+		return
+	}
+
+	usage := s.quotas.Usage(k, time.Now())
+
+	setQuotaHeaders(w, usage)
+	writeJSON(w, http.StatusOK, usage)
+}
+
+// handleAdminUsage returns the usage of every API key.
+func (s *Server) handleAdminUsage(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"keys": s.quotas.All(time.Now())})
+}
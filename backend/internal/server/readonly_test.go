@@ -0,0 +1,61 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+func TestReadOnlyLeavesWritesUnregistered(t *testing.T) {
+	events, err := analytics.Open(filepath.Join(t.TempDir(), "analytics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(events.Close)
+
+	srv := New(nil, Options{
+		Catalog:    appqdrant.NewSeeder(nil, nil, "", "", appqdrant.Options{}),
+		Analytics:  events,
+		LogLevel:   new(slog.LevelVar),
+		AdminToken: "secret",
+		ReadOnly:   true,
+	})
+
+	writes := []struct{ method, path string }{
+		{http.MethodPut, "/api/admin/maintenance"},
+		{http.MethodPut, "/api/admin/log-level"},
+		{http.MethodPost, "/api/admin/phones"},
+		{http.MethodDelete, "/api/admin/phones/1"},
+		{http.MethodPost, "/api/events"},
+	}
+
+	for _, tc := range writes {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader("{}"))
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: status %d, want 404 or 405", tc.method, tc.path, rec.Code)
+		}
+	}
+
+	// The reads next to them stay up.
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/maintenance", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /api/admin/maintenance: status %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+)
+
+const (
+	// maxProfilePhones bounds the phones averaged into a visitor profile.
+	maxProfilePhones = 20
+	// maxRecentViews is how many viewed phones are kept per visitor.
+	maxRecentViews = 50
+)
+
+// handleRecommendations suggests phones similar to the average of the
+// caller's recently viewed and favorite phones plus any passed in ids,
+// excluding all of them.
+func (s *Server) handleRecommendations(w http.ResponseWriter, r *http.Request) {
+	params, v := s.parseSearchParams(r)
+	ids := v.idList("ids", maxProfilePhones)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	profile := ids
+
+	var seen []uint64
+
+	if owner, _ := s.owner(w, r, false); owner != "" && s.store != nil {
+		views, err := s.store.RecentViews(owner)
+		if err != nil {
+			slog.WarnContext(r.Context(), "loading recent views failed", slog.String("error", err.Error()))
+		}
+
+		favorites, err := s.store.Favorites(owner)
+		if err != nil {
+			slog.WarnContext(r.Context(), "loading favorites failed", slog.String("error", err.Error()))
+		}
+
+		// Favorites are oldest first; prefer the latest ones.
+		slices.Reverse(favorites)
+
+		profile = slices.Concat(ids, views, favorites)
+		seen = profile
+	}
+
+	profile = dedupIDs(profile)
+	profile = profile[:min(len(profile), maxProfilePhones)]
+
+	if len(profile) == 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"results": []any{}, "total": 0, "based_on": []uint64{}})
+		return
+	}
+
+	start := time.Now()
+
+	phones, err := s.searcher.Recommend(r.Context(), profile, seen, params.Limit, params.Filters)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "recommendation failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	phones = nonNil(phones)
+
+	recordResults(r.Context(), len(phones))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results":  params.present(phones),
+		"total":    len(phones),
+		"based_on": profile,
+		"time_ms":  time.Since(start).Milliseconds(),
+	})
+}
+
+// recordViews remembers the clicked phones of events as viewed by the
+// caller, if they enabled history.
+func (s *Server) recordViews(r *http.Request, events []clientEvent) {
+	if s.store == nil || s.historyTTL <= 0 {
+		return
+	}
+
+	owner, _ := s.owner(nil, r, false)
+	if owner == "" {
+		return
+	}
+
+	for _, e := range events {
+		if e.Type != analytics.TypeClick {
+			continue
+		}
+
+		if err := s.store.AddView(owner, e.PhoneID, maxRecentViews); err != nil {
+			slog.WarnContext(r.Context(), "recording view failed", slog.String("error", err.Error()))
+			return
+		}
+	}
+}
+
+func dedupIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]struct{}, len(ids))
+	out := ids[:0:0]
+
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
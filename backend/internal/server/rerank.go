@@ -0,0 +1,48 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+// defaultCTRSmoothing is the number of impressions a phone needs before half
+// of its observed click-through rate counts towards the boost.
+const defaultCTRSmoothing = 10
+
+// rerankByCTR adds a boost proportional to each phone's historical
+// click-through rate for query to its similarity score and re-sorts the
+// results. The rate is smoothed as clicks / (impressions + smoothing), so
+// phones shown only a few times barely move. phones is not modified.
+func (s *Server) rerankByCTR(ctx context.Context, query string, phones []model.Smartphone) []model.Smartphone {
+	boost, smoothing := s.ctrBoostFor(ctx), s.settings.Load().ctrSmoothing
+
+	report := s.analytics.CTR()
+	if boost <= 0 || report == nil || query == "" {
+		return phones
+	}
+
+	boosted := slices.Clone(phones)
+	changed := false
+
+	for i := range boosted {
+		ps, ok := report.Phone(query, boosted[i].ID)
+		if !ok || ps.Clicks == 0 {
+			continue
+		}
+
+		ctr := float64(ps.Clicks) / (float64(ps.Impressions) + smoothing)
+		boosted[i].Score += float32(boost * ctr)
+		changed = true
+	}
+
+	if !changed {
+		return phones
+	}
+
+	slices.SortStableFunc(boosted, func(a, b model.Smartphone) int { return cmp.Compare(b.Score, a.Score) })
+
+	return boosted
+}
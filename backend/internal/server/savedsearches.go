@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+	"github.com/alessandrolattao/qdrant-experiment/internal/webhook"
+)
+
+const maxSavedSearchName = 100
+
+// savedSearchRequest is the body of POST /api/saved-searches. Params holds
+// the same filter, limit and fields parameters accepted by /api/search.
+type savedSearchRequest struct {
+	Name   string            `json:"name"`
+	Query  string            `json:"q"`
+	Params map[string]string `json:"params"`
+	Notify bool              `json:"notify"`
+}
+
+// savedSearchView is a saved search as returned to its owner.
+type savedSearchView struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Query     string            `json:"q"`
+	Params    map[string]string `json:"params,omitempty"`
+	Notify    bool              `json:"notify"`
+	CreatedAt time.Time         `json:"created_at"`
+	// NewMatches counts phones found by a reseed since the last run.
+	NewMatches int `json:"new_matches"`
+}
+
+func newSavedSearchView(ss store.SavedSearch) savedSearchView {
+	return savedSearchView{
+		ID:         ss.ID,
+		Name:       ss.Name,
+		Query:      ss.Query,
+		Params:     ss.Params,
+		Notify:     ss.Notify,
+		CreatedAt:  ss.CreatedAt,
+		NewMatches: len(ss.NewIDs),
+	}
+}
+
+// savedSearchParams validates the stored parameters of a saved search.
+func (s *Server) savedSearchParams(params map[string]string) (searchParams, *validator) {
+	return s.parseSearchValues(newValidator(func(key string) string { return params[key] }), false)
+}
+
+func (s *Server) handleCreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var req savedSearchRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Query = strings.TrimSpace(req.Query)
+
+	_, v := s.savedSearchParams(req.Params)
+	checkQuery(v, req.Query)
+
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxSavedSearchName {
+		v.fail("name", "must be between %d and %d characters", 1, maxSavedSearchName)
+	}
+
+	if req.Query == "" {
+		v.fail("q", "must not be empty")
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	owner, token := s.owner(w, r, true)
+
+	ss, err := s.store.SaveSearch(owner, store.SavedSearch{
+		Name:   req.Name,
+		Query:  req.Query,
+		Params: req.Params,
+		Notify: req.Notify,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "saving search failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "saving the search failed")
+
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, withToken(map[string]any{"search": newSavedSearchView(ss)}, token))
+}
+
+func (s *Server) handleListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	owner, token := s.owner(w, r, false)
+	if owner == "" {
+		writeJSON(w, http.StatusOK, map[string]any{"searches": []any{}})
+		return
+	}
+
+	searches, err := s.store.SavedSearches(owner)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading saved searches failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "loading saved searches failed")
+
+		return
+	}
+
+	views := make([]savedSearchView, len(searches))
+	for i, ss := range searches {
+		views[i] = newSavedSearchView(ss)
+	}
+
+	writeJSON(w, http.StatusOK, withToken(map[string]any{"searches": views}, token))
+}
+
+func (s *Server) handleDeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	owner, _ := s.owner(w, r, false)
+
+	err := store.ErrNotFound
+	if owner != "" {
+		err = s.store.DeleteSavedSearch(owner, r.PathValue("id"))
+	}
+
+	if errors.Is(err, store.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "saved search not found")
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "deleting saved search failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "deleting the saved search failed")
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRunSavedSearch runs a saved search and reports which results the
+// owner has not seen in earlier runs, then records them as seen.
+func (s *Server) handleRunSavedSearch(w http.ResponseWriter, r *http.Request) {
+	owner, _ := s.owner(w, r, false)
+
+	err := store.ErrNotFound
+
+	var ss store.SavedSearch
+	if owner != "" {
+		ss, err = s.store.SavedSearch(owner, r.PathValue("id"))
+	}
+
+	if errors.Is(err, store.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "saved search not found")
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading saved search failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "loading saved searches failed")
+
+		return
+	}
+
+	params, _ := s.savedSearchParams(ss.Params)
+	start := time.Now()
+
+	phones, err := s.searcher.SearchByText(r.Context(), ss.Query, params.Limit, params.Filters)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "saved search failed", slog.String("error", err.Error()))
+		writeSearchError(w, r, err)
+
+		return
+	}
+
+	phones = s.rerankByCTR(r.Context(), ss.Query, phones)
+
+	fresh := ss.NewIDs
+	if !ss.CheckedAt.IsZero() {
+		fresh = appendUnseen(fresh, phones, ss.SeenIDs)
+	}
+
+	ss.SeenIDs = phoneIDs(phones)
+	ss.NewIDs = nil
+	ss.CheckedAt = time.Now().UTC()
+
+	if _, err := s.store.SaveSearch(owner, ss); err != nil && !errors.Is(err, store.ErrNotFound) {
+		slog.WarnContext(r.Context(), "recording saved search results failed", slog.String("error", err.Error()))
+	}
+
+	recordResults(r.Context(), len(phones))
+
+	writeJSON(w, http.StatusOK, withSearchTags(r.Context(), map[string]any{
+		"search":  newSavedSearchView(ss),
+		"results": params.present(phones),
+		"total":   len(phones),
+		"new":     fresh,
+		"time_ms": time.Since(start).Milliseconds(),
+	}, ""))
+}
+
+// CheckSavedSearches re-runs every saved search with notifications enabled
+// after a reseed. Phones that were not among a search's previous results are
+// kept for its next run and announced with a saved_search.matches webhook.
+func (s *Server) CheckSavedSearches(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+
+	searches, err := s.store.NotifyingSearches()
+	if err != nil {
+		slog.ErrorContext(ctx, "loading saved searches failed", slog.String("error", err.Error()))
+		return
+	}
+
+	notified := 0
+
+	for _, owned := range searches {
+		if ctx.Err() != nil {
+			return
+		}
+
+		params, _ := s.savedSearchParams(owned.Params)
+
+		phones, err := s.searcher.SearchByText(ctx, owned.Query, params.Limit, params.Filters)
+		if err != nil {
+			slog.WarnContext(ctx, "checking saved search failed", slog.String("search_id", owned.ID), slog.String("error", err.Error()))
+			continue
+		}
+
+		ss := owned.SavedSearch
+		checked := !ss.CheckedAt.IsZero()
+		fresh := appendUnseen(nil, phones, slices.Concat(ss.SeenIDs, ss.NewIDs))
+
+		ss.NewIDs = append(ss.NewIDs, fresh...)
+		ss.SeenIDs = phoneIDs(phones)
+		ss.CheckedAt = time.Now().UTC()
+
+		if !checked {
+			// The first check only establishes what the owner has seen.
+			ss.NewIDs = nil
+			fresh = nil
+		}
+
+		if _, err := s.store.SaveSearch(owned.Owner, ss); err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				slog.WarnContext(ctx, "recording saved search results failed", slog.String("search_id", ss.ID), slog.String("error", err.Error()))
+			}
+
+			continue
+		}
+
+		if len(fresh) == 0 {
+			continue
+		}
+
+		notified++
+
+		data := map[string]any{"id": ss.ID, "name": ss.Name, "new_ids": fresh}
+		if userID, ok := strings.CutPrefix(owned.Owner, "user:"); ok {
+			data["user_id"] = userID
+		}
+
+		s.webhooks.Send(ctx, webhook.SavedSearchMatches, data)
+	}
+
+	slog.InfoContext(ctx, "saved searches checked", slog.Int("searches", len(searches)), slog.Int("notified", notified))
+}
+
+// appendUnseen appends the IDs of phones that are not in seen to dst.
+func appendUnseen(dst []uint64, phones []model.Smartphone, seen []uint64) []uint64 {
+	known := make(map[uint64]struct{}, len(seen))
+	for _, id := range seen {
+		known[id] = struct{}{}
+	}
+
+	for _, p := range phones {
+		if _, ok := known[p.ID]; !ok {
+			dst = append(dst, p.ID)
+		}
+	}
+
+	return dst
+}
+
+func phoneIDs(phones []model.Smartphone) []uint64 {
+	ids := make([]uint64, len(phones))
+	for i, p := range phones {
+		ids[i] = p.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/flags"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// searchCacheKey identifies a search by its query, filters and limit.
+func searchCacheKey(query string, limit uint64, filters appqdrant.SearchFilters) string {
+	b, _ := json.Marshal(struct {
+		Query   string                  `json:"q"`
+		Limit   uint64                  `json:"limit"`
+		Filters appqdrant.SearchFilters `json:"filters"`
+	}{query, limit, filters})
+
+	sum := sha256.Sum256(b)
+
+	return "search:" + hex.EncodeToString(sum[:])
+}
+
+// cachedSearch returns previously stored results for key, if any.
+// Cache failures are logged and treated as misses.
+func (s *Server) cachedSearch(ctx context.Context, key string) ([]model.Smartphone, bool) {
+	if s.cache == nil || !s.flags.Enabled(flags.SearchCache) {
+		return nil, false
+	}
+
+	b, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "search cache read failed", slog.String("error", err.Error()))
+		s.searchStats.Miss()
+
+		return nil, false
+	}
+
+	var phones []model.Smartphone
+	if !ok || json.Unmarshal(b, &phones) != nil {
+		s.searchStats.Miss()
+		return nil, false
+	}
+
+	s.searchStats.Hit()
+
+	return phones, true
+}
+
+// storeSearch caches results under key; failures are logged and ignored.
+func (s *Server) storeSearch(ctx context.Context, key string, phones []model.Smartphone) {
+	if s.cache == nil || !s.flags.Enabled(flags.SearchCache) {
+		return
+	}
+
+	b, err := json.Marshal(phones)
+	if err != nil {
+		return
+	}
+
+	if err := s.cache.Set(ctx, key, b, s.cacheTTL); err != nil {
+		slog.WarnContext(ctx, "search cache write failed", slog.String("error", err.Error()))
+	}
+}
+
+// InvalidateCaches drops cached responses, facet values and phone of the day
+// candidates; call it after the collection changes.
+func (s *Server) InvalidateCaches(ctx context.Context) {
+	s.brands.Invalidate()
+	s.daily.Invalidate()
+
+	if s.cache == nil {
+		return
+	}
+
+	if err := s.cache.Flush(ctx); err != nil {
+		slog.WarnContext(ctx, "search cache flush failed", slog.String("error", err.Error()))
+		return
+	}
+
+	slog.InfoContext(ctx, "search cache flushed")
+}
@@ -2,154 +2,519 @@
 package server
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"sort"
-	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	"github.com/alessandrolattao/qdrant-experiment/internal/cache"
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
+	"github.com/alessandrolattao/qdrant-experiment/internal/experiment"
+	"github.com/alessandrolattao/qdrant-experiment/internal/flags"
+	"github.com/alessandrolattao/qdrant-experiment/internal/i18n"
+	"github.com/alessandrolattao/qdrant-experiment/internal/images"
+	"github.com/alessandrolattao/qdrant-experiment/internal/mail"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
 	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+	"github.com/alessandrolattao/qdrant-experiment/internal/quota"
+	"github.com/alessandrolattao/qdrant-experiment/internal/specs"
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+	"github.com/alessandrolattao/qdrant-experiment/internal/translate"
+	"github.com/alessandrolattao/qdrant-experiment/internal/webhook"
 )
 
-const defaultLimit = 20
+// Options configures the HTTP server.
+type Options struct {
+	ImagesDir string
+	// ImageCacheDir holds resized image variants generated on demand.
+	ImageCacheDir string
+	// Settings holds the options Reload can change later.
+	Settings
+	// Cache stores full search responses; nil disables caching.
+	Cache    cache.Cache
+	CacheTTL time.Duration
+	// FiltersTTL is how long the facet values served by /api/filters are kept in memory.
+	FiltersTTL time.Duration
+	// Seeded reports whether the initial data import has finished; /readyz
+	// answers 503 until it returns true. Nil means always seeded.
+	Seeded func() bool
+	// Connected returns nil once Qdrant answered at startup and the last
+	// connection error until then; /readyz answers 503 meanwhile. Nil means
+	// always connected.
+	Connected func() error
+	// MaxUploadBytes bounds image uploads and MaxJSONBytes JSON request
+	// bodies; zero selects the defaults (10MB and 1MB).
+	MaxUploadBytes int64
+	MaxJSONBytes   int64
+	// Catalog applies admin changes to the indexed phones; the write
+	// endpoints are registered only when it and AdminToken are set.
+	Catalog *appqdrant.Seeder
+	// IdempotencyTTL is how long responses to admin writes are replayed for
+	// retries carrying the same Idempotency-Key.
+	IdempotencyTTL time.Duration
+	// Webhooks receives catalog change events; nil disables them.
+	Webhooks *webhook.Dispatcher
+	// Store persists favorites, saved searches, shared searches and price
+	// watches; nil disables their endpoints.
+	Store *store.Store
+	// Mailer emails price alerts to account holders; nil sends webhooks only.
+	Mailer *mail.Sender
+	// Accounts enables registration and login; favorites of logged-in users
+	// follow their account. Requires Store.
+	Accounts   bool
+	SessionTTL time.Duration
+	// HistoryTTL is how long recorded queries are kept for callers who
+	// enabled search history, up to HistoryLimit per caller; zero disables
+	// the history endpoints.
+	HistoryTTL   time.Duration
+	HistoryLimit int
+	// Analytics logs searches and the interaction events posted to
+	// /api/events; nil disables both.
+	Analytics *analytics.Log
+	// Experiment splits searches between ranking variants; nil serves
+	// everyone the configured ranking.
+	Experiment *experiment.Experiment
+	// Rates converts prices for the currency search parameter; nil uses the
+	// built-in exchange rates.
+	Rates *currency.Rates
+	// Translator translates text queries detected in one of
+	// TranslateLangs to English before embedding; nil disables it.
+	Translator     translate.Translator
+	TranslateLangs []string
+	// Quotas meters the requests carrying an API key; nil disables API
+	// keys and /api/usage.
+	Quotas *quota.Tracker
+	// Flags switch caching, CTR reranking and the experiment on and off at
+	// runtime; nil leaves them all on.
+	Flags *flags.Flags
+	// LogLevel is the minimum level logged, changed through
+	// /api/admin/log-level; nil leaves the endpoints unregistered.
+	LogLevel *slog.LevelVar
+	// AdminToken is the bearer token required by /api/admin endpoints; they
+	// are not registered when it is empty.
+	AdminToken string
+	// ReadOnly leaves every endpoint that changes state unregistered, for
+	// search replicas: the admin writes, /api/events and the Store and
+	// account endpoints.
+	ReadOnly bool
+	// Frontend serves the single-page app for all non-API paths; nil leaves
+	// the frontend to a separate server.
+	Frontend http.Handler
+}
 
 // Server handles HTTP requests for smartphone search.
 type Server struct {
-	searcher  *appqdrant.Searcher
-	imagesDir string
-	mux       *http.ServeMux
+	searcher       *appqdrant.Searcher
+	imagesDir      string
+	imageCacheDir  string
+	cache          cache.Cache
+	cacheTTL       time.Duration
+	searchStats    cache.Stats
+	brands         *cache.Memo[[]string]
+	daily          *cache.Memo[dailyPools]
+	seeded         func() bool
+	connected      func() error
+	adminToken     string
+	logLevel       *slog.LevelVar
+	maxUploadBytes int64
+	maxJSONBytes   int64
+	catalog        *appqdrant.Seeder
+	idempotency    *idempotencyStore
+	webhooks       *webhook.Dispatcher
+	store          *store.Store
+	mailer         *mail.Sender
+	accounts       bool
+	sessionTTL     time.Duration
+	historyTTL     time.Duration
+	historyLimit   int
+	analytics      *analytics.Log
+	experiment     *experiment.Experiment
+	flags          *flags.Flags
+	translator     translate.Translator
+	translateLangs []string
+	quotas         *quota.Tracker
+	maintenance    atomic.Pointer[maintenanceState]
+	rates          *currency.Rates
+	mux            *http.ServeMux
+	// settings are read afresh by each request so Reload takes effect
+	// without a restart.
+	settings atomic.Pointer[settings]
 }
 
 // New creates a new HTTP server.
-func New(searcher *appqdrant.Searcher, imagesDir string) *Server {
+func New(searcher *appqdrant.Searcher, opts Options) *Server {
 	s := &Server{
-		searcher:  searcher,
-		imagesDir: imagesDir,
-		mux:       http.NewServeMux(),
+		searcher:       searcher,
+		imagesDir:      opts.ImagesDir,
+		imageCacheDir:  opts.ImageCacheDir,
+		cache:          opts.Cache,
+		cacheTTL:       opts.CacheTTL,
+		brands:         cache.NewMemo[[]string](opts.FiltersTTL),
+		daily:          cache.NewMemo[dailyPools](time.Hour),
+		seeded:         opts.Seeded,
+		connected:      opts.Connected,
+		adminToken:     opts.AdminToken,
+		maxUploadBytes: cmp.Or(opts.MaxUploadBytes, defaultMaxUploadBytes),
+		maxJSONBytes:   cmp.Or(opts.MaxJSONBytes, defaultMaxJSONBytes),
+		catalog:        opts.Catalog,
+		idempotency:    newIdempotencyStore(opts.IdempotencyTTL),
+		webhooks:       opts.Webhooks,
+		store:          opts.Store,
+		mailer:         opts.Mailer,
+		accounts:       opts.Accounts && opts.Store != nil,
+		sessionTTL:     opts.SessionTTL,
+		historyTTL:     opts.HistoryTTL,
+		historyLimit:   cmp.Or(opts.HistoryLimit, defaultHistoryLimit),
+		analytics:      opts.Analytics,
+		experiment:     opts.Experiment,
+		flags:          opts.Flags,
+		translator:     opts.Translator,
+		translateLangs: opts.TranslateLangs,
+		quotas:         opts.Quotas,
+		logLevel:       opts.LogLevel,
+		rates:          opts.Rates,
+		mux:            http.NewServeMux(),
 	}
 
-	s.mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
-		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-	})
+	s.Reload(opts.Settings)
+
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /healthz", s.handleLiveness)
+	s.mux.HandleFunc("GET /readyz", s.handleReadiness)
 	s.mux.HandleFunc("GET /api/filters", s.handleFilters)
-	s.mux.HandleFunc("GET /api/search", s.handleSearchText)
-	s.mux.HandleFunc("POST /api/search/image", s.handleSearchImage)
-	s.mux.Handle("GET /api/images/", http.StripPrefix("/api/images/", http.FileServer(http.Dir(imagesDir))))
+	s.mux.HandleFunc("GET /api/search", s.limitSearch(s.withVariant(s.handleSearchText)))
+	s.mux.HandleFunc("GET /api/search/stream", s.limitSearch(s.withVariant(s.handleSearchStream)))
+	s.mux.HandleFunc("GET /api/ws/search", s.withVariant(s.handleSearchWS))
+	s.mux.HandleFunc("POST /api/search/image", s.limitSearch(s.withVariant(s.handleSearchImage)))
+	s.mux.HandleFunc("GET /api/recommendations", s.limitSearch(s.handleRecommendations))
+	s.mux.HandleFunc("POST /api/discover", s.limitSearch(s.handleDiscover))
+	s.mux.HandleFunc("GET /api/phones/random", s.handleRandomPhones)
+	s.mux.HandleFunc("GET /api/phones/today", s.handlePhoneOfTheDay)
+	s.mux.HandleFunc("GET /api/phones/{id}", s.handlePhone)
+	s.mux.HandleFunc("GET /api/phones/by-slug/{slug}", s.handlePhoneBySlug)
+	s.mux.HandleFunc("GET /api/count", s.handleCount)
+	s.mux.HandleFunc("GET /api/compare", s.handleCompare)
+	s.mux.HandleFunc("GET /api/brands/{brand}", s.handleBrand)
+	s.mux.Handle("GET /api/images/{file}", images.NewHandler(s.imagesDir, s.imageCacheDir, writeImageProblem))
+
+	if s.analytics != nil && !opts.ReadOnly {
+		s.mux.HandleFunc("POST /api/events", s.withVariant(s.handleEvents))
+	}
+
+	if s.quotas != nil {
+		s.mux.HandleFunc("GET /api/usage", s.handleUsage)
+	}
+
+	if s.store != nil && !opts.ReadOnly {
+		s.mux.HandleFunc("GET /api/favorites", s.handleListFavorites)
+		s.mux.HandleFunc("PUT /api/favorites/{id}", s.handleAddFavorite)
+		s.mux.HandleFunc("DELETE /api/favorites/{id}", s.handleRemoveFavorite)
+		s.mux.HandleFunc("GET /api/saved-searches", s.handleListSavedSearches)
+		s.mux.HandleFunc("POST /api/saved-searches", s.handleCreateSavedSearch)
+		s.mux.HandleFunc("DELETE /api/saved-searches/{id}", s.handleDeleteSavedSearch)
+		s.mux.HandleFunc("GET /api/saved-searches/{id}/run", s.limitSearch(s.withVariant(s.handleRunSavedSearch)))
+		s.mux.HandleFunc("POST /api/share", s.handleCreateShare)
+		s.mux.HandleFunc("GET /api/share/{token}", s.handleShare)
+		s.mux.HandleFunc("GET /api/price-watches", s.handleListPriceWatches)
+		s.mux.HandleFunc("POST /api/price-watches", s.handleCreatePriceWatch)
+		s.mux.HandleFunc("DELETE /api/price-watches/{id}", s.handleDeletePriceWatch)
+
+		if s.historyTTL > 0 {
+			s.mux.HandleFunc("GET /api/history", s.handleHistory)
+			s.mux.HandleFunc("PUT /api/history", s.handleEnableHistory)
+			s.mux.HandleFunc("DELETE /api/history", s.handleDisableHistory)
+		}
+	}
+
+	if s.accounts && !opts.ReadOnly {
+		s.mux.HandleFunc("POST /api/auth/register", s.handleRegister)
+		s.mux.HandleFunc("POST /api/auth/login", s.handleLogin)
+		s.mux.HandleFunc("POST /api/auth/logout", s.handleLogout)
+		s.mux.HandleFunc("GET /api/auth/me", s.handleMe)
+	}
+
+	if s.adminToken != "" {
+		s.mux.HandleFunc("GET /api/admin/stats", s.requireAdmin(s.handleAdminStats))
+		s.mux.HandleFunc("GET /api/admin/flags", s.requireAdmin(s.handleAdminFlags))
+		s.mux.HandleFunc("GET /api/admin/quality", s.requireAdmin(s.handleAdminQuality))
+		s.mux.HandleFunc("GET /api/admin/export", s.requireAdmin(s.handleAdminExport))
+		s.mux.HandleFunc("GET /api/admin/maintenance", s.requireAdmin(s.handleAdminMaintenance))
+
+		if !opts.ReadOnly {
+			s.mux.HandleFunc("PUT /api/admin/maintenance", s.requireAdmin(s.handleSetMaintenance))
+		}
+
+		if s.quotas != nil {
+			s.mux.HandleFunc("GET /api/admin/usage", s.requireAdmin(s.handleAdminUsage))
+		}
+
+		if s.logLevel != nil {
+			s.mux.HandleFunc("GET /api/admin/log-level", s.requireAdmin(s.handleAdminLogLevel))
+
+			if !opts.ReadOnly {
+				s.mux.HandleFunc("PUT /api/admin/log-level", s.requireAdmin(s.handleSetLogLevel))
+			}
+		}
+
+		if s.analytics != nil {
+			s.mux.HandleFunc("GET /api/admin/analytics/ctr", s.requireAdmin(s.handleAdminCTR))
+			s.mux.HandleFunc("GET /api/admin/analytics/summary", s.requireAdmin(s.handleAdminSummary))
+			s.mux.HandleFunc("GET /api/admin/analytics/queries", s.requireAdmin(s.handleAdminQueryLog))
+			s.mux.HandleFunc("GET /api/admin/experiments", s.requireAdmin(s.handleAdminExperiments))
+		}
+
+		if s.catalog != nil && !opts.ReadOnly {
+			s.mux.HandleFunc("POST /api/admin/phones", s.requireAdmin(s.idempotent(s.handleUpsertPhones)))
+			s.mux.HandleFunc("DELETE /api/admin/phones/{id}", s.requireAdmin(s.idempotent(s.handleDeletePhone)))
+		}
+	}
+
+	if opts.Frontend != nil {
+		s.mux.Handle("GET /", opts.Frontend)
+	}
 
 	return s
 }
 
-// Handler returns the HTTP handler with CORS middleware.
+// Handler returns the HTTP handler wrapped with request ID, tracing, logging,
+// Server-Timing, panic recovery, CORS, language negotiation, maintenance
+// mode and API key quota middleware.
 func (s *Server) Handler() http.Handler {
-	return corsMiddleware(s.mux)
+	return requestIDMiddleware(s.tracingMiddleware(loggingMiddleware(serverTimingMiddleware(recoveryMiddleware(s.corsMiddleware(languageMiddleware(s.maintenanceMiddleware(s.quotaMiddleware(s.mux)))))))))
 }
 
 func (s *Server) handleFilters(w http.ResponseWriter, r *http.Request) {
-	brands, err := s.searcher.AvailableBrands(r.Context())
+	brands, err := s.brands.Get(r.Context(), func(ctx context.Context) ([]string, error) {
+		brands, err := s.searcher.AvailableBrands(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(brands)
+
+		return brands, nil
+	})
 	if err != nil {
-		slog.Error("failed to load brands", slog.String("error", err.Error()))
+		slog.ErrorContext(r.Context(), "failed to load brands", slog.String("error", err.Error()))
 		brands = nil
-	} else {
-		sort.Strings(brands)
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
+	lang := i18n.Lang(r.Context())
+
+	filters := map[string]any{
 		"brands":       brands,
-		"nfc":          []string{"Yes", "No"},
-		"network":      []string{"5G", "LTE", "HSPA", "GSM"},
-		"os":           []string{"Android", "iOS", "Windows", "Other"},
-		"display_type": []string{"AMOLED", "OLED", "IPS", "TFT", "LCD", "Other"},
-	})
+		"nfc":          nfcValues,
+		"network":      networkValues,
+		"os":           osValues,
+		"display_type": displayTypeValues,
+		"soc":          model.SoCFamilies,
+		"soc_tier":     model.SoCTiers,
+		"lens":         specs.LensTypes,
+		"color":        model.ColorFamilies,
+		"sensor":       model.Sensors,
+		"dual_sim":     boolValues,
+		"esim":         boolValues,
+		"sim_size":     model.SIMSizes,
+		// Exchange rates per euro of the currencies accepted by the
+		// currency parameter.
+		"currencies": s.rates.All(),
+		// Display labels in the negotiated language; filter parameters
+		// still take the canonical values above.
+		"labels": map[string]any{
+			"nfc":          i18n.Values(lang, nfcValues),
+			"network":      i18n.Values(lang, networkValues),
+			"os":           i18n.Values(lang, osValues),
+			"display_type": i18n.Values(lang, displayTypeValues),
+			"soc_tier":     i18n.Values(lang, model.SoCTiers),
+			"lens":         i18n.Values(lang, specs.LensTypes),
+			"color":        i18n.Values(lang, model.ColorFamilies),
+			"fields":       i18n.Labels(lang, smartphoneFieldNames()),
+		},
+	}
+
+	writeJSONWithETag(w, r, filters, filters)
 }
 
 func (s *Server) handleSearchText(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing query parameter 'q'"})
+	raw := r.URL.Query().Get("q")
+	if analytics.NormalizeQuery(raw) == "" {
+		writeProblem(w, r, http.StatusBadRequest, codeMissingQuery, "missing query parameter 'q'")
 		return
 	}
 
-	filters := parseFilters(r)
+	params, v := s.parseSearchParams(r)
+	checkQuery(v, raw)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
 
 	start := time.Now()
 
-	phones, err := s.searcher.SearchByText(r.Context(), query, defaultLimit, filters)
-	if err != nil {
-		slog.Error("text search failed", slog.String("error", err.Error()))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "search failed"})
+	pq := s.prepareQuery(r.Context(), raw)
+	query := pq.text
+
+	if params.Stream {
+		if pq.translatedFrom != "" {
+			w.Header().Set(translatedFromHeader, pq.translatedFrom)
+		}
+
+		phones, err := s.searcher.StreamByText(r.Context(), query, params.Limit, params.Filters)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "text search failed", slog.String("error", err.Error()))
+
+			fallback, ok := s.browseFallback(r.Context(), err, params.Limit, params.Filters)
+			if !ok {
+				writeSearchError(w, r, err)
+				return
+			}
+
+			phones = slices.Values(fallback)
+			w.Header().Set(degradedHeader, "true")
+		}
+
+		var ids []uint64
+
+		queryID := s.newQueryID(w)
+		recordResults(r.Context(), writeNDJSON(w, collectIDs(phones, &ids), params.presentOne))
+		s.logQuery(r.Context(), queryID, "text", query, pq.lang, filterValues(r.FormValue), ids, start)
 
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"results":  phones,
+	key := searchCacheKey(query, params.Limit, params.Filters)
+
+	phones, cached := s.cachedSearch(r.Context(), key)
+	degraded := false
+
+	if !cached {
+		var err error
+
+		phones, err = s.searcher.SearchByText(r.Context(), query, params.Limit, params.Filters)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "text search failed", slog.String("error", err.Error()))
+
+			if phones, degraded = s.browseFallback(r.Context(), err, params.Limit, params.Filters); !degraded {
+				writeSearchError(w, r, err)
+				return
+			}
+		}
+
+		// Filter-only results must not outlive the outage.
+		if !degraded {
+			s.storeSearch(r.Context(), key, phones)
+		}
+	}
+
+	phones = s.rerankByCTR(r.Context(), query, phones)
+
+	recordResults(r.Context(), len(phones))
+	s.recordHistory(r, raw)
+
+	queryID := s.newQueryID(w)
+	s.logQuery(r.Context(), queryID, "text", query, pq.lang, filterValues(r.FormValue), phoneIDs(phones), start)
+
+	results := params.present(phones)
+
+	writeJSONWithETag(w, r, results, withSearchTags(r.Context(), withQueryInfo(map[string]any{
+		"results":  results,
 		"total":    len(phones),
+		"cached":   cached,
+		"degraded": degraded,
 		"time_ms":  time.Since(start).Milliseconds(),
-	})
+	}, pq), queryID))
 }
 
 func (s *Server) handleSearchImage(w http.ResponseWriter, r *http.Request) {
-	const maxUploadSize = 10 << 20 // 10MB
-
-	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxUploadBytes)
 
+	_, span := tracer.Start(r.Context(), "image.upload")
 	file, header, err := r.FormFile("image")
+	tracing.RecordError(span, err)
+	span.End()
+
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing image file"})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeTooLarge(w, r, "image exceeds the %s upload limit", tooLarge.Limit)
+			return
+		}
+
+		writeProblem(w, r, http.StatusBadRequest, codeMissingImage, "missing image file")
+
 		return
 	}
 	defer func() { _ = file.Close() }()
 
-	filters := parseFilters(r)
-
-	start := time.Now()
-
-	phones, err := s.searcher.SearchByImage(r.Context(), file, header.Filename, defaultLimit, filters)
-	if err != nil {
-		slog.Error("image search failed", slog.String("error", err.Error()))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "search failed"})
+	params, v := s.parseSearchParams(r)
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
 
+	image, filename, ok := uploadedImage(w, r, file, header.Filename)
+	if !ok {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"results":  phones,
-		"total":    len(phones),
-		"time_ms":  time.Since(start).Milliseconds(),
-	})
-}
+	start := time.Now()
 
-func parseFilters(r *http.Request) appqdrant.SearchFilters {
-	var filters appqdrant.SearchFilters
+	if params.Stream {
+		phones, err := s.searcher.StreamByImage(r.Context(), image, filename, params.Limit, params.Filters)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "image search failed", slog.String("error", err.Error()))
 
-	filters.Brand = r.FormValue("brand")
-	filters.NetGen = r.FormValue("network")
-	filters.OS = r.FormValue("os")
-	filters.DisplayType = r.FormValue("display_type")
+			fallback, ok := s.browseFallback(r.Context(), err, params.Limit, params.Filters)
+			if !ok {
+				writeSearchError(w, r, err)
+				return
+			}
 
-	if v := r.FormValue("price_min"); v != "" {
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			filters.PriceMin = f
+			phones = slices.Values(fallback)
+			w.Header().Set(degradedHeader, "true")
 		}
+
+		var ids []uint64
+
+		queryID := s.newQueryID(w)
+		recordResults(r.Context(), writeNDJSON(w, collectIDs(phones, &ids), params.presentOne))
+		s.logQuery(r.Context(), queryID, "image", "", "", filterValues(r.FormValue), ids, start)
+
+		return
 	}
 
-	if v := r.FormValue("price_max"); v != "" {
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			filters.PriceMax = f
+	degraded := false
+
+	phones, err := s.searcher.SearchByImage(r.Context(), image, filename, params.Limit, params.Filters)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "image search failed", slog.String("error", err.Error()))
+
+		if phones, degraded = s.browseFallback(r.Context(), err, params.Limit, params.Filters); !degraded {
+			writeSearchError(w, r, err)
+			return
 		}
 	}
 
-	switch r.FormValue("nfc") {
-	case "Yes":
-		t := true
-		filters.NFC = &t
-	case "No":
-		f := false
-		filters.NFC = &f
-	}
+	recordResults(r.Context(), len(phones))
+
+	queryID := s.newQueryID(w)
+	s.logQuery(r.Context(), queryID, "image", "", "", filterValues(r.FormValue), phoneIDs(phones), start)
 
-	return filters
+	writeJSON(w, http.StatusOK, withSearchTags(r.Context(), map[string]any{
+		"results":  params.present(phones),
+		"total":    len(phones),
+		"degraded": degraded,
+		"time_ms":  time.Since(start).Milliseconds(),
+	}, queryID))
 }
 
 func writeJSON(w http.ResponseWriter, status int, data any) {
@@ -157,18 +522,3 @@ func writeJSON(w http.ResponseWriter, status int, data any) {
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(data)
 }
-
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
@@ -0,0 +1,43 @@
+package server
+
+import "cmp"
+
+// Settings are the options a running server can change without a restart.
+type Settings struct {
+	CORS CORSOptions
+	// MaxConcurrentSearches bounds in-flight embed+search operations; 0
+	// disables the limit. SearchQueueDepth requests may wait for a slot
+	// before further ones are rejected with 503.
+	MaxConcurrentSearches int
+	SearchQueueDepth      int
+	// CTRBoost weighs the historical click-through rate of each result,
+	// smoothed by CTRSmoothing impressions, against its similarity score;
+	// zero keeps the pure vector ranking. Requires Analytics.
+	CTRBoost     float64
+	CTRSmoothing float64
+}
+
+// settings is the request-time form of Settings.
+type settings struct {
+	cors         *corsPolicy
+	limiter      *limiter
+	ctrBoost     float64
+	ctrSmoothing float64
+}
+
+func newSettings(st Settings) *settings {
+	return &settings{
+		cors:         newCORSPolicy(st.CORS),
+		limiter:      newLimiter(st.MaxConcurrentSearches, st.SearchQueueDepth),
+		ctrBoost:     st.CTRBoost,
+		ctrSmoothing: cmp.Or(st.CTRSmoothing, defaultCTRSmoothing),
+	}
+}
+
+// Reload replaces the settings for the requests that start from now on.
+// Requests in flight finish under the settings they started with: a search
+// holding a slot of the previous concurrency limit releases it there, so
+// until those searches end the old and new limits both admit searches.
+func (s *Server) Reload(st Settings) {
+	s.settings.Store(newSettings(st))
+}
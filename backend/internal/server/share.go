@@ -0,0 +1,67 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/store"
+)
+
+// shareRequest is the body of POST /api/share. Params holds the same filter,
+// limit and fields parameters accepted by /api/search.
+type shareRequest struct {
+	Query  string            `json:"q"`
+	Params map[string]string `json:"params"`
+}
+
+func (s *Server) handleCreateShare(w http.ResponseWriter, r *http.Request) {
+	var req shareRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	req.Query = strings.TrimSpace(req.Query)
+
+	_, v := s.savedSearchParams(req.Params)
+	checkQuery(v, req.Query)
+
+	if req.Query == "" {
+		v.fail("q", "must not be empty")
+	}
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	sh, err := s.store.CreateShare(store.Share{Query: req.Query, Params: req.Params})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "creating share failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "sharing the search failed")
+
+		return
+	}
+
+	w.Header().Set("Location", "/api/share/"+sh.Token)
+	writeJSON(w, http.StatusCreated, sh)
+}
+
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	// Tokens are base32; accept them in any case since people retype links.
+	sh, err := s.store.Share(strings.ToUpper(r.PathValue("token")))
+	if errors.Is(err, store.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "shared search not found")
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading share failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "loading the shared search failed")
+
+		return
+	}
+
+	writeJSONWithETag(w, r, sh, sh)
+}
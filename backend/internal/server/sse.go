@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	"github.com/alessandrolattao/qdrant-experiment/internal/i18n"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+)
+
+// sseWriter writes Server-Sent Events and flushes after each one.
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	return &sseWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+func (s *sseWriter) send(event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshaling %s event: %w", event, err)
+	}
+
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+// handleSearchStream serves text search as Server-Sent Events: each dense
+// hit is sent as a "result" event as soon as it is mapped, followed by a
+// "final" event carrying the definitive ranking, total and timing. Errors
+// after the stream has started are reported as an "error" event.
+func (s *Server) handleSearchStream(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("q")
+
+	if analytics.NormalizeQuery(raw) == "" {
+		writeProblem(w, r, http.StatusBadRequest, codeMissingQuery, "missing query parameter 'q'")
+		return
+	}
+
+	params, v := s.parseSearchParams(r)
+	checkQuery(v, raw)
+
+	if len(v.errors) > 0 {
+		writeValidationProblem(w, r, v)
+		return
+	}
+
+	start := time.Now()
+
+	pq := s.prepareQuery(r.Context(), raw)
+	query := pq.text
+	sse := newSSEWriter(w)
+
+	degraded := false
+
+	phones, err := s.searcher.StreamByText(r.Context(), query, params.Limit, params.Filters)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "text search failed", slog.String("error", err.Error()))
+
+		fallback, ok := s.browseFallback(r.Context(), err, params.Limit, params.Filters)
+		if !ok {
+			_, code, detail := classifySearchError(err)
+			_ = sse.send("error", map[string]string{"code": code, "detail": i18n.T(i18n.Lang(r.Context()), detail)})
+
+			return
+		}
+
+		phones, degraded = slices.Values(fallback), true
+	}
+
+	ranked := make([]model.Smartphone, 0, params.Limit)
+
+	for phone := range phones {
+		if err := sse.send("result", params.presentOne(phone)); err != nil {
+			return
+		}
+
+		ranked = append(ranked, phone)
+	}
+
+	ranked = s.rerankByCTR(r.Context(), query, ranked)
+
+	recordResults(r.Context(), len(ranked))
+	s.recordHistory(r, raw)
+
+	queryID := s.newQueryID(nil)
+	s.logQuery(r.Context(), queryID, "text", query, pq.lang, filterValues(r.FormValue), phoneIDs(ranked), start)
+
+	_ = sse.send("final", withSearchTags(r.Context(), withQueryInfo(map[string]any{
+		"results":  params.present(ranked),
+		"total":    len(ranked),
+		"degraded": degraded,
+		"time_ms":  time.Since(start).Milliseconds(),
+	}, pq), queryID))
+}
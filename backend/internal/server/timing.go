@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+)
+
+// serverTimingMiddleware breaks the latency of every request down in a
+// Server-Timing header: the embed and qdrant metrics are the time spent
+// embedding the query and querying Qdrant, when the request did, and total
+// the time until the response started.
+func serverTimingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tw := &timingWriter{ResponseWriter: w, start: time.Now()}
+		next.ServeHTTP(tw, r.WithContext(appqdrant.WithTimings(r.Context(), &tw.timings)))
+	})
+}
+
+// timingWriter sets the Server-Timing header before the response starts.
+type timingWriter struct {
+	http.ResponseWriter
+	start   time.Time
+	timings appqdrant.Timings
+	written bool
+}
+
+func (w *timingWriter) WriteHeader(status int) {
+	w.setHeader()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *timingWriter) setHeader() {
+	if w.written {
+		return
+	}
+
+	w.written = true
+
+	var metrics []string
+
+	if d := w.timings.Embed(); d > 0 {
+		metrics = append(metrics, timingMetric("embed", d))
+	}
+
+	if d := w.timings.Qdrant(); d > 0 {
+		metrics = append(metrics, timingMetric("qdrant", d))
+	}
+
+	metrics = append(metrics, timingMetric("total", time.Since(w.start)))
+
+	w.Header().Set("Server-Timing", strings.Join(metrics, ", "))
+}
+
+// timingMetric formats a Server-Timing metric with its duration in
+// milliseconds.
+func timingMetric(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.1f", name, float64(d.Microseconds())/1000)
+}
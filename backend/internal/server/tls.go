@@ -0,0 +1,56 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSOptions configures native HTTPS serving, either from certificate files
+// or with certificates obtained automatically from Let's Encrypt.
+type TLSOptions struct {
+	CertFile     string
+	KeyFile      string
+	ACMEDomains  []string
+	ACMEEmail    string
+	ACMECacheDir string
+}
+
+// Enabled reports whether HTTPS should be served.
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != "" || len(o.ACMEDomains) > 0
+}
+
+// Config builds the TLS configuration for the options. When ACME is used it
+// also returns the handler answering HTTP-01 challenges (and redirecting all
+// other plain HTTP traffic to HTTPS); it is nil for file-based certificates.
+func (o TLSOptions) Config() (*tls.Config, http.Handler, error) {
+	switch {
+	case len(o.ACMEDomains) > 0 && (o.CertFile != "" || o.KeyFile != ""):
+		return nil, nil, errors.New("certificate files and ACME domains are mutually exclusive")
+	case len(o.ACMEDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(o.ACMEDomains...),
+			Cache:      autocert.DirCache(o.ACMECacheDir),
+			Email:      o.ACMEEmail,
+		}
+
+		return manager.TLSConfig(), manager.HTTPHandler(nil), nil
+	case o.CertFile == "" || o.KeyFile == "":
+		return nil, nil, errors.New("both certificate and key files are required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading tls key pair: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil, nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/images"
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+)
+
+// uploadedImage returns the image to embed from an uploaded file and its
+// name: the photo cropped, downscaled and turned upright by
+// images.PrepareQuery, as a .jpg. HEIC/HEIF images, which iPhones take by
+// default and Go cannot decode, are first converted to JPEG by an external
+// decoder. On failure it writes the problem response and returns false.
+func uploadedImage(w http.ResponseWriter, r *http.Request, file multipart.File, filename string) (io.Reader, string, bool) {
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(file, head)
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "internal server error")
+		return nil, "", false
+	}
+
+	var src io.Reader = file
+
+	if images.IsHEIF(head[:n]) {
+		data, err := convertHEIF(r.Context(), file)
+
+		switch {
+		case errors.Is(err, images.ErrNoHEIFDecoder):
+			writeProblem(w, r, http.StatusUnsupportedMediaType, codeUnsupportedImage, "HEIC/HEIF images are not supported by this server")
+			return nil, "", false
+		case err != nil:
+			slog.WarnContext(r.Context(), "HEIF conversion failed", slog.String("error", err.Error()))
+			writeProblem(w, r, http.StatusBadRequest, codeInvalidImage, "the image could not be decoded")
+
+			return nil, "", false
+		}
+
+		src = bytes.NewReader(data)
+	}
+
+	data, err := prepareQueryImage(r.Context(), src)
+	if err != nil {
+		slog.WarnContext(r.Context(), "image preprocessing failed", slog.String("error", err.Error()))
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidImage, "the image could not be decoded")
+
+		return nil, "", false
+	}
+
+	return bytes.NewReader(data), strings.TrimSuffix(filename, filepath.Ext(filename)) + ".jpg", true
+}
+
+// convertHEIF converts an uploaded HEIC/HEIF image to JPEG.
+func convertHEIF(ctx context.Context, file io.Reader) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "image.convert_heif")
+	defer span.End()
+
+	data, err := images.HEIFToJPEG(ctx, file)
+	tracing.RecordError(span, err)
+
+	return data, err
+}
+
+// prepareQueryImage runs images.PrepareQuery on an uploaded image.
+func prepareQueryImage(ctx context.Context, src io.Reader) ([]byte, error) {
+	_, span := tracer.Start(ctx, "image.prepare")
+	defer span.End()
+
+	data, err := images.PrepareQuery(src)
+	tracing.RecordError(span, err)
+
+	return data, err
+}
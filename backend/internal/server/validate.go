@@ -0,0 +1,445 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	"github.com/alessandrolattao/qdrant-experiment/internal/currency"
+	"github.com/alessandrolattao/qdrant-experiment/internal/i18n"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	appqdrant "github.com/alessandrolattao/qdrant-experiment/internal/qdrant"
+	"github.com/alessandrolattao/qdrant-experiment/internal/specs"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	maxTextMatch = 100
+)
+
+// Allowed values for the enumerated filters, also served by /api/filters.
+var (
+	nfcValues         = []string{"Yes", "No"}
+	boolValues        = []string{"true", "false"}
+	networkValues     = []string{"5G", "LTE", "HSPA", "GSM"}
+	osValues          = []string{"Android", "iOS", "Windows", "Other"}
+	displayTypeValues = []string{"AMOLED", "OLED", "IPS", "TFT", "LCD", "Other"}
+)
+
+// unitSystems are the values of the units parameter.
+var unitSystems = []string{model.Metric, model.Imperial}
+
+// Response schemas selected by the schema parameter: v1 returns phones as
+// flat objects, v2 as model.SpecSheet sections.
+const (
+	schemaV1 = "v1"
+	schemaV2 = "v2"
+)
+
+var schemaVersions = []string{schemaV1, schemaV2}
+
+// filterFields are the parameters that narrow results, as opposed to paging.
+var filterFields = []string{
+	"brand", "network", "os", "display_type", "soc", "soc_tier", "lens", "color", "sensor", "band", "nfc",
+	"dual_sim", "esim", "sim_size",
+	"price_min", "price_max", "battery_min", "ram_min", "ram_max", "storage_min", "storage_max",
+	"screen_min", "screen_max", "weight_max", "announced_after", "announced_before", "announced_within",
+	"text_match",
+}
+
+// filterValues returns the non-empty filter parameters read through get.
+func filterValues(get func(string) string) map[string]string {
+	var filters map[string]string
+
+	for _, f := range filterFields {
+		if v := get(f); v != "" {
+			if filters == nil {
+				filters = map[string]string{}
+			}
+
+			filters[f] = v
+		}
+	}
+
+	return filters
+}
+
+// fieldError describes why a single request parameter was rejected.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+
+	// format and args keep the untranslated message for localization.
+	format string
+	args   []any
+}
+
+// validator collects parameter errors so they can be reported together.
+type validator struct {
+	get    func(string) string
+	errors []fieldError
+}
+
+// newValidator validates parameters read through get, typically r.FormValue.
+func newValidator(get func(string) string) *validator {
+	return &validator{get: get}
+}
+
+func (v *validator) fail(field, format string, args ...any) {
+	v.errors = append(v.errors, fieldError{Field: field, Message: fmt.Sprintf(format, args...), format: format, args: args})
+}
+
+// localized returns the collected errors with messages translated into lang.
+func (v *validator) localized(lang string) []fieldError {
+	errs := make([]fieldError, len(v.errors))
+	for i, e := range v.errors {
+		e.Message = i18n.Sprintf(lang, e.format, e.args...)
+		errs[i] = e
+	}
+
+	return errs
+}
+
+// enum returns the parameter value if it is empty or one of allowed.
+func (v *validator) enum(field string, allowed []string) string {
+	val := v.get(field)
+	if val == "" || slices.Contains(allowed, val) {
+		return val
+	}
+
+	v.fail(field, "must be one of %s", strings.Join(allowed, ", "))
+
+	return ""
+}
+
+// optionalBool parses an optional true or false; missing means nil.
+func (v *validator) optionalBool(field string) *bool {
+	switch v.enum(field, boolValues) {
+	case "true":
+		t := true
+		return &t
+	case "false":
+		f := false
+		return &f
+	default:
+		return nil
+	}
+}
+
+// nonNegativeFloat parses an optional number that must be >= 0; missing means 0.
+func (v *validator) nonNegativeFloat(field string) float64 {
+	val := v.get(field)
+	if val == "" {
+		return 0
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		v.fail(field, "must be a number")
+		return 0
+	}
+
+	if f < 0 {
+		v.fail(field, "must not be negative")
+		return 0
+	}
+
+	return f
+}
+
+// intRange parses an optional integer within [lo, hi], returning def when missing.
+func (v *validator) intRange(field string, def, lo, hi int) int {
+	val := v.get(field)
+	if val == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		v.fail(field, "must be an integer")
+		return def
+	}
+
+	if n < lo || n > hi {
+		v.fail(field, "must be between %d and %d", lo, hi)
+		return def
+	}
+
+	return n
+}
+
+// idList parses an optional comma-separated list of at most max phone IDs.
+func (v *validator) idList(field string, max int) []uint64 {
+	val := v.get(field)
+	if val == "" {
+		return nil
+	}
+
+	var ids []uint64
+
+	for item := range strings.SplitSeq(val, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(item), 10, 64)
+		if err != nil || id == 0 {
+			v.fail(field, "must be a comma-separated list of phone ids")
+			return nil
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(ids) > max {
+		v.fail(field, "must contain between %d and %d items", 1, max)
+		return nil
+	}
+
+	return ids
+}
+
+// bandRe matches a network band: b and an LTE band number, or n and a 5G NR
+// band number.
+var bandRe = regexp.MustCompile(`^([bn])([1-9]\d{0,2})$`)
+
+// bandList parses an optional comma-separated list of network bands such as
+// "n78,b20" into LTE and 5G NR band numbers.
+func (v *validator) bandList(field string) (lte, nr []int) {
+	val := v.get(field)
+	if val == "" {
+		return nil, nil
+	}
+
+	for item := range strings.SplitSeq(strings.ToLower(val), ",") {
+		m := bandRe.FindStringSubmatch(strings.TrimSpace(item))
+		if m == nil {
+			v.fail(field, "must be a comma-separated list of bands such as n78")
+			return nil, nil
+		}
+
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "b" {
+			lte = append(lte, n)
+		} else {
+			nr = append(nr, n)
+		}
+	}
+
+	return lte, nr
+}
+
+// timestamp parses an optional date or RFC 3339 timestamp, returning def
+// when missing.
+func (v *validator) timestamp(field string, def time.Time) time.Time {
+	val := v.get(field)
+	if val == "" {
+		return def
+	}
+
+	t, err := analytics.ParseTime(val)
+	if err != nil {
+		v.fail(field, "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+		return def
+	}
+
+	return t
+}
+
+// code returns invalid_filter when only filter parameters were rejected and
+// invalid_request otherwise.
+func (v *validator) code() string {
+	for _, e := range v.errors {
+		if !slices.Contains(filterFields, e.Field) {
+			return codeInvalidRequest
+		}
+	}
+
+	return codeInvalidFilter
+}
+
+// searchParams are the validated parameters shared by text and image search.
+type searchParams struct {
+	Filters appqdrant.SearchFilters
+	Limit   uint64
+	// Fields restricts the returned smartphone fields; empty means all.
+	Fields []string
+	// Stream selects NDJSON output instead of a single JSON document.
+	Stream bool
+	// Currency is the currency of the price bounds and of the returned
+	// normalized prices; empty means euros.
+	Currency string
+	// Units is the unit system of the returned measurements; empty means
+	// metric.
+	Units string
+	// Schema is the response schema of the returned phones; empty means v1.
+	Schema string
+
+	rates *currency.Rates
+}
+
+// parseSearchParams validates the filter and paging parameters of a search request.
+func (s *Server) parseSearchParams(r *http.Request) (searchParams, *validator) {
+	return s.parseSearchValues(newValidator(r.FormValue), wantsNDJSON(r))
+}
+
+// parseSearchValues validates search parameters from any source; stream
+// raises the limit bound for NDJSON exports. Price bounds given in another
+// currency are converted to euros, the currency of the index.
+func (s *Server) parseSearchValues(v *validator, stream bool) (searchParams, *validator) {
+	p := searchParams{rates: s.rates}
+
+	p.Filters.Brand = model.CanonicalBrand(v.get("brand"))
+	p.Filters.NetGen = v.enum("network", networkValues)
+	p.Filters.OS = v.enum("os", osValues)
+	p.Filters.DisplayType = v.enum("display_type", displayTypeValues)
+	p.Filters.SoCFamily = v.enum("soc", model.SoCFamilies)
+	p.Filters.SoCTier = v.enum("soc_tier", model.SoCTiers)
+	p.Filters.Lens = v.enum("lens", specs.LensTypes)
+	p.Filters.Color = v.enum("color", model.ColorFamilies)
+	p.Filters.Sensor = v.enum("sensor", model.Sensors)
+	p.Filters.LTEBands, p.Filters.NRBands = v.bandList("band")
+	p.Filters.DualSIM = v.optionalBool("dual_sim")
+	p.Filters.ESIM = v.optionalBool("esim")
+	p.Filters.SIMSize = v.enum("sim_size", model.SIMSizes)
+	p.Filters.PriceMin = v.nonNegativeFloat("price_min")
+	p.Filters.PriceMax = v.nonNegativeFloat("price_max")
+
+	if p.Filters.PriceMin > 0 && p.Filters.PriceMax > 0 && p.Filters.PriceMin > p.Filters.PriceMax {
+		v.fail("price_max", "must be greater than or equal to price_min")
+	}
+
+	p.Filters.BatteryMin = v.nonNegativeFloat("battery_min")
+	p.Filters.RAMMin = v.nonNegativeFloat("ram_min")
+	p.Filters.RAMMax = v.nonNegativeFloat("ram_max")
+	p.Filters.StorageMin = v.nonNegativeFloat("storage_min")
+	p.Filters.StorageMax = v.nonNegativeFloat("storage_max")
+	p.Filters.ScreenMin = v.nonNegativeFloat("screen_min")
+	p.Filters.ScreenMax = v.nonNegativeFloat("screen_max")
+	p.Filters.WeightMax = v.nonNegativeFloat("weight_max")
+
+	if p.Filters.ScreenMin > 0 && p.Filters.ScreenMax > 0 && p.Filters.ScreenMin > p.Filters.ScreenMax {
+		v.fail("screen_max", "must be greater than or equal to screen_min")
+	}
+
+	p.Filters.AnnouncedAfter = v.timestamp("announced_after", time.Time{})
+	p.Filters.AnnouncedBefore = v.timestamp("announced_before", time.Time{})
+
+	// announced_within=12 is shorthand for announced in the last 12 months,
+	// counted from midnight so the search cache key stays stable all day.
+	if months := v.intRange("announced_within", 0, 1, 1200); months > 0 {
+		within := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, -months, 0)
+		if within.After(p.Filters.AnnouncedAfter) {
+			p.Filters.AnnouncedAfter = within
+		}
+	}
+
+	if !p.Filters.AnnouncedAfter.IsZero() && !p.Filters.AnnouncedBefore.IsZero() && !p.Filters.AnnouncedBefore.After(p.Filters.AnnouncedAfter) {
+		v.fail("announced_before", "must be after announced_after")
+	}
+
+	// text_match narrows to the phones naming every one of its words in the
+	// model or the description, matched by the full-text indexes.
+	p.Filters.TextMatch = strings.TrimSpace(v.get("text_match"))
+	if utf8.RuneCountInString(p.Filters.TextMatch) > maxTextMatch {
+		v.fail("text_match", "must be between %d and %d characters", 1, maxTextMatch)
+	}
+
+	p.Units = v.enum("units", unitSystems)
+	p.Currency = v.enum("currency", currency.Supported)
+	if p.Currency != "" && p.Currency != currency.EUR {
+		p.Filters.PriceMin, _ = s.rates.ToEUR(p.Filters.PriceMin, p.Currency)
+		p.Filters.PriceMax, _ = s.rates.ToEUR(p.Filters.PriceMax, p.Currency)
+	}
+
+	switch v.enum("nfc", nfcValues) {
+	case "Yes":
+		t := true
+		p.Filters.NFC = &t
+	case "No":
+		f := false
+		p.Filters.NFC = &f
+	}
+
+	p.Stream = stream
+
+	limitMax := maxLimit
+	if p.Stream {
+		limitMax = maxStreamLimit
+	}
+
+	p.Limit = uint64(v.intRange("limit", defaultLimit, 1, limitMax))
+	p.Fields = v.fieldList("fields")
+	p.Schema = v.schema(p.Fields)
+
+	return p, v
+}
+
+// schema validates the schema parameter; field selection only applies to
+// the flat v1 schema.
+func (v *validator) schema(fields []string) string {
+	schema := v.enum("schema", schemaVersions)
+	if schema == schemaV2 && len(fields) > 0 {
+		v.fail("fields", "cannot be combined with schema=v2")
+	}
+
+	return schema
+}
+
+// present returns phones with their normalized prices and measurements in
+// the requested currency and units, projected to the requested fields.
+func (p searchParams) present(phones []model.Smartphone) any {
+	if p.localized() {
+		converted := make([]model.Smartphone, len(phones))
+		for i, phone := range phones {
+			converted[i] = p.localize(phone)
+		}
+
+		phones = converted
+	}
+
+	if p.Schema == schemaV2 {
+		sheets := make([]model.SpecSheet, len(phones))
+		for i, phone := range phones {
+			sheets[i] = phone.SpecSheet()
+		}
+
+		return sheets
+	}
+
+	return projectPhones(phones, p.Fields)
+}
+
+// presentOne is present for a single phone.
+func (p searchParams) presentOne(phone model.Smartphone) any {
+	if p.Schema == schemaV2 {
+		return p.localize(phone).SpecSheet()
+	}
+
+	return projectPhone(p.localize(phone), p.Fields)
+}
+
+// localized reports whether phones need converting from euros and metric.
+func (p searchParams) localized() bool {
+	return (p.Currency != "" && p.Currency != currency.EUR) || (p.Units != "" && p.Units != model.Metric)
+}
+
+// localize returns phone with its normalized price in p.Currency and its
+// measurements in p.Units.
+func (p searchParams) localize(phone model.Smartphone) model.Smartphone {
+	if price := phone.PriceNormalized; price != nil && p.Currency != "" && p.Currency != price.Currency {
+		if amount, ok := p.rates.FromEUR(price.Amount, p.Currency); ok {
+			phone.PriceNormalized = &model.Money{Amount: amount, Currency: p.Currency}
+		}
+	}
+
+	if size := phone.Measurements; size != nil && p.Units != "" && p.Units != size.System {
+		converted := size.In(p.Units)
+		phone.Measurements = &converted
+	}
+
+	return phone
+}
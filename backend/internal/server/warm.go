@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/embedder"
+)
+
+// WarmCache runs the n text searches most often logged in the last window,
+// with their filters and the default limit, and caches their results. It
+// loads the embedding models and the Qdrant pages popular searches use, so
+// the first users after a start or a seed do not pay for them. The searches
+// run one at a time and are not logged; warming stops at the first embedder
+// outage. Without analytics it does nothing.
+func (s *Server) WarmCache(ctx context.Context, n int, window time.Duration) {
+	if s.analytics == nil || n <= 0 {
+		return
+	}
+
+	start := time.Now()
+
+	searches, err := s.analytics.PopularSearches(start.Add(-window), n)
+	if err != nil {
+		slog.WarnContext(ctx, "loading popular searches failed", slog.String("error", err.Error()))
+		return
+	}
+
+	var warmed, failed int
+
+	for _, ps := range searches {
+		if ctx.Err() != nil {
+			break
+		}
+
+		params, v := s.savedSearchParams(ps.Filters)
+		if len(v.errors) > 0 {
+			// Logged before a filter was tightened or removed.
+			continue
+		}
+
+		phones, err := s.searcher.SearchByText(ctx, ps.Query, params.Limit, params.Filters)
+		if err != nil {
+			failed++
+
+			if errors.Is(err, embedder.ErrUnavailable) {
+				slog.WarnContext(ctx, "embedder unavailable, cache warming stopped", slog.Int("warmed", warmed))
+				return
+			}
+
+			slog.WarnContext(ctx, "warming search failed", slog.String("query", ps.Query), slog.String("error", err.Error()))
+
+			continue
+		}
+
+		s.storeSearch(ctx, searchCacheKey(ps.Query, params.Limit, params.Filters), phones)
+		warmed++
+	}
+
+	slog.InfoContext(ctx, "search cache warmed",
+		slog.Int("searches", warmed), slog.Int("failed", failed), slog.Duration("took", time.Since(start)))
+}
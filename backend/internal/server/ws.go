@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/analytics"
+	"github.com/alessandrolattao/qdrant-experiment/internal/i18n"
+	"github.com/alessandrolattao/qdrant-experiment/internal/model"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// wsDebounce is how long a query must stay unchanged before it is embedded.
+	wsDebounce = 250 * time.Millisecond
+	// wsMaxMessage bounds a single client message.
+	wsMaxMessage = 16 << 10
+)
+
+// wsQuery is a search-as-you-type message sent by the client. Params holds
+// the same filter, limit and fields parameters accepted by /api/search.
+type wsQuery struct {
+	ID     int64             `json:"id"`
+	Query  string            `json:"q"`
+	Params map[string]string `json:"params"`
+}
+
+// wsResult is pushed back for the latest query only; superseded queries are
+// dropped silently.
+type wsResult struct {
+	ID              int64        `json:"id"`
+	QueryID         string       `json:"query_id,omitempty"`
+	Variant         string       `json:"variant,omitempty"`
+	NormalizedQuery string       `json:"normalized_query,omitempty"`
+	Lang            string       `json:"lang,omitempty"`
+	TranslatedFrom  string       `json:"translated_from,omitempty"`
+	Results         any          `json:"results,omitempty"`
+	Total           int          `json:"total"`
+	Degraded        bool         `json:"degraded"`
+	TimeMs          int64        `json:"time_ms"`
+	Error           *wsError     `json:"error,omitempty"`
+	Errors          []fieldError `json:"errors,omitempty"`
+}
+
+// wsError reports why a query failed, using the problem codes of the HTTP API.
+type wsError struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// handleSearchWS upgrades to a WebSocket that accepts incremental queries.
+// Each new message cancels the pending or in-flight search of the previous
+// one, so only the last query typed after a short pause reaches the embedder.
+func (s *Server) handleSearchWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: originHosts(s.settings.Load().cors.AllowedOrigins),
+	})
+	if err != nil {
+		slog.WarnContext(r.Context(), "websocket upgrade failed", slog.String("error", err.Error()))
+		return
+	}
+	defer func() { _ = conn.CloseNow() }()
+
+	conn.SetReadLimit(wsMaxMessage)
+
+	ctx := r.Context()
+
+	var cancelPrev context.CancelFunc
+
+	defer func() {
+		if cancelPrev != nil {
+			cancelPrev()
+		}
+	}()
+
+	for {
+		var q wsQuery
+		if err := wsjson.Read(ctx, conn, &q); err != nil {
+			if websocket.CloseStatus(err) != websocket.StatusNormalClosure && !errors.Is(err, context.Canceled) {
+				slog.DebugContext(ctx, "websocket closed", slog.String("error", err.Error()))
+			}
+
+			return
+		}
+
+		if cancelPrev != nil {
+			cancelPrev()
+		}
+
+		searchCtx, cancel := context.WithCancel(ctx)
+		cancelPrev = cancel
+
+		go s.runWSQuery(searchCtx, conn, q)
+	}
+}
+
+// runWSQuery waits for the debounce delay, then searches and pushes results
+// unless ctx was cancelled by a newer query.
+func (s *Server) runWSQuery(ctx context.Context, conn *websocket.Conn, q wsQuery) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(wsDebounce):
+	}
+
+	if analytics.NormalizeQuery(q.Query) == "" {
+		return
+	}
+
+	params, v := s.parseSearchValues(newValidator(func(key string) string { return q.Params[key] }), false)
+	checkQuery(v, q.Query)
+
+	if len(v.errors) > 0 {
+		_ = wsjson.Write(ctx, conn, wsResult{ID: q.ID, Error: &wsError{Code: v.code()}, Errors: v.localized(i18n.Lang(ctx))})
+		return
+	}
+
+	start := time.Now()
+	pq := s.prepareQuery(ctx, q.Query)
+
+	var (
+		phones   []model.Smartphone
+		degraded bool
+	)
+
+	release, err := s.settings.Load().limiter.acquire(ctx)
+	if err == nil {
+		phones, err = s.searcher.SearchByText(ctx, pq.text, params.Limit, params.Filters)
+		release()
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "websocket search failed", slog.String("error", err.Error()))
+
+		if phones, degraded = s.browseFallback(ctx, err, params.Limit, params.Filters); !degraded {
+			_, code, detail := classifySearchError(err)
+			_ = wsjson.Write(ctx, conn, wsResult{ID: q.ID, Error: &wsError{Code: code, Detail: i18n.T(i18n.Lang(ctx), detail)}})
+
+			return
+		}
+	}
+
+	phones = s.rerankByCTR(ctx, pq.text, phones)
+
+	queryID := s.newQueryID(nil)
+	s.logQuery(ctx, queryID, "text", pq.text, pq.lang, filterValues(func(key string) string { return q.Params[key] }), phoneIDs(phones), start)
+
+	_ = wsjson.Write(ctx, conn, wsResult{
+		ID:              q.ID,
+		QueryID:         queryID,
+		Variant:         variantFrom(ctx),
+		NormalizedQuery: pq.text,
+		Lang:            pq.lang,
+		TranslatedFrom:  pq.translatedFrom,
+		Results:         params.present(phones),
+		Total:           len(phones),
+		Degraded:        degraded,
+		TimeMs:          time.Since(start).Milliseconds(),
+	})
+}
+
+// originHosts converts CORS origins ("https://app.example.com") into the
+// host patterns used by the WebSocket origin check.
+func originHosts(origins []string) []string {
+	hosts := make([]string, 0, len(origins))
+
+	for _, o := range origins {
+		if o == "*" {
+			hosts = append(hosts, "*")
+			continue
+		}
+
+		if u, err := url.Parse(o); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+
+	return hosts
+}
@@ -0,0 +1,226 @@
+// Package snapshot records the search responses of a running server for a
+// set of queries and compares later runs against them, so refactors of the
+// search pipeline are checked end to end against known rankings.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/bench"
+)
+
+// Hit is one ranked phone. Scores are rounded to 4 decimals so float noise
+// between runs does not count as a change.
+type Hit struct {
+	ID    uint64  `json:"id"`
+	Brand string  `json:"brand"`
+	Model string  `json:"model"`
+	Score float64 `json:"score"`
+}
+
+// Entry is the recorded response to one query.
+type Entry struct {
+	Query    string            `json:"q"`
+	Filters  map[string]string `json:"filters,omitempty"`
+	Total    int               `json:"total"`
+	Degraded bool              `json:"degraded,omitempty"`
+	Results  []Hit             `json:"results"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Runner searches the queries against the search API of a running server.
+// A nil Client uses http.DefaultClient.
+type Runner struct {
+	BaseURL string
+	Limit   int
+	Client  *http.Client
+}
+
+// Run searches every query once, in order. Failed searches are recorded
+// with their error, which a comparison reports like any other change.
+func (r *Runner) Run(ctx context.Context, queries []bench.Query) []Entry {
+	entries := make([]Entry, 0, len(queries))
+
+	for _, q := range queries {
+		e, err := r.search(ctx, q)
+		if err != nil {
+			e = Entry{Query: q.Query, Filters: q.Filters, Error: err.Error()}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// search returns the response to q. Results served from a search cache
+// filled by an earlier build would hide its changes, so snapshots are taken
+// against a fresh server or with the search_cache flag off.
+func (r *Runner) search(ctx context.Context, q bench.Query) (Entry, error) {
+	params := url.Values{}
+	for k, v := range q.Filters {
+		params.Set(k, v)
+	}
+
+	params.Set("q", q.Query)
+	params.Set("limit", strconv.Itoa(r.Limit))
+	params.Set("fields", "id,brand,model,score")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.BaseURL+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return Entry{}, fmt.Errorf("creating request: %w", err)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Entry{}, fmt.Errorf("searching: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return Entry{}, fmt.Errorf("search returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Total    int   `json:"total"`
+		Degraded bool  `json:"degraded"`
+		Results  []Hit `json:"results"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Entry{}, fmt.Errorf("decoding response: %w", err)
+	}
+
+	for i := range body.Results {
+		body.Results[i].Score = math.Round(body.Results[i].Score*1e4) / 1e4
+	}
+
+	return Entry{
+		Query:    q.Query,
+		Filters:  q.Filters,
+		Total:    body.Total,
+		Degraded: body.Degraded,
+		Results:  body.Results,
+	}, nil
+}
+
+// Load reads a snapshot written by Write.
+func Load(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Write stores entries as indented JSON, so changes review well in a diff.
+func Write(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(entries)
+}
+
+// Compare lists the differences between the recorded entries and the
+// current ones, matched by query and filters; nothing means no change.
+func Compare(recorded, current []Entry) []string {
+	var diffs []string
+
+	find := func(entries []Entry, e Entry) (Entry, bool) {
+		i := slices.IndexFunc(entries, func(o Entry) bool { return key(o) == key(e) })
+		if i < 0 {
+			return Entry{}, false
+		}
+
+		return entries[i], true
+	}
+
+	for _, cur := range current {
+		rec, ok := find(recorded, cur)
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: not in the snapshot", key(cur)))
+			continue
+		}
+
+		diffs = append(diffs, compareEntry(rec, cur)...)
+	}
+
+	for _, rec := range recorded {
+		if _, ok := find(current, rec); !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: no longer searched", key(rec)))
+		}
+	}
+
+	return diffs
+}
+
+// compareEntry lists the differences between two responses to one query.
+func compareEntry(rec, cur Entry) []string {
+	k := key(cur)
+
+	var diffs []string
+
+	if rec.Error != cur.Error {
+		diffs = append(diffs, fmt.Sprintf("%s: error %q, was %q", k, cur.Error, rec.Error))
+	}
+
+	if rec.Total != cur.Total {
+		diffs = append(diffs, fmt.Sprintf("%s: %d results, was %d", k, cur.Total, rec.Total))
+	}
+
+	if rec.Degraded != cur.Degraded {
+		diffs = append(diffs, fmt.Sprintf("%s: degraded %t, was %t", k, cur.Degraded, rec.Degraded))
+	}
+
+	for i := range max(len(rec.Results), len(cur.Results)) {
+		var was, now *Hit
+
+		if i < len(rec.Results) {
+			was = &rec.Results[i]
+		}
+
+		if i < len(cur.Results) {
+			now = &cur.Results[i]
+		}
+
+		switch {
+		case was == nil:
+			diffs = append(diffs, fmt.Sprintf("%s: #%d %s added", k, i+1, now))
+		case now == nil:
+			diffs = append(diffs, fmt.Sprintf("%s: #%d %s removed", k, i+1, was))
+		case *was != *now:
+			diffs = append(diffs, fmt.Sprintf("%s: #%d %s, was %s", k, i+1, now, was))
+		}
+	}
+
+	return diffs
+}
+
+// key identifies an entry by its query and filters.
+func key(e Entry) string {
+	if len(e.Filters) == 0 {
+		return strconv.Quote(e.Query)
+	}
+
+	filters, _ := json.Marshal(e.Filters)
+
+	return strconv.Quote(e.Query) + " " + string(filters)
+}
+
+func (h *Hit) String() string {
+	return fmt.Sprintf("%d %s %s (%.4f)", h.ID, h.Brand, h.Model, h.Score)
+}
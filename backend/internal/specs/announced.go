@@ -0,0 +1,52 @@
+package specs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// announcedRe matches the announcement part of "2020, November 03",
+// "2017, Q4", "2018, Oct" or "2013. Released 2013": the year, then an
+// optional quarter or month with an optional day.
+var announcedRe = regexp.MustCompile(`^(\d{4})(?:,\s*(?:Q([1-4])|([A-Za-z]{3})[A-Za-z]*(?:\s+(\d{1,2}))?))?`)
+
+// Announced returns the date of an "Announced" string in UTC. Missing parts
+// default to the start of the period, so "2017, Q4" is October 1 and "2013"
+// January 1. Values such as "Not announced yet" return false.
+func Announced(s string) (time.Time, bool) {
+	m := announcedRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	year, _ := strconv.Atoi(m[1])
+	month, day := time.January, 1
+
+	switch {
+	case m[2] != "":
+		quarter, _ := strconv.Atoi(m[2])
+		month = time.Month(quarter*3 - 2)
+	case m[3] != "":
+		t, err := time.Parse("Jan", strings.ToUpper(m[3][:1])+strings.ToLower(m[3][1:]))
+		if err != nil {
+			// A misspelled month still dates the phone to its year.
+			break
+		}
+
+		month = t.Month()
+
+		if m[4] != "" {
+			day, _ = strconv.Atoi(m[4])
+		}
+	}
+
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != month {
+		// Out-of-range days such as "February 30".
+		date = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return date, true
+}
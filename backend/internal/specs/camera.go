@@ -0,0 +1,90 @@
+package specs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Lens types, as labelled in the dataset's camera strings.
+const (
+	LensWide      = "wide"
+	LensUltrawide = "ultrawide"
+	LensTelephoto = "telephoto"
+	LensMacro     = "macro"
+	LensDepth     = "depth"
+)
+
+// LensTypes lists the lens types Lenses recognizes.
+var LensTypes = []string{LensWide, LensUltrawide, LensTelephoto, LensMacro, LensDepth}
+
+var (
+	// apertureRe matches "f/1.8".
+	apertureRe = regexp.MustCompile(`f/(\d+(?:\.\d+)?)`)
+	// lensLabelRe matches a parenthesized label such as "(ultrawide)".
+	lensLabelRe = regexp.MustCompile(`\(([^)]*)\)`)
+)
+
+// Lens is one camera of a phone. Type and Aperture are empty when the
+// camera string does not state them.
+type Lens struct {
+	MP       float64 `json:"mp"`
+	Type     string  `json:"type,omitempty"`
+	Aperture float64 `json:"aperture,omitempty"`
+}
+
+// Lenses splits a camera string such as "48 MP, f/1.8, 26mm (wide), PDAF
+// 8 MP, f/2.4, (telephoto)" into its lenses, main camera first. Periscope
+// lenses count as telephoto.
+func Lenses(s string) []Lens {
+	// Each lens description starts with its resolution.
+	starts := cameraRe.FindAllStringSubmatchIndex(s, -1)
+
+	lenses := make([]Lens, 0, len(starts))
+
+	for i, m := range starts {
+		end := len(s)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+
+		desc := s[m[0]:end]
+		lens := Lens{}
+		lens.MP, _ = strconv.ParseFloat(s[m[2]:m[3]], 64)
+
+		if a := apertureRe.FindStringSubmatch(desc); a != nil {
+			lens.Aperture, _ = strconv.ParseFloat(a[1], 64)
+		}
+
+		for _, t := range lensLabelRe.FindAllStringSubmatch(desc, -1) {
+			if typ := lensType(t[1]); typ != "" {
+				lens.Type = typ
+				break
+			}
+		}
+
+		lenses = append(lenses, lens)
+	}
+
+	return lenses
+}
+
+// lensType maps a parenthesized label to one of LensTypes, or "".
+func lensType(label string) string {
+	label = strings.ToLower(label)
+
+	switch {
+	case strings.Contains(label, "telephoto"):
+		return LensTelephoto
+	case strings.Contains(label, "ultrawide"):
+		return LensUltrawide
+	case strings.Contains(label, "wide"):
+		return LensWide
+	case strings.Contains(label, "macro"):
+		return LensMacro
+	case strings.Contains(label, "depth"):
+		return LensDepth
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,112 @@
+package specs
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// memoryRe matches one variant of "64GB 4GB RAM, 128GB 4GB RAM", a bare
+// storage size such as "192MB" or "500 KB", or a bare "512MB RAM".
+var memoryRe = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(TB|GB|MB|KB)(\s+RAM)?(?:\s+(\d+(?:\.\d+)?)\s*(GB|MB|KB)\s+RAM)?`)
+
+// MemoryVariant is one storage and RAM configuration of a phone, in GB.
+// Either is zero when the dataset does not state it.
+type MemoryVariant struct {
+	StorageGB float64 `json:"storage_gb,omitempty"`
+	RAMGB     float64 `json:"ram_gb,omitempty"`
+}
+
+// MemoryVariants splits an internal memory string such as "128GB 8GB RAM,
+// 256GB 12GB RAM" into its distinct variants, in the listed order. RAM
+// listed on its own ("64MB RAM, 128MB ROM") applies to the variants that do
+// not state theirs.
+func MemoryVariants(s string) []MemoryVariant {
+	var (
+		listed []MemoryVariant
+		ramGB  float64
+	)
+
+	for _, m := range memoryRe.FindAllStringSubmatch(s, -1) {
+		if m[3] != "" {
+			ramGB = max(ramGB, gigabytes(m[1], m[2]))
+			continue
+		}
+
+		v := MemoryVariant{StorageGB: gigabytes(m[1], m[2])}
+		if m[4] != "" {
+			v.RAMGB = gigabytes(m[4], m[5])
+		}
+
+		listed = append(listed, v)
+	}
+
+	if len(listed) == 0 && ramGB > 0 {
+		return []MemoryVariant{{RAMGB: ramGB}}
+	}
+
+	var variants []MemoryVariant
+
+	for _, v := range listed {
+		if v.RAMGB == 0 {
+			v.RAMGB = ramGB
+		}
+
+		if !slices.Contains(variants, v) {
+			variants = append(variants, v)
+		}
+	}
+
+	return variants
+}
+
+// Memory returns the largest storage and RAM, in GB, among the variants of
+// an internal memory string.
+func Memory(s string) (storageGB, ramGB float64) {
+	_, storageGB, _, ramGB = memoryBounds(MemoryVariants(s))
+	return storageGB, ramGB
+}
+
+// memoryBounds returns the smallest and largest known storage and RAM among
+// variants.
+func memoryBounds(variants []MemoryVariant) (storageMin, storageMax, ramMin, ramMax float64) {
+	for _, v := range variants {
+		storageMin, storageMax = bounds(storageMin, storageMax, v.StorageGB)
+		ramMin, ramMax = bounds(ramMin, ramMax, v.RAMGB)
+	}
+
+	return storageMin, storageMax, ramMin, ramMax
+}
+
+// bounds widens lo and hi, where zero means unknown, to include v > 0.
+func bounds(lo, hi, v float64) (float64, float64) {
+	if v <= 0 {
+		return lo, hi
+	}
+
+	if lo == 0 || v < lo {
+		lo = v
+	}
+
+	return lo, max(hi, v)
+}
+
+// gigabytes converts a size in unit (TB, GB, MB or KB) to GB.
+func gigabytes(size, unit string) float64 {
+	v, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0
+	}
+
+	switch strings.ToUpper(unit) {
+	case "TB":
+		return v * 1024
+	case "MB":
+		return v / 1024
+	case "KB":
+		return v / (1024 * 1024)
+	default:
+		return v
+	}
+}
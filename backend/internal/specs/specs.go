@@ -0,0 +1,176 @@
+// Package specs parses the free-text GSMArena spec strings into typed
+// numeric values that can be indexed, filtered and sorted on.
+package specs
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// batteryRe matches "Li-Po 5000 mAh, non-removable".
+	batteryRe = regexp.MustCompile(`(\d{3,5})\s*mAh`)
+	// weightRe matches "168 g (5.93 oz)".
+	weightRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*g\b`)
+	// screenRe matches "6.67 inches, 107.4 cm2".
+	screenRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*inches`)
+	// resolutionRe matches "1080 x 2400 pixels".
+	resolutionRe = regexp.MustCompile(`(\d+)\s*x\s*(\d+)\s*pixels`)
+	// cameraRe matches the main camera of "50 MP, f/1.8, (wide)".
+	cameraRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*MP`)
+)
+
+// Raw holds the spec strings Parse reads, as found in the dataset.
+type Raw struct {
+	Announced  string
+	Battery    string
+	Weight     string
+	ScreenSize string
+	Storage    string
+	Resolution string
+	Camera     string
+}
+
+// Specs are the numeric values parsed from a phone's spec strings. Zero
+// means the value is unknown.
+type Specs struct {
+	BatteryMAh   float64 `json:"battery_mah,omitempty"`
+	WeightG      float64 `json:"weight_g,omitempty"`
+	ScreenInches float64 `json:"screen_inches,omitempty"`
+	// RAMGB and StorageGB are the largest of the phone's memory variants,
+	// RAMMinGB and StorageMinGB the smallest.
+	RAMGB        float64 `json:"ram_gb,omitempty"`
+	StorageGB    float64 `json:"storage_gb,omitempty"`
+	RAMMinGB     float64 `json:"ram_min_gb,omitempty"`
+	StorageMinGB float64 `json:"storage_min_gb,omitempty"`
+	// MemoryVariants are the storage and RAM configurations sold.
+	MemoryVariants []MemoryVariant `json:"memory_variants,omitempty"`
+	// ResolutionWidth and ResolutionHeight are in pixels, as listed;
+	// ResolutionPixels is their product.
+	ResolutionWidth  int     `json:"resolution_width,omitempty"`
+	ResolutionHeight int     `json:"resolution_height,omitempty"`
+	ResolutionPixels int     `json:"resolution_pixels,omitempty"`
+	CameraMP         float64 `json:"camera_mp,omitempty"`
+	// Lenses are the phone's cameras, main camera first.
+	Lenses []Lens `json:"camera_lenses,omitempty"`
+	// Announced is the announcement date, see the Announced function.
+	Announced time.Time `json:"announced_date,omitzero"`
+}
+
+// Parse extracts every numeric spec from raw.
+func Parse(raw Raw) Specs {
+	s := Specs{
+		BatteryMAh:   Battery(raw.Battery),
+		WeightG:      Weight(raw.Weight),
+		ScreenInches: ScreenInches(raw.ScreenSize),
+		CameraMP:     CameraMP(raw.Camera),
+		Lenses:       Lenses(raw.Camera),
+	}
+
+	s.MemoryVariants = MemoryVariants(raw.Storage)
+	s.StorageMinGB, s.StorageGB, s.RAMMinGB, s.RAMGB = memoryBounds(s.MemoryVariants)
+	s.ResolutionWidth, s.ResolutionHeight = Resolution(raw.Resolution)
+	s.ResolutionPixels = s.ResolutionWidth * s.ResolutionHeight
+	s.Announced, _ = Announced(raw.Announced)
+
+	return s
+}
+
+// IsZero reports whether no spec could be parsed.
+func (s Specs) IsZero() bool {
+	return reflect.ValueOf(s).IsZero()
+}
+
+// Payload returns the specs as Qdrant payload fields, named after their JSON
+// keys. Unknown numbers are stored as zero; a known announcement date is
+// stored both as an RFC 3339 announced_date and as Unix seconds in
+// announced_at, and the memory variants and lenses as lists of objects.
+func (s Specs) Payload() map[string]any {
+	payload := map[string]any{
+		"battery_mah":       s.BatteryMAh,
+		"weight_g":          s.WeightG,
+		"screen_inches":     s.ScreenInches,
+		"ram_gb":            s.RAMGB,
+		"storage_gb":        s.StorageGB,
+		"ram_min_gb":        s.RAMMinGB,
+		"storage_min_gb":    s.StorageMinGB,
+		"resolution_width":  s.ResolutionWidth,
+		"resolution_height": s.ResolutionHeight,
+		"resolution_pixels": s.ResolutionPixels,
+		"camera_mp":         s.CameraMP,
+	}
+
+	if len(s.MemoryVariants) > 0 {
+		variants := make([]any, len(s.MemoryVariants))
+		for i, v := range s.MemoryVariants {
+			variants[i] = map[string]any{"storage_gb": v.StorageGB, "ram_gb": v.RAMGB}
+		}
+
+		payload["memory_variants"] = variants
+	}
+
+	if len(s.Lenses) > 0 {
+		lenses := make([]any, len(s.Lenses))
+		for i, l := range s.Lenses {
+			lenses[i] = map[string]any{"mp": l.MP, "type": l.Type, "aperture": l.Aperture}
+		}
+
+		payload["camera_lenses"] = lenses
+	}
+
+	if !s.Announced.IsZero() {
+		payload["announced_date"] = s.Announced.Format(time.RFC3339)
+		payload["announced_at"] = s.Announced.Unix()
+		payload["announced_year"] = s.Announced.Year()
+	}
+
+	return payload
+}
+
+// Battery returns the capacity in mAh of a battery string.
+func Battery(s string) float64 {
+	return first(batteryRe, s)
+}
+
+// Weight returns the grams of a weight string.
+func Weight(s string) float64 {
+	return first(weightRe, s)
+}
+
+// ScreenInches returns the diagonal of a screen size string.
+func ScreenInches(s string) float64 {
+	return first(screenRe, s)
+}
+
+// CameraMP returns the resolution of the first (main) camera in a camera
+// string.
+func CameraMP(s string) float64 {
+	return first(cameraRe, s)
+}
+
+// Resolution returns the width and height in pixels of a resolution string.
+func Resolution(s string) (width, height int) {
+	m := resolutionRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0
+	}
+
+	width, _ = strconv.Atoi(m[1])
+	height, _ = strconv.Atoi(m[2])
+
+	return width, height
+}
+
+// first returns the first number captured by re in s, or 0.
+func first(re *regexp.Regexp, s string) float64 {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+
+	v, _ := strconv.ParseFloat(m[1], 64)
+
+	return v
+}
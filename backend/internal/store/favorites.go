@@ -0,0 +1,102 @@
+package store
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const favoritesBucket = "favorites"
+
+// Favorites returns the phone IDs saved by owner, oldest first.
+func (s *Store) Favorites(owner string) ([]uint64, error) {
+	type favorite struct {
+		id    uint64
+		added uint64
+	}
+
+	var favs []favorite
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := ownerBucket(tx, favoritesBucket, owner, false)
+		if b == nil || err != nil {
+			return err
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			favs = append(favs, favorite{id: btoi(k), added: btoi(v)})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(favs, func(a, b favorite) int { return cmp.Compare(a.added, b.added) })
+
+	ids := make([]uint64, len(favs))
+	for i, f := range favs {
+		ids[i] = f.id
+	}
+
+	return ids, nil
+}
+
+// AddFavorite saves phone id for owner; adding it again keeps its position.
+func (s *Store) AddFavorite(owner string, id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := ownerBucket(tx, favoritesBucket, owner, true)
+		if err != nil {
+			return err
+		}
+
+		if b.Get(itob(id)) != nil {
+			return nil
+		}
+
+		return b.Put(itob(id), itob(uint64(time.Now().UnixNano())))
+	})
+}
+
+// RemoveFavorite deletes phone id from owner's favorites.
+func (s *Store) RemoveFavorite(owner string, id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := ownerBucket(tx, favoritesBucket, owner, false)
+		if b == nil || err != nil {
+			return err
+		}
+
+		return b.Delete(itob(id))
+	})
+}
+
+// MergeFavorites moves the favorites of from into to, e.g. when an anonymous
+// visitor logs in, keeping the earlier timestamp for phones in both lists.
+func (s *Store) MergeFavorites(from, to string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		src, err := ownerBucket(tx, favoritesBucket, from, false)
+		if src == nil || err != nil {
+			return err
+		}
+
+		dst, err := ownerBucket(tx, favoritesBucket, to, true)
+		if err != nil {
+			return err
+		}
+
+		err = src.ForEach(func(k, v []byte) error {
+			if existing := dst.Get(k); existing != nil && btoi(existing) <= btoi(v) {
+				return nil
+			}
+
+			return dst.Put(k, v)
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(favoritesBucket)).DeleteBucket([]byte(from))
+	})
+}
@@ -0,0 +1,243 @@
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// historyBucket holds one nested bucket per owner who enabled search
+// history, keyed by the time of each query.
+const historyBucket = "history"
+
+// HistoryEntry is a query recorded in a search history.
+type HistoryEntry struct {
+	Query   string            `json:"q"`
+	Filters map[string]string `json:"filters,omitempty"`
+	At      time.Time         `json:"at"`
+}
+
+// EnableHistory starts recording owner's searches.
+func (s *Store) EnableHistory(owner string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		_, err := ownerBucket(tx, historyBucket, owner, true)
+		return err
+	})
+}
+
+// DisableHistory stops recording owner's searches and views and deletes
+// both.
+func (s *Store) DisableHistory(owner string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range []string{historyBucket, viewsBucket} {
+			b, err := ownerBucket(tx, name, owner, false)
+			if err != nil {
+				return err
+			}
+
+			if b == nil {
+				continue
+			}
+
+			if err := tx.Bucket([]byte(name)).DeleteBucket([]byte(owner)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AddHistory records a query for owner if they enabled history. An earlier
+// entry for the same query is replaced, entries older than ttl are dropped
+// and only the newest limit entries are kept.
+func (s *Store) AddHistory(owner string, e HistoryEntry, ttl time.Duration, limit int) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding history entry: %w", err)
+	}
+
+	cutoff := e.At.Add(-ttl)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, historyBucket, owner, false)
+		if bucket == nil || err != nil {
+			return err
+		}
+
+		var stale [][]byte
+
+		kept := 0
+		c := bucket.Cursor()
+
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var old HistoryEntry
+			if err := json.Unmarshal(v, &old); err != nil {
+				return fmt.Errorf("decoding history entry: %w", err)
+			}
+
+			if old.Query == e.Query || old.At.Before(cutoff) || kept >= limit-1 {
+				stale = append(stale, k)
+				continue
+			}
+
+			kept++
+		}
+
+		for _, k := range stale {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return bucket.Put(itob(uint64(e.At.UnixNano())), b)
+	})
+}
+
+// History returns owner's entries newer than ttl, newest first, and whether
+// history is enabled for owner.
+func (s *Store) History(owner string, ttl time.Duration) ([]HistoryEntry, bool, error) {
+	var entries []HistoryEntry
+
+	enabled := false
+	cutoff := time.Now().Add(-ttl)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, historyBucket, owner, false)
+		if bucket == nil || err != nil {
+			return err
+		}
+
+		enabled = true
+		c := bucket.Cursor()
+
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e HistoryEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("decoding history entry: %w", err)
+			}
+
+			if e.At.Before(cutoff) {
+				break
+			}
+
+			entries = append(entries, e)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return entries, enabled, nil
+}
+
+// MergeHistory moves the history and views of from into to, e.g. when an
+// anonymous visitor logs in. History is enabled for to if it was enabled
+// for from.
+func (s *Store) MergeHistory(from, to string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range []string{historyBucket, viewsBucket} {
+			src, err := ownerBucket(tx, name, from, false)
+			if err != nil {
+				return err
+			}
+
+			if src == nil {
+				continue
+			}
+
+			dst, err := ownerBucket(tx, name, to, true)
+			if err != nil {
+				return err
+			}
+
+			if err := src.ForEach(dst.Put); err != nil {
+				return err
+			}
+
+			if err := tx.Bucket([]byte(name)).DeleteBucket([]byte(from)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// viewsBucket holds the phones recently viewed by owners who enabled search
+// history, mapping phone ID to view time.
+const viewsBucket = "views"
+
+// AddView records that owner viewed phone id if they enabled history,
+// keeping only the newest limit views.
+func (s *Store) AddView(owner string, id uint64, limit int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if h, err := ownerBucket(tx, historyBucket, owner, false); h == nil || err != nil {
+			return err
+		}
+
+		b, err := ownerBucket(tx, viewsBucket, owner, true)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Put(itob(id), itob(uint64(time.Now().UnixNano()))); err != nil {
+			return err
+		}
+
+		views := recentViews(b)
+		for _, v := range views[min(limit, len(views)):] {
+			if err := b.Delete(itob(v)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RecentViews returns the phones owner viewed, newest first.
+func (s *Store) RecentViews(owner string) ([]uint64, error) {
+	var ids []uint64
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := ownerBucket(tx, viewsBucket, owner, false)
+		if b == nil || err != nil {
+			return err
+		}
+
+		ids = recentViews(b)
+
+		return nil
+	})
+
+	return ids, err
+}
+
+func recentViews(b *bolt.Bucket) []uint64 {
+	type view struct {
+		id, at uint64
+	}
+
+	var views []view
+
+	_ = b.ForEach(func(k, v []byte) error {
+		views = append(views, view{id: btoi(k), at: btoi(v)})
+		return nil
+	})
+
+	slices.SortFunc(views, func(a, b view) int { return cmp.Compare(b.at, a.at) })
+
+	ids := make([]uint64, len(views))
+	for i, v := range views {
+		ids[i] = v.id
+	}
+
+	return ids
+}
@@ -0,0 +1,199 @@
+package store
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const savedSearchesBucket = "saved_searches"
+
+// SavedSearch is a named query with its filter parameters.
+type SavedSearch struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Query     string            `json:"q"`
+	Params    map[string]string `json:"params,omitempty"`
+	Notify    bool              `json:"notify"`
+	CreatedAt time.Time         `json:"created_at"`
+	// CheckedAt is when the results were last recorded in SeenIDs; zero
+	// until the search first runs, so there is nothing to compare against.
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+	// SeenIDs are the phones already reported to the owner.
+	SeenIDs []uint64 `json:"seen_ids,omitempty"`
+	// NewIDs are matches found after a reseed that the owner has not seen yet.
+	NewIDs []uint64 `json:"new_ids,omitempty"`
+}
+
+// SaveSearch stores ss for owner. A search without ID is created with a new
+// ID and creation time; otherwise the existing search is replaced, or
+// ErrNotFound returned if it was deleted.
+func (s *Store) SaveSearch(owner string, ss SavedSearch) (SavedSearch, error) {
+	create := ss.ID == ""
+	if create {
+		ss.ID = rand.Text()
+		ss.CreatedAt = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(ss)
+	if err != nil {
+		return SavedSearch{}, fmt.Errorf("encoding saved search: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, savedSearchesBucket, owner, create)
+		if err != nil {
+			return err
+		}
+
+		if !create && (bucket == nil || bucket.Get([]byte(ss.ID)) == nil) {
+			return ErrNotFound
+		}
+
+		return bucket.Put([]byte(ss.ID), b)
+	})
+	if err != nil {
+		return SavedSearch{}, err
+	}
+
+	return ss, nil
+}
+
+// SavedSearches returns owner's saved searches, oldest first.
+func (s *Store) SavedSearches(owner string) ([]SavedSearch, error) {
+	var searches []SavedSearch
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, savedSearchesBucket, owner, false)
+		if bucket == nil || err != nil {
+			return err
+		}
+
+		return bucket.ForEach(func(_, v []byte) error {
+			var ss SavedSearch
+			if err := json.Unmarshal(v, &ss); err != nil {
+				return fmt.Errorf("decoding saved search: %w", err)
+			}
+
+			searches = append(searches, ss)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(searches, func(a, b SavedSearch) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return searches, nil
+}
+
+// SavedSearch returns one of owner's saved searches.
+func (s *Store) SavedSearch(owner, id string) (SavedSearch, error) {
+	var ss SavedSearch
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, savedSearchesBucket, owner, false)
+		if err != nil {
+			return err
+		}
+
+		if bucket == nil {
+			return ErrNotFound
+		}
+
+		v := bucket.Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		return json.Unmarshal(v, &ss)
+	})
+
+	return ss, err
+}
+
+// DeleteSavedSearch removes one of owner's saved searches.
+func (s *Store) DeleteSavedSearch(owner, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, savedSearchesBucket, owner, false)
+		if err != nil {
+			return err
+		}
+
+		if bucket == nil || bucket.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+
+		return bucket.Delete([]byte(id))
+	})
+}
+
+// MergeSavedSearches moves the saved searches of from into to, e.g. when an
+// anonymous visitor logs in.
+func (s *Store) MergeSavedSearches(from, to string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		src, err := ownerBucket(tx, savedSearchesBucket, from, false)
+		if src == nil || err != nil {
+			return err
+		}
+
+		dst, err := ownerBucket(tx, savedSearchesBucket, to, true)
+		if err != nil {
+			return err
+		}
+
+		if err := src.ForEach(dst.Put); err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(savedSearchesBucket)).DeleteBucket([]byte(from))
+	})
+}
+
+// OwnedSearch is a saved search together with its owner key.
+type OwnedSearch struct {
+	Owner string
+	SavedSearch
+}
+
+// NotifyingSearches returns every saved search with notifications enabled,
+// across all owners.
+func (s *Store) NotifyingSearches() ([]OwnedSearch, error) {
+	var searches []OwnedSearch
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket([]byte(savedSearchesBucket))
+		if root == nil {
+			return nil
+		}
+
+		return root.ForEachBucket(func(owner []byte) error {
+			return root.Bucket(owner).ForEach(func(_, v []byte) error {
+				var ss SavedSearch
+				if err := json.Unmarshal(v, &ss); err != nil {
+					return fmt.Errorf("decoding saved search: %w", err)
+				}
+
+				if ss.Notify {
+					searches = append(searches, OwnedSearch{Owner: string(owner), SavedSearch: ss})
+				}
+
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(searches, func(a, b OwnedSearch) int { return cmp.Compare(a.Owner, b.Owner) })
+
+	return searches, nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	sharesBucket = "shares"
+	// shareTokenLen is the length of share tokens in base32 characters,
+	// 50 bits of randomness: short enough for a link, long enough not to be
+	// guessed.
+	shareTokenLen = 10
+)
+
+// Share is a search state published under a short token so it can be sent
+// to someone else.
+type Share struct {
+	Token     string            `json:"token"`
+	Query     string            `json:"q"`
+	Params    map[string]string `json:"params,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// CreateShare stores sh under a new random token.
+func (s *Store) CreateShare(sh Share) (Share, error) {
+	sh.CreatedAt = time.Now().UTC()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(sharesBucket))
+		if err != nil {
+			return fmt.Errorf("creating bucket %s: %w", sharesBucket, err)
+		}
+
+		// Draw again on the unlikely collision with an existing token.
+		for sh.Token == "" || bucket.Get([]byte(sh.Token)) != nil {
+			sh.Token = rand.Text()[:shareTokenLen]
+		}
+
+		b, err := json.Marshal(sh)
+		if err != nil {
+			return fmt.Errorf("encoding share: %w", err)
+		}
+
+		return bucket.Put([]byte(sh.Token), b)
+	})
+	if err != nil {
+		return Share{}, err
+	}
+
+	return sh, nil
+}
+
+// Share returns the share stored under token.
+func (s *Store) Share(token string) (Share, error) {
+	var sh Share
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(sharesBucket))
+		if bucket == nil {
+			return ErrNotFound
+		}
+
+		v := bucket.Get([]byte(token))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		return json.Unmarshal(v, &sh)
+	})
+
+	return sh, err
+}
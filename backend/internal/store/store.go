@@ -0,0 +1,70 @@
+// Package store persists per-user application state (favorites and the like)
+// in an embedded bbolt database.
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store is an embedded key-value database safe for concurrent use.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (or creates) the database at path.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating store dir: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening store: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ownerBucket returns the nested bucket of owner inside the top-level bucket
+// name, creating both when create is set. It returns nil if missing.
+func ownerBucket(tx *bolt.Tx, name, owner string, create bool) (*bolt.Bucket, error) {
+	if !create {
+		root := tx.Bucket([]byte(name))
+		if root == nil {
+			return nil, nil
+		}
+
+		return root.Bucket([]byte(owner)), nil
+	}
+
+	root, err := tx.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("creating bucket %s: %w", name, err)
+	}
+
+	b, err := root.CreateBucketIfNotExists([]byte(owner))
+	if err != nil {
+		return nil, fmt.Errorf("creating bucket for owner: %w", err)
+	}
+
+	return b, nil
+}
+
+func itob(v uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, v)
+}
+
+func btoi(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// usageBucket holds the request counts of each API key, keyed by key name.
+const usageBucket = "api_usage"
+
+// APIUsage is the request count of an API key in the current UTC day and
+// month, formatted 2006-01-02 and 2006-01.
+type APIUsage struct {
+	Day        string `json:"day"`
+	DayCount   int64  `json:"day_count"`
+	Month      string `json:"month"`
+	MonthCount int64  `json:"month_count"`
+}
+
+// APIUsage returns the stored request counts by API key name.
+func (s *Store) APIUsage() (map[string]APIUsage, error) {
+	usage := map[string]APIUsage{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(usageBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var u APIUsage
+			if err := json.Unmarshal(v, &u); err != nil {
+				return fmt.Errorf("decoding usage of %s: %w", k, err)
+			}
+
+			usage[string(k)] = u
+
+			return nil
+		})
+	})
+
+	return usage, err
+}
+
+// SaveAPIUsage stores the request counts of the given API keys, replacing
+// their earlier counts.
+func (s *Store) SaveAPIUsage(usage map[string]APIUsage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(usageBucket))
+		if err != nil {
+			return fmt.Errorf("creating bucket %s: %w", usageBucket, err)
+		}
+
+		for name, u := range usage {
+			v, err := json.Marshal(u)
+			if err != nil {
+				return fmt.Errorf("encoding usage: %w", err)
+			}
+
+			if err := b.Put([]byte(name), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	usersBucket        = "users"
+	usersByEmailBucket = "users_by_email"
+	sessionsBucket     = "sessions"
+)
+
+var (
+	// ErrNotFound is returned when a user or session does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrEmailTaken is returned when registering an email that already has an account.
+	ErrEmailTaken = errors.New("email already registered")
+)
+
+// User is a registered account.
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// userRecord is the stored form of a User, including the password hash.
+type userRecord struct {
+	User
+	PasswordHash []byte `json:"password_hash"`
+}
+
+type session struct {
+	UserID  string    `json:"user_id"`
+	Expires time.Time `json:"expires"`
+}
+
+// normalizeEmail makes email lookups case-insensitive.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CreateUser registers an account with an already hashed password.
+func (s *Store) CreateUser(email string, passwordHash []byte) (User, error) {
+	u := User{
+		ID:           rand.Text(),
+		Email:        normalizeEmail(email),
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		byEmail, err := tx.CreateBucketIfNotExists([]byte(usersByEmailBucket))
+		if err != nil {
+			return fmt.Errorf("creating bucket: %w", err)
+		}
+
+		if byEmail.Get([]byte(u.Email)) != nil {
+			return ErrEmailTaken
+		}
+
+		users, err := tx.CreateBucketIfNotExists([]byte(usersBucket))
+		if err != nil {
+			return fmt.Errorf("creating bucket: %w", err)
+		}
+
+		b, err := json.Marshal(userRecord{User: u, PasswordHash: u.PasswordHash})
+		if err != nil {
+			return fmt.Errorf("encoding user: %w", err)
+		}
+
+		if err := users.Put([]byte(u.ID), b); err != nil {
+			return err
+		}
+
+		return byEmail.Put([]byte(u.Email), []byte(u.ID))
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return u, nil
+}
+
+// UserByEmail looks up an account by email.
+func (s *Store) UserByEmail(email string) (User, error) {
+	var u User
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		byEmail := tx.Bucket([]byte(usersByEmailBucket))
+		if byEmail == nil {
+			return ErrNotFound
+		}
+
+		id := byEmail.Get([]byte(normalizeEmail(email)))
+		if id == nil {
+			return ErrNotFound
+		}
+
+		return getUser(tx, string(id), &u)
+	})
+
+	return u, err
+}
+
+// User looks up an account by ID.
+func (s *Store) User(id string) (User, error) {
+	var u User
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return getUser(tx, id, &u)
+	})
+
+	return u, err
+}
+
+func getUser(tx *bolt.Tx, id string, u *User) error {
+	users := tx.Bucket([]byte(usersBucket))
+	if users == nil {
+		return ErrNotFound
+	}
+
+	b := users.Get([]byte(id))
+	if b == nil {
+		return ErrNotFound
+	}
+
+	var rec userRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return fmt.Errorf("decoding user: %w", err)
+	}
+
+	*u = rec.User
+	u.PasswordHash = rec.PasswordHash
+
+	return nil
+}
+
+// CreateSession starts a session for userID and returns its bearer token.
+// Only a hash of the token is stored.
+func (s *Store) CreateSession(userID string, ttl time.Duration) (string, error) {
+	token := rand.Text() + rand.Text()
+
+	b, err := json.Marshal(session{UserID: userID, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return "", fmt.Errorf("encoding session: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		sessions, err := tx.CreateBucketIfNotExists([]byte(sessionsBucket))
+		if err != nil {
+			return fmt.Errorf("creating bucket: %w", err)
+		}
+
+		return sessions.Put(sessionKey(token), b)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// SessionUser returns the user owning an unexpired session token.
+func (s *Store) SessionUser(token string) (User, error) {
+	var u User
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket([]byte(sessionsBucket))
+		if sessions == nil {
+			return ErrNotFound
+		}
+
+		b := sessions.Get(sessionKey(token))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		var sess session
+		if err := json.Unmarshal(b, &sess); err != nil {
+			return fmt.Errorf("decoding session: %w", err)
+		}
+
+		if time.Now().After(sess.Expires) {
+			return ErrNotFound
+		}
+
+		return getUser(tx, sess.UserID, &u)
+	})
+
+	return u, err
+}
+
+// DeleteSession ends a session; unknown tokens are ignored.
+func (s *Store) DeleteSession(token string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket([]byte(sessionsBucket))
+		if sessions == nil {
+			return nil
+		}
+
+		return sessions.Delete(sessionKey(token))
+	})
+}
+
+func sessionKey(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
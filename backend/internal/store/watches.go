@@ -0,0 +1,178 @@
+package store
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const priceWatchesBucket = "price_watches"
+
+// PriceWatch asks to be notified when a phone, or any phone matching a query
+// and filter set, is priced at or below TargetEUR. Exactly one of PhoneID and
+// Query/Params is set.
+type PriceWatch struct {
+	ID        string            `json:"id"`
+	PhoneID   uint64            `json:"phone_id,omitempty"`
+	Query     string            `json:"q,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	TargetEUR float64           `json:"target_eur"`
+	// Email also notifies the owner's account address, not just webhooks.
+	Email     bool      `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	// CheckedAt is when the watch was last evaluated.
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+	// LastPriceEUR is the price of PhoneID at the last check, so a phone
+	// staying below target is reported once rather than after every reseed.
+	LastPriceEUR float64 `json:"last_price_eur,omitempty"`
+	// MatchedIDs are the phones under target at the last check of a filter
+	// watch, already reported to the owner.
+	MatchedIDs []uint64 `json:"matched_ids,omitempty"`
+	// TriggeredAt is when the watch last fired.
+	TriggeredAt time.Time `json:"triggered_at,omitzero"`
+}
+
+// SavePriceWatch stores pw for owner. A watch without ID is created with a
+// new ID and creation time; otherwise the existing watch is replaced, or
+// ErrNotFound returned if it was deleted.
+func (s *Store) SavePriceWatch(owner string, pw PriceWatch) (PriceWatch, error) {
+	create := pw.ID == ""
+	if create {
+		pw.ID = rand.Text()
+		pw.CreatedAt = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(pw)
+	if err != nil {
+		return PriceWatch{}, fmt.Errorf("encoding price watch: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, priceWatchesBucket, owner, create)
+		if err != nil {
+			return err
+		}
+
+		if !create && (bucket == nil || bucket.Get([]byte(pw.ID)) == nil) {
+			return ErrNotFound
+		}
+
+		return bucket.Put([]byte(pw.ID), b)
+	})
+	if err != nil {
+		return PriceWatch{}, err
+	}
+
+	return pw, nil
+}
+
+// PriceWatches returns owner's price watches, oldest first.
+func (s *Store) PriceWatches(owner string) ([]PriceWatch, error) {
+	var watches []PriceWatch
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, priceWatchesBucket, owner, false)
+		if bucket == nil || err != nil {
+			return err
+		}
+
+		return bucket.ForEach(func(_, v []byte) error {
+			var pw PriceWatch
+			if err := json.Unmarshal(v, &pw); err != nil {
+				return fmt.Errorf("decoding price watch: %w", err)
+			}
+
+			watches = append(watches, pw)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(watches, func(a, b PriceWatch) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return watches, nil
+}
+
+// DeletePriceWatch removes one of owner's price watches.
+func (s *Store) DeletePriceWatch(owner, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := ownerBucket(tx, priceWatchesBucket, owner, false)
+		if err != nil {
+			return err
+		}
+
+		if bucket == nil || bucket.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+
+		return bucket.Delete([]byte(id))
+	})
+}
+
+// MergePriceWatches moves the price watches of from into to, e.g. when an
+// anonymous visitor logs in.
+func (s *Store) MergePriceWatches(from, to string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		src, err := ownerBucket(tx, priceWatchesBucket, from, false)
+		if src == nil || err != nil {
+			return err
+		}
+
+		dst, err := ownerBucket(tx, priceWatchesBucket, to, true)
+		if err != nil {
+			return err
+		}
+
+		if err := src.ForEach(dst.Put); err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(priceWatchesBucket)).DeleteBucket([]byte(from))
+	})
+}
+
+// OwnedWatch is a price watch together with its owner key.
+type OwnedWatch struct {
+	Owner string
+	PriceWatch
+}
+
+// AllPriceWatches returns every price watch across all owners.
+func (s *Store) AllPriceWatches() ([]OwnedWatch, error) {
+	var watches []OwnedWatch
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket([]byte(priceWatchesBucket))
+		if root == nil {
+			return nil
+		}
+
+		return root.ForEachBucket(func(owner []byte) error {
+			return root.Bucket(owner).ForEach(func(_, v []byte) error {
+				var pw PriceWatch
+				if err := json.Unmarshal(v, &pw); err != nil {
+					return fmt.Errorf("decoding price watch: %w", err)
+				}
+
+				watches = append(watches, OwnedWatch{Owner: string(owner), PriceWatch: pw})
+
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(watches, func(a, b OwnedWatch) int { return cmp.Compare(a.Owner, b.Owner) })
+
+	return watches, nil
+}
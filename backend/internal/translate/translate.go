@@ -0,0 +1,111 @@
+// Package translate translates search queries to English, for the query
+// languages the embedding models rank poorly.
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/alessandrolattao/qdrant-experiment/internal/translate")
+
+// ErrUnavailable reports that the translation service could not be reached
+// or failed to translate.
+var ErrUnavailable = errors.New("translator unavailable")
+
+// Translator translates text from the language with ISO 639-1 code from to
+// English.
+type Translator interface {
+	Translate(ctx context.Context, text, from string) (string, error)
+}
+
+// Client translates through a LibreTranslate-compatible HTTP API, a
+// self-hosted LibreTranslate or one of the services speaking its protocol.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a translation client whose requests time out after
+// timeout. A non-empty apiKey is sent with every request.
+func NewClient(baseURL, apiKey string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type translateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type translateResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+// Translate implements Translator.
+func (c *Client) Translate(ctx context.Context, text, from string) (string, error) {
+	ctx, span := tracer.Start(ctx, "translate", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("translate.from", from)))
+	defer span.End()
+
+	translated, err := c.translate(ctx, text, from)
+	tracing.RecordError(span, err)
+
+	return translated, err
+}
+
+func (c *Client) translate(ctx context.Context, text, from string) (string, error) {
+	body, err := json.Marshal(translateRequest{Q: text, Source: from, Target: "en", Format: "text", APIKey: c.apiKey})
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result translateResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("%w: decoding response: %w", ErrUnavailable, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: status %d: %s", ErrUnavailable, resp.StatusCode, result.Error)
+	}
+
+	translated := strings.TrimSpace(result.TranslatedText)
+	if translated == "" {
+		return "", fmt.Errorf("%w: empty translation", ErrUnavailable)
+	}
+
+	return translated, nil
+}
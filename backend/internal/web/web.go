@@ -0,0 +1,74 @@
+// Package web embeds the built frontend so the server can ship as a single
+// binary. The dist directory is populated by copying the output of
+// `npm run build` from the frontend before compiling.
+package web
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// ErrNotBuilt is returned when the binary was compiled without a frontend build.
+var ErrNotBuilt = errors.New("frontend not embedded: copy frontend/dist into internal/web/dist and rebuild")
+
+// Handler serves the embedded SPA. Existing files are served as-is, hashed
+// build assets are cached indefinitely, and any other path falls back to
+// index.html so client-side routes survive a page reload.
+func Handler() (http.Handler, error) {
+	fsys, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := fs.Stat(fsys, "index.html"); err != nil {
+		return nil, ErrNotBuilt
+	}
+
+	files := http.FileServerFS(fsys)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Unknown API routes must stay 404 rather than returning the SPA shell.
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" || name == "index.html" {
+			serveIndex(w, r, fsys)
+			return
+		}
+
+		info, err := fs.Stat(fsys, name)
+		if err != nil || info.IsDir() {
+			serveIndex(w, r, fsys)
+			return
+		}
+
+		if strings.HasPrefix(name, "assets/") {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+
+		files.ServeHTTP(w, r)
+	}), nil
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request, fsys fs.FS) {
+	w.Header().Set("Cache-Control", "no-cache")
+
+	data, err := fs.ReadFile(fsys, "index.html")
+	if err != nil {
+		http.Error(w, "index.html missing", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,268 @@
+// Package webhook delivers signed catalog event notifications to configured
+// HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alessandrolattao/qdrant-experiment/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/alessandrolattao/qdrant-experiment/internal/webhook")
+
+// Event types.
+const (
+	SeedCompleted = "seed.completed"
+	PhoneAdded    = "phone.added"
+	PhoneUpdated  = "phone.updated"
+	PhoneDeleted  = "phone.deleted"
+	// SavedSearchMatches reports phones newly matching a saved search after
+	// a reseed.
+	SavedSearchMatches = "saved_search.matches"
+	// PriceWatchTriggered reports phones priced at or below the target of a
+	// price watch.
+	PriceWatchTriggered = "price_watch.triggered"
+)
+
+// Signature headers sent with every delivery. The signature is the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the shared secret.
+const (
+	EventHeader     = "X-Webhook-Event"
+	IDHeader        = "X-Webhook-ID"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	queueSize   = 256
+	maxAttempts = 5
+	baseBackoff = time.Second
+)
+
+// Event is the JSON body posted to each endpoint.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Dispatcher queues events and delivers them in the background, retrying
+// failed deliveries with exponential backoff. A nil Dispatcher drops events,
+// so callers need not check whether webhooks are configured.
+type Dispatcher struct {
+	urls   []string
+	secret []byte
+	client *http.Client
+
+	queue chan Event
+	// mu guards closed so late events, e.g. from a seed finishing during
+	// shutdown, are dropped instead of sent on a closed queue.
+	mu     sync.RWMutex
+	closed bool
+
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New starts a Dispatcher posting to urls, signing with secret. It returns
+// nil when no URLs are configured.
+func New(urls []string, secret string) (*Dispatcher, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	if secret == "" {
+		return nil, errors.New("a webhook secret is required to sign payloads")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := &Dispatcher{
+		urls:   urls,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan Event, queueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	go d.run()
+
+	return d, nil
+}
+
+// Send queues an event without blocking; it is dropped when the queue is
+// full or the Dispatcher is closed.
+func (d *Dispatcher) Send(ctx context.Context, eventType string, data any) {
+	if d == nil {
+		return
+	}
+
+	ev := Event{ID: rand.Text(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		slog.WarnContext(ctx, "webhooks closed, dropping event", slog.String("event", eventType))
+		return
+	}
+
+	select {
+	case d.queue <- ev:
+	default:
+		slog.WarnContext(ctx, "webhook queue full, dropping event", slog.String("event", eventType))
+	}
+}
+
+// Close stops accepting events and waits for queued deliveries, abandoning
+// them when ctx expires. Events sent afterwards are dropped.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	defer d.cancel()
+
+	for ev := range d.queue {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			slog.Error("encoding webhook event", slog.String("event", ev.Type), slog.String("error", err.Error()))
+			continue
+		}
+
+		for _, url := range d.urls {
+			d.deliver(ev, url, body)
+		}
+	}
+}
+
+// deliver posts body to url, retrying network errors, 429 and 5xx responses.
+func (d *Dispatcher) deliver(ev Event, url string, body []byte) {
+	ctx, span := tracer.Start(d.ctx, "webhook.deliver", trace.WithAttributes(
+		attribute.String("webhook.event", ev.Type),
+		attribute.String("webhook.url", url),
+	))
+	defer span.End()
+
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		var retry bool
+
+		retry, err = d.post(ctx, ev, url, body)
+		if err == nil || !retry || attempt == maxAttempts {
+			break
+		}
+
+		if !sleep(ctx, baseBackoff<<(attempt-1)) {
+			err = fmt.Errorf("giving up after attempt %d: %w", attempt, err)
+			break
+		}
+	}
+
+	tracing.RecordError(span, err)
+
+	if err != nil {
+		slog.Warn("webhook delivery failed",
+			slog.String("event", ev.Type),
+			slog.String("url", url),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// sleep waits for d, returning false if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is retryable.
+func (d *Dispatcher) post(ctx context.Context, ev Event, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, ev.Type)
+	req.Header.Set(IDHeader, ev.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(d.secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("sending request: %w", err)
+	}
+
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return true, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret,
+// as sent in the signature header.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -52,6 +52,23 @@ async def embed_texts(req: TextsRequest):
     return {"embeddings": embeddings}
 
 
+def token_embeddings(texts: list[str]) -> list[list[list[float]]]:
+    # Per-token vectors for late interaction (MaxSim), normalized like the
+    # pooled ones so cosine and dot product agree.
+    outputs = text_model.encode(texts, output_value="token_embeddings")
+    return [torch.nn.functional.normalize(t, dim=-1).tolist() for t in outputs]
+
+
+@app.post("/embed/text/tokens")
+async def embed_text_tokens(req: TextRequest):
+    return {"tokens": token_embeddings([req.text])[0]}
+
+
+@app.post("/embed/texts/tokens")
+async def embed_texts_tokens(req: TextsRequest):
+    return {"tokens": token_embeddings(req.texts)}
+
+
 @app.post("/embed/image")
 async def embed_image(file: UploadFile = File(...)):
     contents = await file.read()